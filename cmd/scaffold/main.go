@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"goilerplate/pkg/scaffold"
+)
+
+func main() {
+	var (
+		name        = flag.String("name", "", "Resource name in singular form, e.g. product or purchase_order")
+		fields      = flag.String("fields", "", "Field spec: name:type[:required][:unique][:search],...")
+		scopes      = flag.String("scopes", scaffold.ScopePublic, "Route scopes to register: public, partner, internal (comma separated)")
+		withGrpc    = flag.Bool("grpc", false, "Generate and register a gRPC handler (requires the proto in goilerplate-proto)")
+		idempotency = flag.Bool("idempotency", false, "Require an Idempotency-Key header on the public POST route")
+		force       = flag.Bool("force", false, "Overwrite generated files that already exist")
+		root        = flag.String("root", ".", "Repository root")
+	)
+	flag.Parse()
+
+	if *name == "" || *fields == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	parsedFields, err := scaffold.ParseFields(*fields)
+	if err != nil {
+		log.Fatalf("Invalid field spec: %v", err)
+	}
+
+	var scopeList []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopeList = append(scopeList, scope)
+		}
+	}
+
+	resource, err := scaffold.NewResource(*name, parsedFields, scopeList, *withGrpc)
+	if err != nil {
+		log.Fatalf("Invalid resource: %v", err)
+	}
+
+	generator := &scaffold.Generator{Root: *root, Force: *force}
+	if err := generator.Generate(resource, *idempotency); err != nil {
+		log.Fatalf("Failed to scaffold %s: %v", resource.Name, err)
+	}
+
+	fmt.Printf("\nResource %s scaffolded successfully!\n", resource.Name)
+	fmt.Println("Next steps:")
+	fmt.Println("  1. Review the generated files and adjust business rules in the domain usecase")
+	fmt.Println("  2. Seed the permissions for the new resource and assign them to roles")
+	fmt.Println("  3. Run the migration: make migrate-up")
+	if *withGrpc {
+		fmt.Printf("  4. Make sure goilerplate-proto provides %s/v1 with the %sService definition\n", resource.Package, resource.Name)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: scaffold [options]")
+	fmt.Println("")
+	fmt.Println("Options:")
+	fmt.Println("  -name string")
+	fmt.Println("        Resource name in singular form (required)")
+	fmt.Println("  -fields string")
+	fmt.Println("        Field spec: name:type[:required][:unique][:search],... (required)")
+	fmt.Println("        Types: string, text, uuid, int, int64, float64, bool, time")
+	fmt.Println("  -scopes string")
+	fmt.Println("        Route scopes to register: public, partner, internal (default: public)")
+	fmt.Println("  -grpc")
+	fmt.Println("        Generate and register a gRPC handler")
+	fmt.Println("  -idempotency")
+	fmt.Println("        Require an Idempotency-Key header on the public POST route")
+	fmt.Println("  -force")
+	fmt.Println("        Overwrite generated files that already exist")
+	fmt.Println("  -root string")
+	fmt.Println("        Repository root (default: .)")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  scaffold -name=product -fields=\"code:string:required:unique:search,name:string:required:search,price:float64\"")
+	fmt.Println("  scaffold -name=purchase_order -fields=\"number:string:required:unique,ordered_at:time:required\" -scopes=public,internal")
+}
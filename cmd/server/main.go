@@ -40,11 +40,11 @@ func main() {
 	// 4. Register gRPC services
 	wired.GrpcHandlers.ServiceRegistry.Register(app.GrpcServer)
 
-	// 5. Start the servers
-	start(app)
+	// 5. Start the servers and background jobs
+	start(app, wired)
 }
 
-func start(app *bootstrap.App) {
+func start(app *bootstrap.App, wired *wire.ApplicationContainer) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -72,10 +72,15 @@ func start(app *bootstrap.App) {
 		}()
 	}
 
+	wired.Jobs.Scheduler.Start(ctx)
+
 	<-ctx.Done()
 
 	fmt.Printf("\n\nShutting down server...\n\n")
 
+	// Jobs stop with ctx, wait for running ones before connections are closed
+	wired.Jobs.Scheduler.Wait()
+
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -4,22 +4,29 @@ import (
 	"time"
 
 	"goilerplate/pkg/filesystem"
+	"goilerplate/pkg/logger"
+	"goilerplate/pkg/search"
 )
 
 type Config struct {
-	App        App                `mapstructure:"app"`
-	Server     Server             `mapstructure:"server"`
-	GRPC       GRPC               `mapstructure:"grpc"`
-	DB         DB                 `mapstructure:"db"`
-	Redis      Redis              `mapstructure:"redis"`
-	JWT        JWT                `mapstructure:"jwt"`
-	Log        *Logger            `mapstructure:"log"`
-	OTel       OTel               `mapstructure:"otel"`
-	RateLimit  RateLimit          `mapstructure:"rate_limit"`
-	FileSystem FileSystem         `mapstructure:"filesystem"`
-	Crypto     Crypto             `mapstructure:"crypto"`
-	Apikeys    map[string]string  `mapstructure:"api_key"`
-	Services   map[string]Service `mapstructure:"service"`
+	App         App                      `mapstructure:"app"`
+	Server      Server                   `mapstructure:"server"`
+	GRPC        GRPC                     `mapstructure:"grpc"`
+	DB          DB                       `mapstructure:"db"`
+	Redis       Redis                    `mapstructure:"redis"`
+	JWT         JWT                      `mapstructure:"jwt"`
+	Log         *logger.Config           `mapstructure:"log"`
+	OTel        OTel                     `mapstructure:"otel"`
+	RateLimit   RateLimit                `mapstructure:"rate_limit"`
+	FileSystem  FileSystem               `mapstructure:"filesystem"`
+	Crypto      Crypto                   `mapstructure:"crypto"`
+	Bulk        Bulk                     `mapstructure:"bulk"`
+	Trash       Trash                    `mapstructure:"trash"`
+	Concurrency Concurrency              `mapstructure:"concurrency"`
+	Search      map[string]search.Config `mapstructure:"search"` // keyed by resource, e.g. "bars"
+	Cache       map[string]EntityCache   `mapstructure:"cache"`  // keyed by resource, e.g. "bars"
+	Apikeys     map[string]string        `mapstructure:"api_key"`
+	Services    map[string]Service       `mapstructure:"service"`
 }
 
 type RateLimit struct {
@@ -104,17 +111,68 @@ type JWT struct {
 	Issuer             string        `mapstructure:"issuer"`
 }
 
-type Logger struct {
-	Level  string `mapstructure:"level"`
-	Source bool   `mapstructure:"source"`
+type FileSystem struct {
+	Driver      string                           `mapstructure:"driver"`        // local, s3, drive, memory
+	MaxFileSize int64                            `mapstructure:"max_file_size"` // Maximum file size in bytes
+	URLTTL      time.Duration                    `mapstructure:"url_ttl"`       // Lifetime of signed download URLs, defaults to 15m
+	UploadTTL   time.Duration                    `mapstructure:"upload_ttl"`    // Lifetime of presigned uploads, defaults to 15m
+	Resumable   ResumableUpload                  `mapstructure:"resumable"`
+	Local       filesystem.LocalConfig           `mapstructure:"local"`
+	S3          filesystem.S3Config              `mapstructure:"s3"`
+	Drive       filesystem.DriveConfig           `mapstructure:"drive"`
+	Images      filesystem.ImageConfig           `mapstructure:"images"` // Variants generated for uploaded images, none when empty
+	Policies    UploadPolicies                   `mapstructure:"policies"`
+	Quota       StorageQuota                     `mapstructure:"quota"`
+	Scan        filesystem.ScanConfig            `mapstructure:"scan"`       // Malware scanning of uploads, disabled when the driver is empty
+	Encryption  filesystem.EncryptionConfig      `mapstructure:"encryption"` // Encryption at rest of the default disk, disabled when key_id is empty
+	Mirror      string                           `mapstructure:"mirror"`     // Disk receiving a copy of every write to the default disk
+	Disks       map[string]filesystem.DiskConfig `mapstructure:"disks"`      // Named disks, reachable through Manager.Disk
 }
 
-type FileSystem struct {
-	Driver      string                 `mapstructure:"driver"`        // local, s3, drive
-	MaxFileSize int64                  `mapstructure:"max_file_size"` // Maximum file size in bytes
-	Local       filesystem.LocalConfig `mapstructure:"local"`
-	S3          filesystem.S3Config    `mapstructure:"s3"`
-	Drive       filesystem.DriveConfig `mapstructure:"drive"`
+// UploadPolicies restrict the files accepted per upload route
+type UploadPolicies struct {
+	Upload    filesystem.UploadPolicy `mapstructure:"upload"`    // POST /files
+	Multiple  filesystem.UploadPolicy `mapstructure:"multiple"`  // POST /files/multiple
+	Presign   filesystem.UploadPolicy `mapstructure:"presign"`   // POST /files/presign
+	Resumable filesystem.UploadPolicy `mapstructure:"resumable"` // tus uploads, max_size defaults to resumable.max_size
+}
+
+// StorageQuota limits the bytes stored per user and per tenant, variants and unfinished uploads included
+type StorageQuota struct {
+	User              int64         `mapstructure:"user"`               // Maximum bytes per user, 0 is unlimited
+	Tenant            int64         `mapstructure:"tenant"`             // Maximum bytes per tenant (store), 0 is unlimited
+	ReconcileEnabled  bool          `mapstructure:"reconcile_enabled"`  // Run the background reconciliation of stored sizes
+	ReconcileInterval time.Duration `mapstructure:"reconcile_interval"` // Time between reconciliation runs, defaults to 24h
+}
+
+// ResumableUpload configures tus uploads
+type ResumableUpload struct {
+	MaxSize         int64         `mapstructure:"max_size"`         // Maximum size in bytes, defaults to filesystem.max_file_size
+	TTL             time.Duration `mapstructure:"ttl"`              // Time to finish an upload, defaults to 24h
+	StagingPath     string        `mapstructure:"staging_path"`     // Local folder staging chunks, defaults to a temporary folder
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"` // Time between removals of expired uploads, defaults to 1h
+}
+
+type Bulk struct {
+	MaxBatchSize  int `mapstructure:"max_batch_size"`  // Maximum items per bulk request, defaults to 100
+	MaxImportRows int `mapstructure:"max_import_rows"` // Maximum rows per import file, defaults to 10000
+}
+
+type Trash struct {
+	PurgeEnabled  bool          `mapstructure:"purge_enabled"`  // Run the background purge of soft deleted records
+	RetentionDays int           `mapstructure:"retention_days"` // Days a soft deleted record stays in the trash, defaults to 30
+	PurgeInterval time.Duration `mapstructure:"purge_interval"` // Time between purge runs, defaults to 24h
+}
+
+type Concurrency struct {
+	RequireIfMatch bool `mapstructure:"require_if_match"` // Reject updates and deletes without If-Match (gRPC: x-expected-version) with 428
+}
+
+// EntityCache configures the read-through cache of a resource, it is only used when Redis is enabled
+type EntityCache struct {
+	TTL             time.Duration `mapstructure:"ttl"`              // Lifetime of cached entities, defaults to 5m (1m for bars)
+	NegativeTTL     time.Duration `mapstructure:"negative_ttl"`     // Lifetime of not found results, defaults to 30s, negative disables them
+	InvalidateAgain time.Duration `mapstructure:"invalidate_again"` // Delay of the repeated invalidation after a write, defaults to 1s, negative disables it
 }
 
 type Crypto struct {
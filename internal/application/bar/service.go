@@ -10,6 +10,7 @@ import (
 // ApplicationService handles multi-domain orchestration
 type ApplicationService interface {
 	CreateSomething(ctx context.Context, exp *Exp) error
+	ImportBars(ctx context.Context, entities []*bar.Bar, dryRun bool) (*bar.BulkResult, error)
 }
 
 type applicationService struct {
@@ -43,3 +44,19 @@ func (s *applicationService) CreateSomething(ctx context.Context, exp *Exp) erro
 		return nil
 	})
 }
+
+// ImportBars imports the rows in a single transaction, a dry run only validates them
+func (s *applicationService) ImportBars(ctx context.Context, entities []*bar.Bar, dryRun bool) (*bar.BulkResult, error) {
+	if dryRun {
+		return s.barUC.Import(ctx, entities, true)
+	}
+
+	var result *bar.BulkResult
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		result, err = s.barUC.Import(txCtx, entities, false)
+		return err
+	})
+
+	return result, err
+}
@@ -30,7 +30,7 @@ type App struct {
 
 func Init() *App {
 	cfg := Load()
-	log := logger.NewSlog(cfg)
+	log := logger.NewSlog(cfg.Log)
 
 	tp, err := NewTracerProvider(cfg)
 	if err != nil {
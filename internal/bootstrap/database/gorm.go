@@ -3,6 +3,8 @@ package bootstrap
 import (
 	"fmt"
 	"goilerplate/config"
+	"goilerplate/internal/infrastructure/plugin"
+	"goilerplate/pkg/utils"
 	"log/slog"
 	"os"
 	"strings"
@@ -53,6 +55,7 @@ func NewGorm(cfg *config.Config, log *slog.Logger) *gorm.DB {
 		SkipDefaultTransaction: true,
 		PrepareStmt:            true,
 		QueryFields:            true,
+		NowFunc:                utils.Now,
 		Logger: logger.New(NewSlogWriter(log), logger.Config{
 			SlowThreshold:             time.Second * 5,
 			Colorful:                  false,
@@ -70,6 +73,16 @@ func NewGorm(cfg *config.Config, log *slog.Logger) *gorm.DB {
 		log.Error(fmt.Sprintf("failed to register GORM OTel plugin: %v", err))
 	}
 
+	if err := gdb.Use(plugin.NewAudit()); err != nil {
+		log.Error(fmt.Sprintf("failed to register GORM audit plugin: %v", err))
+		os.Exit(1)
+	}
+
+	if err := gdb.Use(plugin.NewErrorTranslator()); err != nil {
+		log.Error(fmt.Sprintf("failed to register GORM error translator plugin: %v", err))
+		os.Exit(1)
+	}
+
 	connection, err := gdb.DB()
 	if err != nil {
 		log.Error(fmt.Sprintf("failed to get sql.DB from gorm: %v", err))
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	bardomain "goilerplate/internal/domain/bar"
+	"goilerplate/pkg/etag"
 	"goilerplate/pkg/grpcresponse"
+	"goilerplate/pkg/listquery"
 	"goilerplate/pkg/pagination"
 
 	pb "github.com/arisatriop/goilerplate-proto/bar/v1"
@@ -16,11 +18,14 @@ import (
 
 type Bar struct {
 	pb.UnimplementedBarServiceServer
-	uc bardomain.Usecase
+	uc             bardomain.Usecase
+	requireVersion bool
 }
 
-func NewBar(uc bardomain.Usecase) *Bar {
-	return &Bar{uc: uc}
+// NewBar creates the bar service. With requireVersion, updates and deletes must send
+// x-expected-version metadata, the gRPC counterpart of If-Match.
+func NewBar(uc bardomain.Usecase, requireVersion bool) *Bar {
+	return &Bar{uc: uc, requireVersion: requireVersion}
 }
 
 func (b *Bar) CreateBar(ctx context.Context, req *pb.CreateBarRequest) (*pb.Bar, error) {
@@ -34,6 +39,10 @@ func (b *Bar) CreateBar(ctx context.Context, req *pb.CreateBarRequest) (*pb.Bar,
 		return nil, grpcresponse.HandleError(ctx, err)
 	}
 
+	if err := etag.SetVersionHeader(ctx, created.Version); err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
 	return toProtoBar(created), nil
 }
 
@@ -47,12 +56,22 @@ func (b *Bar) GetBar(ctx context.Context, req *pb.GetBarRequest) (*pb.Bar, error
 		return nil, grpcresponse.HandleError(ctx, err)
 	}
 
+	if err := etag.SetVersionHeader(ctx, entity.Version); err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
 	return toProtoBar(entity), nil
 }
 
 func (b *Bar) ListBars(ctx context.Context, req *pb.ListBarsRequest) (*pb.ListBarsResponse, error) {
+	query, err := listquery.ParseMetadata(ctx, bardomain.ListAllowlist)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
 	filter := &bardomain.Filter{
 		Keyword: req.Keyword,
+		Query:   query,
 		Pagination: &pagination.PaginationRequest{
 			Page:  int(req.Page),
 			Limit: int(req.Limit),
@@ -60,6 +79,11 @@ func (b *Bar) ListBars(ctx context.Context, req *pb.ListBarsRequest) (*pb.ListBa
 	}
 	filter.Pagination.Validate(pagination.DefaultPaginationConfig())
 
+	if cursor, ok := pagination.CursorFromMetadata(ctx); ok {
+		filter.Pagination.Cursor, filter.Pagination.UseCursor = cursor, true
+		return b.listBarsByCursor(ctx, filter)
+	}
+
 	bars, total, err := b.uc.GetList(ctx, filter)
 	if err != nil {
 		return nil, grpcresponse.HandleError(ctx, err)
@@ -78,15 +102,44 @@ func (b *Bar) ListBars(ctx context.Context, req *pb.ListBarsRequest) (*pb.ListBa
 	}, nil
 }
 
+// listBarsByCursor serves cursor mode: the cursors travel in x-next-cursor and
+// x-prev-cursor response headers, total and page stay zero
+func (b *Bar) listBarsByCursor(ctx context.Context, filter *bardomain.Filter) (*pb.ListBarsResponse, error) {
+	bars, page, err := b.uc.GetListByCursor(ctx, filter)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	if err := pagination.SetCursorHeader(ctx, page); err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	items := make([]*pb.Bar, len(bars))
+	for i, bar := range bars {
+		items[i] = toProtoBar(bar)
+	}
+
+	return &pb.ListBarsResponse{
+		Bars:  items,
+		Limit: int32(filter.Pagination.Limit),
+	}, nil
+}
+
 func (b *Bar) UpdateBar(ctx context.Context, req *pb.UpdateBarRequest) (*pb.Bar, error) {
 	if req.Id == "" {
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
 
+	version, err := etag.ExpectedVersionFromMetadata(ctx, b.requireVersion)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
 	entity := &bardomain.Bar{
-		ID:   req.Id,
-		Code: req.Code,
-		Bar:  req.Bar,
+		ID:      req.Id,
+		Code:    req.Code,
+		Bar:     req.Bar,
+		Version: version,
 	}
 
 	updated, err := b.uc.Update(ctx, entity)
@@ -94,6 +147,10 @@ func (b *Bar) UpdateBar(ctx context.Context, req *pb.UpdateBarRequest) (*pb.Bar,
 		return nil, grpcresponse.HandleError(ctx, err)
 	}
 
+	if err := etag.SetVersionHeader(ctx, updated.Version); err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
 	return toProtoBar(updated), nil
 }
 
@@ -102,7 +159,12 @@ func (b *Bar) DeleteBar(ctx context.Context, req *pb.DeleteBarRequest) (*emptypb
 		return nil, status.Error(codes.InvalidArgument, "id is required")
 	}
 
-	entity := &bardomain.Bar{ID: req.Id}
+	version, err := etag.ExpectedVersionFromMetadata(ctx, b.requireVersion)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	entity := &bardomain.Bar{ID: req.Id, Version: version}
 
 	if err := b.uc.Delete(ctx, entity); err != nil {
 		return nil, grpcresponse.HandleError(ctx, err)
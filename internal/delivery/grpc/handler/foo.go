@@ -3,6 +3,11 @@ package grpchandler
 import (
 	"context"
 
+	foodomain "goilerplate/internal/domain/foo"
+	"goilerplate/pkg/grpcresponse"
+	"goilerplate/pkg/listquery"
+	"goilerplate/pkg/pagination"
+
 	pb "github.com/arisatriop/goilerplate-proto/foo/v1"
 
 	"google.golang.org/grpc/codes"
@@ -11,28 +16,112 @@ import (
 
 type Foo struct {
 	pb.UnimplementedFooServiceServer
+	uc foodomain.Usecase
 }
 
-func NewFoo() *Foo {
-	return &Foo{}
+func NewFoo(uc foodomain.Usecase) *Foo {
+	return &Foo{uc: uc}
 }
 
-func (f *Foo) CreateFoo(_ context.Context, req *pb.CreateFooRequest) (*pb.CreateFooResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method CreateFoo not implemented")
+func (f *Foo) CreateFoo(ctx context.Context, req *pb.CreateFooRequest) (*pb.CreateFooResponse, error) {
+	entity := &foodomain.Foo{
+		Code: req.Code,
+		Foo:  req.Name,
+	}
+
+	created, err := f.uc.Create(ctx, entity)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	return &pb.CreateFooResponse{Foo: toProtoFoo(created)}, nil
 }
 
-func (f *Foo) GetFoo(_ context.Context, req *pb.GetFooRequest) (*pb.GetFooResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetFoo not implemented")
+func (f *Foo) GetFoo(ctx context.Context, req *pb.GetFooRequest) (*pb.GetFooResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	entity, err := f.uc.GetByID(ctx, req.Id)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	return &pb.GetFooResponse{Foo: toProtoFoo(entity)}, nil
 }
 
-func (f *Foo) ListFoos(_ context.Context, req *pb.ListFoosRequest) (*pb.ListFoosResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method ListFoos not implemented")
+func (f *Foo) ListFoos(ctx context.Context, req *pb.ListFoosRequest) (*pb.ListFoosResponse, error) {
+	query, err := listquery.ParseMetadata(ctx, foodomain.ListAllowlist)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	filter := &foodomain.Filter{
+		Keyword: req.Keyword,
+		Query:   query,
+		Pagination: &pagination.PaginationRequest{
+			Page:  int(req.Page),
+			Limit: int(req.Limit),
+		},
+	}
+	filter.Pagination.Validate(pagination.DefaultPaginationConfig())
+
+	foos, total, err := f.uc.GetList(ctx, filter)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	items := make([]*pb.Foo, len(foos))
+	for i, foo := range foos {
+		items[i] = toProtoFoo(foo)
+	}
+
+	return &pb.ListFoosResponse{
+		Foos:  items,
+		Total: total,
+		Page:  int32(filter.Pagination.Page),
+		Limit: int32(filter.Pagination.Limit),
+	}, nil
 }
 
-func (f *Foo) UpdateFoo(_ context.Context, req *pb.UpdateFooRequest) (*pb.UpdateFooResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method UpdateFoo not implemented")
+func (f *Foo) UpdateFoo(ctx context.Context, req *pb.UpdateFooRequest) (*pb.UpdateFooResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	entity := &foodomain.Foo{
+		ID:   req.Id,
+		Code: req.Code,
+		Foo:  req.Name,
+	}
+
+	updated, err := f.uc.Update(ctx, entity)
+	if err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	return &pb.UpdateFooResponse{Foo: toProtoFoo(updated)}, nil
+}
+
+func (f *Foo) DeleteFoo(ctx context.Context, req *pb.DeleteFooRequest) (*pb.DeleteFooResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	entity := &foodomain.Foo{ID: req.Id}
+
+	if err := f.uc.Delete(ctx, entity); err != nil {
+		return nil, grpcresponse.HandleError(ctx, err)
+	}
+
+	return &pb.DeleteFooResponse{}, nil
 }
 
-func (f *Foo) DeleteFoo(_ context.Context, req *pb.DeleteFooRequest) (*pb.DeleteFooResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method DeleteFoo not implemented")
+// toProtoFoo maps the domain entity to the proto message (proto names the foo text "name")
+func toProtoFoo(e *foodomain.Foo) *pb.Foo {
+	return &pb.Foo{
+		Id:   e.ID,
+		Code: e.Code,
+		Name: e.Foo,
+	}
 }
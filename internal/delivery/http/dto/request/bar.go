@@ -10,6 +10,35 @@ type BarUpdateRequest struct {
 	Bar  string `json:"bar" validate:"required"`
 }
 
+// BarPatchDocument is the JSON view of a bar that merge patches and JSON patches are applied to
+type BarPatchDocument struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+	Bar  string `json:"bar"`
+}
+
 type BarListRequest struct {
 	Keyword string `json:"keyword" query:"keyword" form:"keyword"`
 }
+
+type BarBulkUpdateItem struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+	Bar  string `json:"bar"`
+}
+
+// BarBulkCreateRequest items are validated one by one by the usecase so that failures are reported per item
+type BarBulkCreateRequest struct {
+	Mode  string             `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Items []BarCreateRequest `json:"items" validate:"required,min=1"`
+}
+
+type BarBulkUpdateRequest struct {
+	Mode  string              `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Items []BarBulkUpdateItem `json:"items" validate:"required,min=1"`
+}
+
+type BarBulkDeleteRequest struct {
+	Mode string   `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	IDs  []string `json:"ids" validate:"required,min=1"`
+}
@@ -0,0 +1,12 @@
+package dtorequest
+
+type FileListRequest struct {
+	Keyword string `json:"keyword" query:"keyword" form:"keyword"`
+}
+
+type FilePresignRequest struct {
+	Filename string `json:"filename" validate:"required,max=255"`
+	Size     int64  `json:"size" validate:"required,gt=0"`
+	MimeType string `json:"mimeType" validate:"required"`
+	Checksum string `json:"checksum" validate:"required,len=64,hexadecimal"` // hex encoded SHA-256 of the content
+}
@@ -2,12 +2,12 @@ package dtorequest
 
 type FooCreateRequest struct {
 	Code string `json:"code" validate:"required,gte=3"`
-	Bar  string `json:"bar" validate:"required"`
+	Foo  string `json:"foo" validate:"required"`
 }
 
 type FooUpdateRequest struct {
 	Code string `json:"code" validate:"required,gte=3"`
-	Bar  string `json:"bar" validate:"required"`
+	Foo  string `json:"foo" validate:"required"`
 }
 
 type FooListRequest struct {
@@ -1,7 +1,58 @@
 package dtoresponse
 
+import "time"
+
 type BarResponse struct {
+	ID      string              `json:"id"`
+	Code    string              `json:"code"`
+	Bar     string              `json:"bar"`
+	Creator *BarCreatorResponse `json:"creator,omitempty"` // only with expand=creator
+}
+
+type BarCreatorResponse struct {
 	ID   string `json:"id"`
-	Code string `json:"code"`
-	Bar  string `json:"bar"`
+	Name string `json:"name"`
+}
+
+type BarPatchResponse struct {
+	Bar     *BarResponse `json:"bar"`
+	Changed []string     `json:"changed"`
+}
+
+type BarTrashResponse struct {
+	ID        string     `json:"id"`
+	Code      string     `json:"code"`
+	Bar       string     `json:"bar"`
+	DeletedAt *time.Time `json:"deletedAt"`
+	DeletedBy string     `json:"deletedBy"`
+}
+
+type BarBulkItemResponse struct {
+	Index  int          `json:"index"`
+	Status string       `json:"status"`
+	Data   *BarResponse `json:"data,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type BarBulkResponse struct {
+	Mode      string                 `json:"mode"`
+	Total     int                    `json:"total"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Items     []*BarBulkItemResponse `json:"items"`
+}
+
+type BarImportRowResponse struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BarImportResponse struct {
+	DryRun    bool                    `json:"dryRun"`
+	Total     int                     `json:"total"`
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+	Rows      []*BarImportRowResponse `json:"rows"`
 }
@@ -1,11 +1,52 @@
 package dtoresponse
 
+import "time"
+
 type UploadFileResponse struct {
-	OriginalName string `json:"originalName"`
-	Filename     string `json:"fileName"`
-	Path         string `json:"filePath"`
-	Size         int64  `json:"fileSize"`
-	URL          string `json:"fileUrl"` // Preview URL (iframe-friendly, works for PDF & images)
-	MimeType     string `json:"mimeType"`
-	Driver       string `json:"driver"`
+	ID           string    `json:"id"`
+	OriginalName string    `json:"originalName"`
+	Path         string    `json:"filePath"`
+	Size         int64     `json:"fileSize"`
+	URL          string    `json:"fileUrl"` // Preview URL (iframe-friendly, works for PDF & images)
+	MimeType     string    `json:"mimeType"`
+	Checksum     string    `json:"checksum"` // hex encoded SHA-256
+	Driver       string    `json:"driver"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	Variants []FileVariantResponse `json:"variants,omitempty"` // resized copies of images
+}
+
+type FileVariantResponse struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	MimeType string `json:"mimeType"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+}
+
+type PresignUploadResponse struct {
+	File   *UploadFileResponse     `json:"file"`
+	Upload *PresignedUploadRequest `json:"upload"`
+}
+
+// PresignedUploadRequest is sent by the client as is to upload the content, then the upload is completed
+type PresignedUploadRequest struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+type StorageUsageResponse struct {
+	User   UsageResponse  `json:"user"`
+	Tenant *UsageResponse `json:"tenant,omitempty"` // only when the request has a tenant
+}
+
+type UsageResponse struct {
+	ID        string `json:"id"`
+	Bytes     int64  `json:"bytes"` // files and variants, pending and unfinished uploads reserve their size
+	Files     int64  `json:"files"`
+	Quota     int64  `json:"quota"`     // 0 is unlimited
+	Remaining int64  `json:"remaining"` // -1 when unlimited
 }
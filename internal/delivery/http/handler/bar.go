@@ -1,27 +1,60 @@
 package handler
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"goilerplate/config"
+	barapp "goilerplate/internal/application/bar"
 	dtorequest "goilerplate/internal/delivery/http/dto/request"
 	"goilerplate/internal/delivery/http/presenter"
 	"goilerplate/internal/delivery/http/request"
 	"goilerplate/internal/domain/bar"
 	"goilerplate/pkg/constants"
+	"goilerplate/pkg/etag"
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/logger"
 	"goilerplate/pkg/pagination"
 	"goilerplate/pkg/response"
+	"goilerplate/pkg/spreadsheet"
+	"goilerplate/pkg/utils"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
+// Defaults applied when the bulk config is not set
+const (
+	defaultBulkBatchSize = 100
+	defaultMaxImportRows = 10000
+)
+
 type Bar struct {
-	Validator *validator.Validate
-	Usecase   bar.Usecase
+	Validator      *validator.Validate
+	Usecase        bar.Usecase
+	Service        barapp.ApplicationService
+	MaxBatchSize   int
+	MaxImportRows  int
+	RequireIfMatch bool
 }
 
-func NewBar(validator *validator.Validate, usecase bar.Usecase) *Bar {
+func NewBar(validator *validator.Validate, usecase bar.Usecase, service barapp.ApplicationService, cfg config.Bulk, concurrency config.Concurrency) *Bar {
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaultBulkBatchSize
+	}
+	if cfg.MaxImportRows <= 0 {
+		cfg.MaxImportRows = defaultMaxImportRows
+	}
+
 	return &Bar{
-		Validator: validator,
-		Usecase:   usecase,
+		Validator:      validator,
+		Usecase:        usecase,
+		Service:        service,
+		MaxBatchSize:   cfg.MaxBatchSize,
+		MaxImportRows:  cfg.MaxImportRows,
+		RequireIfMatch: concurrency.RequireIfMatch,
 	}
 }
 
@@ -52,11 +85,13 @@ func (h *Bar) Create(ctx *fiber.Ctx) error {
 		Bar:  req.Bar,
 	}
 
-	_, err := h.Usecase.Create(ctx.UserContext(), entity)
+	created, err := h.Usecase.Create(ctx.UserContext(), entity)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
+	ctx.Set(fiber.HeaderETag, etag.Format(created.Version))
+
 	return response.Created(ctx, nil, response.WithMessage(bar.MsgBarCreatedSuccessfully))
 }
 
@@ -64,18 +99,26 @@ func (h *Bar) Create(ctx *fiber.Ctx) error {
 // @Tags         bars
 // @Accept       json
 // @Produce      json
-// @Param        id       path      string                       true  "Bar ID"
-// @Param        request  body      dtorequest.BarUpdateRequest  true  "Bar data"
-// @Success      200      {object}  response.BaseResponse
-// @Failure      400      {object}  response.BaseResponse
-// @Failure      401      {object}  response.BaseResponse
-// @Failure      404      {object}  response.BaseResponse
-// @Failure      500      {object}  response.BaseResponse
+// @Param        id        path      string                       true   "Bar ID"
+// @Param        If-Match  header    string                       false  "ETag of the bar, required when concurrency.require_if_match is set"
+// @Param        request   body      dtorequest.BarUpdateRequest  true   "Bar data"
+// @Success      200       {object}  response.BaseResponse
+// @Failure      400       {object}  response.BaseResponse
+// @Failure      401       {object}  response.BaseResponse
+// @Failure      404       {object}  response.BaseResponse
+// @Failure      412       {object}  response.BaseResponse
+// @Failure      428       {object}  response.BaseResponse
+// @Failure      500       {object}  response.BaseResponse
 // @Security     BearerAuth
 // @Router       /api/v1/bars/{id} [put]
 func (h *Bar) Update(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 
+	version, err := etag.ExpectedVersion(ctx.Get(fiber.HeaderIfMatch), h.RequireIfMatch)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
 	var req dtorequest.BarUpdateRequest
 	if err := ctx.BodyParser(&req); err != nil {
 		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
@@ -87,37 +130,104 @@ func (h *Bar) Update(ctx *fiber.Ctx) error {
 	}
 
 	entity := &bar.Bar{
-		ID:   id,
-		Code: req.Code,
-		Bar:  req.Bar,
+		ID:      id,
+		Code:    req.Code,
+		Bar:     req.Bar,
+		Version: version,
 	}
 
-	_, err := h.Usecase.Update(ctx.UserContext(), entity)
+	updated, err := h.Usecase.Update(ctx.UserContext(), entity)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
+	ctx.Set(fiber.HeaderETag, etag.Format(updated.Version))
+
 	return response.Success(ctx, nil, response.WithMessage(bar.MsgBarUpdatedSuccessfully))
 }
 
+// @Summary      Patch bar
+// @Description  Partially updates a bar with a JSON Merge Patch (application/merge-patch+json, RFC 7386) or a JSON Patch (application/json-patch+json, RFC 6902). Only changed fields are written.
+// @Tags         bars
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id        path      string  true   "Bar ID"
+// @Param        If-Match  header    string  false  "ETag of the bar, required when concurrency.require_if_match is set"
+// @Param        request   body      object  true   "Merge patch or JSON patch"
+// @Success      200       {object}  response.BaseResponse{data=dtoresponse.BarPatchResponse}
+// @Failure      400       {object}  response.BaseResponse
+// @Failure      401       {object}  response.BaseResponse
+// @Failure      404       {object}  response.BaseResponse
+// @Failure      409       {object}  response.BaseResponse
+// @Failure      412       {object}  response.BaseResponse
+// @Failure      415       {object}  response.BaseResponse
+// @Failure      422       {object}  response.BaseResponse
+// @Failure      428       {object}  response.BaseResponse
+// @Failure      500       {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/{id} [patch]
+func (h *Bar) Patch(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	version, err := etag.ExpectedVersion(ctx.Get(fiber.HeaderIfMatch), h.RequireIfMatch)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	current, err := h.Usecase.GetForUpdate(ctx.UserContext(), id)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+	if version > 0 && version != current.Version {
+		return response.HandleError(ctx, bar.ErrVersionMismatch)
+	}
+
+	patch, err := request.ToBarPatch(current, ctx.Get(fiber.HeaderContentType), ctx.Body())
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	// The patch was computed from current, so it is only applied to that version
+	patched, changed, err := h.Usecase.Patch(ctx.UserContext(), id, current.Version, patch)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderETag, etag.Format(patched.Version))
+
+	patchResponse := presenter.ToBarPatchResponse(patched, changed)
+
+	return response.Success(ctx, patchResponse, response.WithMessage(bar.MsgBarUpdatedSuccessfully))
+}
+
 // @Summary      Delete bar
 // @Tags         bars
 // @Produce      json
-// @Param        id   path      string  true  "Bar ID"
-// @Success      204  {object}  response.BaseResponse
-// @Failure      401  {object}  response.BaseResponse
-// @Failure      404  {object}  response.BaseResponse
-// @Failure      500  {object}  response.BaseResponse
+// @Param        id        path      string  true   "Bar ID"
+// @Param        If-Match  header    string  false  "ETag of the bar, required when concurrency.require_if_match is set"
+// @Success      204       {object}  response.BaseResponse
+// @Failure      401       {object}  response.BaseResponse
+// @Failure      404       {object}  response.BaseResponse
+// @Failure      412       {object}  response.BaseResponse
+// @Failure      428       {object}  response.BaseResponse
+// @Failure      500       {object}  response.BaseResponse
 // @Security     BearerAuth
 // @Router       /api/v1/bars/{id} [delete]
 func (h *Bar) Delete(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 
+	version, err := etag.ExpectedVersion(ctx.Get(fiber.HeaderIfMatch), h.RequireIfMatch)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
 	entity := &bar.Bar{
-		ID: id,
+		ID:      id,
+		Version: version,
 	}
 
-	err := h.Usecase.Delete(ctx.UserContext(), entity)
+	err = h.Usecase.Delete(ctx.UserContext(), entity)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
@@ -129,9 +239,15 @@ func (h *Bar) Delete(ctx *fiber.Ctx) error {
 // @Tags         bars
 // @Produce      json
 // @Param        keyword  query     string  false  "Search keyword"
+// @Param        sort     query     string  false  "Sort fields, prefix with - for descending (e.g. -code,created_at)"
+// @Param        filter   query     string  false  "Filters as filter[field][op]=value (ops: eq, ne, gt, gte, lt, lte, like, in, null)"
 // @Param        page     query     int     false  "Page number"   default(1)
 // @Param        limit    query     int     false  "Page size"     default(10)
+// @Param        cursor   query     string  false  "Opaque cursor, send it empty for the first page to switch to cursor pagination"
+// @Param        fields   query     string  false  "Comma separated fields to return (e.g. id,code), id is always returned"
+// @Param        expand   query     string  false  "Comma separated relations to embed (creator)"
 // @Success      200      {object}  response.PaginatedResponse{data=[]dtoresponse.BarResponse}
+// @Failure      400      {object}  response.BaseResponse
 // @Failure      401      {object}  response.BaseResponse
 // @Failure      500      {object}  response.BaseResponse
 // @Security     BearerAuth
@@ -142,14 +258,35 @@ func (h *Bar) List(ctx *fiber.Ctx) error {
 		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
 	}
 
-	filter := request.ToBarFilter(&req, ctx)
+	filter, err := request.ToBarFilter(&req, ctx)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	if filter.Pagination.IsCursor() {
+		result, page, err := h.Usecase.GetListByCursor(ctx.UserContext(), filter)
+		if err != nil {
+			return response.HandleError(ctx, err)
+		}
+
+		barResponses, err := fieldset.ProjectList(filter.Selection, presenter.ToBarListResponse(result))
+		if err != nil {
+			return response.HandleError(ctx, err)
+		}
+		paginatedResponse := pagination.NewCursorPaginatedResponse(barResponses, filter.Pagination.Limit, page)
+
+		return response.Success(ctx, paginatedResponse, response.WithMessage(bar.MsgBarListFetchSuccessfully))
+	}
 
 	result, total, err := h.Usecase.GetList(ctx.UserContext(), filter)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
-	barResponses := presenter.ToBarListResponse(result)
+	barResponses, err := fieldset.ProjectList(filter.Selection, presenter.ToBarListResponse(result))
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
 	paginatedResponse := pagination.NewPaginatedResponse(barResponses, total, filter.Pagination.Page, filter.Pagination.Limit)
 
 	return response.Success(ctx, paginatedResponse, response.WithMessage(bar.MsgBarListFetchSuccessfully))
@@ -158,8 +295,12 @@ func (h *Bar) List(ctx *fiber.Ctx) error {
 // @Summary      Get bar by ID
 // @Tags         bars
 // @Produce      json
-// @Param        id   path      string  true  "Bar ID"
+// @Param        id      path      string  true   "Bar ID"
+// @Param        fields  query     string  false  "Comma separated fields to return (e.g. id,code), id is always returned"
+// @Param        expand  query     string  false  "Comma separated relations to embed (creator)"
 // @Success      200  {object}  response.BaseResponse{data=dtoresponse.BarResponse}
+// @Header       200  {string}  ETag  "Version of the bar, send it back as If-Match"
+// @Failure      400  {object}  response.BaseResponse
 // @Failure      401  {object}  response.BaseResponse
 // @Failure      404  {object}  response.BaseResponse
 // @Failure      500  {object}  response.BaseResponse
@@ -168,12 +309,367 @@ func (h *Bar) List(ctx *fiber.Ctx) error {
 func (h *Bar) Get(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 
-	entity, err := h.Usecase.GetByID(ctx.UserContext(), id)
+	selection, err := request.ToBarSelection(ctx)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
-	barResponse := presenter.ToBarResponse(entity)
+	entity, err := h.Usecase.GetView(ctx.UserContext(), id, selection)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderETag, etag.Format(entity.Version))
+
+	barResponse, err := selection.Project(presenter.ToBarResponse(entity))
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
 
 	return response.Success(ctx, barResponse, response.WithMessage(bar.MsgBarFetchedSuccessfully))
 }
+
+// @Summary      Bulk create bars
+// @Description  Atomic mode (default) writes nothing when any item fails and responds 422. Partial mode writes the valid items and responds 207 when some failed.
+// @Tags         bars
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key  header    string                           true  "Idempotency key"
+// @Param        request          body      dtorequest.BarBulkCreateRequest  true  "Bars to create"
+// @Success      201              {object}  response.BaseResponse{data=dtoresponse.BarBulkResponse}
+// @Success      207              {object}  response.BaseResponse{data=dtoresponse.BarBulkResponse}
+// @Failure      400              {object}  response.BaseResponse
+// @Failure      401              {object}  response.BaseResponse
+// @Failure      422              {object}  response.BaseResponse{errors=dtoresponse.BarBulkResponse}
+// @Failure      500              {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/bulk [post]
+func (h *Bar) BulkCreate(ctx *fiber.Ctx) error {
+	var req dtorequest.BarBulkCreateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	if err := h.Validator.Struct(&req); err != nil {
+		validationErrors := response.FormatValidationErrors(err)
+		return response.ValidationError(ctx, validationErrors)
+	}
+
+	if len(req.Items) > h.MaxBatchSize {
+		return h.batchTooLarge(ctx)
+	}
+
+	entities := make([]*bar.Bar, len(req.Items))
+	for i, item := range req.Items {
+		entities[i] = &bar.Bar{
+			Code: item.Code,
+			Bar:  item.Bar,
+		}
+	}
+
+	result, err := h.Usecase.BulkCreate(ctx.UserContext(), entities, bar.BulkMode(req.Mode))
+	return h.bulkResponse(ctx, result, err, true)
+}
+
+// @Summary      Bulk update bars
+// @Description  Atomic mode (default) writes nothing when any item fails and responds 422. Partial mode writes the valid items and responds 207 when some failed.
+// @Tags         bars
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key  header    string                           true  "Idempotency key"
+// @Param        request          body      dtorequest.BarBulkUpdateRequest  true  "Bars to update"
+// @Success      200              {object}  response.BaseResponse{data=dtoresponse.BarBulkResponse}
+// @Success      207              {object}  response.BaseResponse{data=dtoresponse.BarBulkResponse}
+// @Failure      400              {object}  response.BaseResponse
+// @Failure      401              {object}  response.BaseResponse
+// @Failure      422              {object}  response.BaseResponse{errors=dtoresponse.BarBulkResponse}
+// @Failure      500              {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/bulk [put]
+func (h *Bar) BulkUpdate(ctx *fiber.Ctx) error {
+	var req dtorequest.BarBulkUpdateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	if err := h.Validator.Struct(&req); err != nil {
+		validationErrors := response.FormatValidationErrors(err)
+		return response.ValidationError(ctx, validationErrors)
+	}
+
+	if len(req.Items) > h.MaxBatchSize {
+		return h.batchTooLarge(ctx)
+	}
+
+	entities := make([]*bar.Bar, len(req.Items))
+	for i, item := range req.Items {
+		entities[i] = &bar.Bar{
+			ID:   item.ID,
+			Code: item.Code,
+			Bar:  item.Bar,
+		}
+	}
+
+	result, err := h.Usecase.BulkUpdate(ctx.UserContext(), entities, bar.BulkMode(req.Mode))
+	return h.bulkResponse(ctx, result, err, false)
+}
+
+// @Summary      Bulk delete bars
+// @Description  Atomic mode (default) deletes nothing when any id fails and responds 422. Partial mode deletes the valid ids and responds 207 when some failed.
+// @Tags         bars
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key  header    string                           true  "Idempotency key"
+// @Param        request          body      dtorequest.BarBulkDeleteRequest  true  "Bar IDs to delete"
+// @Success      200              {object}  response.BaseResponse{data=dtoresponse.BarBulkResponse}
+// @Success      207              {object}  response.BaseResponse{data=dtoresponse.BarBulkResponse}
+// @Failure      400              {object}  response.BaseResponse
+// @Failure      401              {object}  response.BaseResponse
+// @Failure      422              {object}  response.BaseResponse{errors=dtoresponse.BarBulkResponse}
+// @Failure      500              {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/bulk [delete]
+func (h *Bar) BulkDelete(ctx *fiber.Ctx) error {
+	var req dtorequest.BarBulkDeleteRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	if err := h.Validator.Struct(&req); err != nil {
+		validationErrors := response.FormatValidationErrors(err)
+		return response.ValidationError(ctx, validationErrors)
+	}
+
+	if len(req.IDs) > h.MaxBatchSize {
+		return h.batchTooLarge(ctx)
+	}
+
+	result, err := h.Usecase.BulkDelete(ctx.UserContext(), req.IDs, bar.BulkMode(req.Mode))
+	return h.bulkResponse(ctx, result, err, false)
+}
+
+func (h *Bar) batchTooLarge(ctx *fiber.Ctx) error {
+	return response.BadRequest(ctx, fmt.Sprintf("Batch size exceeds the maximum of %d items", h.MaxBatchSize), nil)
+}
+
+// bulkResponse responds 201/200 when every item succeeded, 207 when a partial batch
+// had failures and 422 when an atomic batch was rejected
+func (h *Bar) bulkResponse(ctx *fiber.Ctx, result *bar.BulkResult, err error, created bool) error {
+	if errors.Is(err, bar.ErrBulkRejected) {
+		return response.UnprocessableEntity(ctx, bar.ErrBulkRejected.Message, presenter.ToBarBulkResponse(result))
+	}
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	bulkResponse := presenter.ToBarBulkResponse(result)
+
+	switch {
+	case result.Failed() > 0:
+		return response.MultiStatus(ctx, bulkResponse, response.WithMessage(bar.MsgBarBulkProcessed))
+	case created:
+		return response.Created(ctx, bulkResponse, response.WithMessage(bar.MsgBarBulkProcessed))
+	default:
+		return response.Success(ctx, bulkResponse, response.WithMessage(bar.MsgBarBulkProcessed))
+	}
+}
+
+// @Summary      Export bars
+// @Description  Streams every bar matching the list filters as CSV or XLSX
+// @Tags         bars
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format   query     string  false  "File format"  Enums(csv, xlsx)  default(csv)
+// @Param        keyword  query     string  false  "Search keyword"
+// @Param        sort     query     string  false  "Sort fields, prefix with - for descending (e.g. -code,created_at)"
+// @Param        filter   query     string  false  "Filters as filter[field][op]=value (ops: eq, ne, gt, gte, lt, lte, like, in, null)"
+// @Success      200      {file}    file
+// @Failure      400      {object}  response.BaseResponse
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/export [get]
+func (h *Bar) Export(ctx *fiber.Ctx) error {
+	format, err := spreadsheet.ParseFormat(ctx.Query("format", string(spreadsheet.FormatCSV)))
+	if err != nil {
+		return response.BadRequest(ctx, "format must be csv or xlsx", nil)
+	}
+
+	var req dtorequest.BarListRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	filter, err := request.ToBarFilter(&req, ctx)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+	filter.Pagination = nil
+
+	// The body is written after the handler returns, the fiber context must not be used inside
+	userCtx := ctx.UserContext()
+
+	ctx.Attachment(fmt.Sprintf("bars-%s.%s", utils.Now().Format("20060102-150405"), format))
+	ctx.Set(fiber.HeaderContentType, format.ContentType())
+	ctx.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer := spreadsheet.NewWriter(format, w)
+
+		err := writer.Write(presenter.BarExportHeader)
+		if err == nil {
+			err = h.Usecase.Export(userCtx, filter, func(entity *bar.Bar) error {
+				return writer.Write(presenter.ToBarExportRow(entity))
+			})
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+
+		// Headers are already sent, a failure can only truncate the file
+		if err != nil {
+			logger.Error(userCtx, err)
+		}
+	})
+
+	return nil
+}
+
+// @Summary      Import bars
+// @Description  Imports bars from a CSV or XLSX file with a code and bar header row. Any invalid row rejects the whole file with 422 and row-level errors.
+// @Tags         bars
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "CSV or XLSX file"
+// @Param        format   formData  string  false  "File format, defaults to the file extension"  Enums(csv, xlsx)
+// @Param        dry_run  query     bool    false  "Validate only, nothing is written"
+// @Success      200      {object}  response.BaseResponse{data=dtoresponse.BarImportResponse}
+// @Success      201      {object}  response.BaseResponse{data=dtoresponse.BarImportResponse}
+// @Failure      400      {object}  response.BaseResponse
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      422      {object}  response.BaseResponse{errors=dtoresponse.BarImportResponse}
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/import [post]
+func (h *Bar) Import(ctx *fiber.Ctx) error {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return response.BadRequest(ctx, "File is required", nil)
+	}
+
+	formatName := ctx.FormValue("format")
+	if formatName == "" {
+		formatName = filepath.Ext(fileHeader.Filename)
+	}
+	format, err := spreadsheet.ParseFormat(formatName)
+	if err != nil {
+		return response.BadRequest(ctx, "File must be csv or xlsx", nil)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+	defer file.Close()
+
+	reader, err := spreadsheet.NewReader(format, file, fileHeader.Size)
+	if err != nil {
+		return response.BadRequest(ctx, "Import file could not be read", nil)
+	}
+
+	entities, rows, err := request.ToBarImportRows(reader, h.MaxImportRows)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	dryRun := ctx.QueryBool("dry_run")
+
+	result, err := h.Service.ImportBars(ctx.UserContext(), entities, dryRun)
+	if errors.Is(err, bar.ErrImportRejected) {
+		return response.UnprocessableEntity(ctx, bar.ErrImportRejected.Message, presenter.ToBarImportResponse(result, rows, dryRun))
+	}
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	importResponse := presenter.ToBarImportResponse(result, rows, dryRun)
+	if dryRun {
+		return response.Success(ctx, importResponse, response.WithMessage(bar.MsgBarImportValidated))
+	}
+
+	return response.Created(ctx, importResponse, response.WithMessage(bar.MsgBarImportedSuccessfully))
+}
+
+// @Summary      List deleted bars
+// @Description  Lists soft deleted bars, most recently deleted first
+// @Tags         bars
+// @Produce      json
+// @Param        keyword  query     string  false  "Search keyword"
+// @Param        page     query     int     false  "Page number"   default(1)
+// @Param        limit    query     int     false  "Page size"     default(10)
+// @Success      200      {object}  response.PaginatedResponse{data=[]dtoresponse.BarTrashResponse}
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/trash [get]
+func (h *Bar) Trash(ctx *fiber.Ctx) error {
+	var req dtorequest.BarListRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	filter := request.ToBarTrashFilter(&req, ctx)
+
+	result, total, err := h.Usecase.ListTrash(ctx.UserContext(), filter)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	trashResponses := presenter.ToBarTrashListResponse(result)
+	paginatedResponse := pagination.NewPaginatedResponse(trashResponses, total, filter.Pagination.Page, filter.Pagination.Limit)
+
+	return response.Success(ctx, paginatedResponse, response.WithMessage(bar.MsgBarTrashFetchSuccessfully))
+}
+
+// @Summary      Restore deleted bar
+// @Tags         bars
+// @Produce      json
+// @Param        id   path      string  true  "Bar ID"
+// @Success      200  {object}  response.BaseResponse{data=dtoresponse.BarResponse}
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      409  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/trash/{id}/restore [post]
+func (h *Bar) Restore(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	entity, err := h.Usecase.Restore(ctx.UserContext(), id)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	barResponse := presenter.ToBarResponse(entity)
+
+	return response.Success(ctx, barResponse, response.WithMessage(bar.MsgBarRestoredSuccessfully))
+}
+
+// @Summary      Permanently delete bar
+// @Description  Permanently deletes a bar from the trash, active bars must be deleted first
+// @Tags         bars
+// @Produce      json
+// @Param        id   path      string  true  "Bar ID"
+// @Success      204  {object}  response.BaseResponse
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/bars/trash/{id} [delete]
+func (h *Bar) HardDelete(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+
+	if err := h.Usecase.HardDelete(ctx.UserContext(), id); err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
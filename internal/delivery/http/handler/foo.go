@@ -1,7 +1,13 @@
 package handler
 
 import (
+	dtorequest "goilerplate/internal/delivery/http/dto/request"
+	"goilerplate/internal/delivery/http/presenter"
+	"goilerplate/internal/delivery/http/request"
 	"goilerplate/internal/domain/foo"
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/pagination"
+	"goilerplate/pkg/response"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -19,22 +25,160 @@ func NewFoo(validator *validator.Validate, usecase foo.Usecase) *Foo {
 	}
 }
 
+// @Summary      Create foo
+// @Tags         foos
+// @Accept       json
+// @Produce      json
+// @Param        request  body      dtorequest.FooCreateRequest  true  "Foo data"
+// @Success      201      {object}  response.BaseResponse
+// @Failure      400      {object}  response.BaseResponse
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/foos [post]
 func (h *Foo) Create(ctx *fiber.Ctx) error {
-	panic("Implement me")
+	var req dtorequest.FooCreateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	if err := h.Validator.Struct(&req); err != nil {
+		validationErrors := response.FormatValidationErrors(err)
+		return response.ValidationError(ctx, validationErrors)
+	}
+
+	entity := &foo.Foo{
+		Code: req.Code,
+		Foo:  req.Foo,
+	}
+
+	_, err := h.Usecase.Create(ctx.UserContext(), entity)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	return response.Created(ctx, nil, response.WithMessage(foo.MsgFooCreatedSuccessfully))
 }
 
+// @Summary      Update foo
+// @Tags         foos
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "Foo ID"
+// @Param        request  body      dtorequest.FooUpdateRequest  true  "Foo data"
+// @Success      200      {object}  response.BaseResponse
+// @Failure      400      {object}  response.BaseResponse
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      404      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/foos/{id} [put]
 func (h *Foo) Update(ctx *fiber.Ctx) error {
-	panic("Implement me")
+	id := ctx.Params("id")
+
+	var req dtorequest.FooUpdateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	if err := h.Validator.Struct(&req); err != nil {
+		validationErrors := response.FormatValidationErrors(err)
+		return response.ValidationError(ctx, validationErrors)
+	}
+
+	entity := &foo.Foo{
+		ID:   id,
+		Code: req.Code,
+		Foo:  req.Foo,
+	}
+
+	_, err := h.Usecase.Update(ctx.UserContext(), entity)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	return response.Success(ctx, nil, response.WithMessage(foo.MsgFooUpdatedSuccessfully))
 }
 
+// @Summary      Delete foo
+// @Tags         foos
+// @Produce      json
+// @Param        id   path      string  true  "Foo ID"
+// @Success      204  {object}  response.BaseResponse
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/foos/{id} [delete]
 func (h *Foo) Delete(ctx *fiber.Ctx) error {
-	panic("Implement me")
+	id := ctx.Params("id")
+
+	entity := &foo.Foo{
+		ID: id,
+	}
+
+	err := h.Usecase.Delete(ctx.UserContext(), entity)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
 }
 
+// @Summary      List foos
+// @Tags         foos
+// @Produce      json
+// @Param        keyword  query     string  false  "Search keyword"
+// @Param        sort     query     string  false  "Sort fields, prefix with - for descending (e.g. -code,created_at)"
+// @Param        filter   query     string  false  "Filters as filter[field][op]=value (ops: eq, ne, gt, gte, lt, lte, like, in, null)"
+// @Param        page     query     int     false  "Page number"   default(1)
+// @Param        limit    query     int     false  "Page size"     default(10)
+// @Success      200      {object}  response.PaginatedResponse{data=[]dtoresponse.FooResponse}
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/foos [get]
 func (h *Foo) List(ctx *fiber.Ctx) error {
-	panic("Implement me")
+	var req dtorequest.FooListRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	filter, err := request.ToFooFilter(&req, ctx)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	result, total, err := h.Usecase.GetList(ctx.UserContext(), filter)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	fooResponses := presenter.ToFooListResponse(result)
+	paginatedResponse := pagination.NewPaginatedResponse(fooResponses, total, filter.Pagination.Page, filter.Pagination.Limit)
+
+	return response.Success(ctx, paginatedResponse, response.WithMessage(foo.MsgFooListFetchSuccessfully))
 }
 
+// @Summary      Get foo by ID
+// @Tags         foos
+// @Produce      json
+// @Param        id   path      string  true  "Foo ID"
+// @Success      200  {object}  response.BaseResponse{data=dtoresponse.FooResponse}
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/foos/{id} [get]
 func (h *Foo) Get(ctx *fiber.Ctx) error {
-	panic("Implement me")
+	id := ctx.Params("id")
+
+	entity, err := h.Usecase.GetByID(ctx.UserContext(), id)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	fooResponse := presenter.ToFooResponse(entity)
+
+	return response.Success(ctx, fooResponse, response.WithMessage(foo.MsgFooFetchedSuccessfully))
 }
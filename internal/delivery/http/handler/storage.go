@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"goilerplate/pkg/filesystem"
+	"goilerplate/pkg/logger"
+	"goilerplate/pkg/response"
+	"goilerplate/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Storage serves the signed URLs of drivers whose files live on this application
+type Storage struct {
+	Manager *filesystem.Manager
+}
+
+func NewStorage(manager *filesystem.Manager) *Storage {
+	return &Storage{
+		Manager: manager,
+	}
+}
+
+// @Summary      Download a file through a signed URL
+// @Tags         storage
+// @Produce      octet-stream
+// @Param        path       path      string  true  "File path"
+// @Param        expires    query     int     true  "Expiry as unix seconds"
+// @Param        signature  query     string  true  "Signature"
+// @Success      200        {file}    binary
+// @Failure      403        {object}  response.BaseResponse
+// @Failure      404        {object}  response.BaseResponse
+// @Router       /storage/signed/{path} [get]
+func (h *Storage) Download(ctx *fiber.Ctx) error {
+	path, query, err := h.verify(ctx)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	reader, err := h.Manager.Open(ctx.UserContext(), path)
+	if errors.Is(err, filesystem.ErrFileNotFound) {
+		return response.NotFound(ctx, "File not found")
+	}
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		ctx.Set(fiber.HeaderContentType, contentType)
+	}
+	// Never cache longer than the URL is valid
+	if expires, err := strconv.ParseInt(query.Get(filesystem.SignedParamExpires), 10, 64); err == nil {
+		ctx.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.FormatInt(max(expires-utils.Now().Unix(), 0), 10))
+	}
+
+	return ctx.SendStream(reader)
+}
+
+// @Summary      Upload a file through a presigned URL
+// @Description  The Content-Length, Content-Type and SHA-256 of the body must match the signed upload, the type sniffed from the content included.
+// @Tags         storage
+// @Accept       octet-stream
+// @Param        path       path      string  true  "File path"
+// @Param        expires    query     int     true  "Expiry as unix seconds"
+// @Param        signature  query     string  true  "Signature"
+// @Success      204
+// @Failure      400        {object}  response.BaseResponse
+// @Failure      403        {object}  response.BaseResponse
+// @Failure      404        {object}  response.BaseResponse
+// @Router       /storage/signed/{path} [put]
+func (h *Storage) Upload(ctx *fiber.Ctx) error {
+	path, query, err := h.verify(ctx)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	size, err := strconv.ParseInt(query.Get(filesystem.SignedParamSize), 10, 64)
+	if err != nil || int64(ctx.Request().Header.ContentLength()) != size {
+		return response.BadRequest(ctx, "Content-Length does not match the signed upload", nil)
+	}
+
+	if mediaType(ctx.Get(fiber.HeaderContentType)) != mediaType(query.Get(filesystem.SignedParamContentType)) {
+		return response.BadRequest(ctx, "Content-Type does not match the signed upload", nil)
+	}
+
+	var body io.Reader = bytes.NewReader(ctx.Body())
+	if stream := ctx.Request().BodyStream(); stream != nil {
+		body = stream
+	}
+
+	// The signed type passed the presign policy, the content must be of that type
+	head, sniffed, err := filesystem.Sniff(body, filepath.Base(path))
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+	if sniffed != mediaType(query.Get(filesystem.SignedParamContentType)) {
+		return response.BadRequest(ctx, "Content is not of the signed Content-Type", nil)
+	}
+	body = io.MultiReader(bytes.NewReader(head), body)
+
+	hash := sha256.New()
+	result, err := h.Manager.UploadFromReader(ctx.UserContext(), io.TeeReader(io.LimitReader(body, size+1), hash), filepath.Base(path), filesystem.UploadOptions{
+		Path:         filepath.Dir(path),
+		SkipVariants: true, // stored exactly as signed, the checksum covers the original only
+	})
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	if result.Size != size || hex.EncodeToString(hash.Sum(nil)) != query.Get(filesystem.SignedParamChecksum) {
+		if err := h.Manager.Delete(ctx.UserContext(), path); err != nil {
+			logger.Warn(ctx.UserContext(), "failed to remove rejected upload "+path+": "+err.Error())
+		}
+		return response.BadRequest(ctx, "Content does not match the signed upload", nil)
+	}
+
+	return response.NoContent(ctx)
+}
+
+// verify checks the signature of the request and returns the signed path and query
+func (h *Storage) verify(ctx *fiber.Ctx) (string, url.Values, error) {
+	path, err := url.PathUnescape(ctx.Params("*"))
+	if err != nil {
+		return "", nil, filesystem.ErrInvalidSignature
+	}
+
+	query, err := url.ParseQuery(string(ctx.Request().URI().QueryString()))
+	if err != nil {
+		return "", nil, filesystem.ErrInvalidSignature
+	}
+
+	if err := h.Manager.VerifySignedRequest(ctx.Method(), path, query); err != nil {
+		return "", nil, err
+	}
+
+	return path, query, nil
+}
+
+func (h *Storage) handleError(ctx *fiber.Ctx, err error) error {
+	// Drivers signing their own URLs are never served here
+	if errors.Is(err, filesystem.ErrSigningNotSupported) {
+		return response.NotFound(ctx, "File not found")
+	}
+	return response.HandleError(ctx, err)
+}
+
+// mediaType returns the media type of a Content-Type without its parameters
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return parsed
+}
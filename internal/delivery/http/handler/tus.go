@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+
+	"goilerplate/internal/domain/file"
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// tus 1.0 protocol, see https://tus.io/protocols/resumable-upload
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination"
+
+	headerTusResumable      = "Tus-Resumable"
+	headerTusVersion        = "Tus-Version"
+	headerTusExtension      = "Tus-Extension"
+	headerTusMaxSize        = "Tus-Max-Size"
+	headerUploadLength      = "Upload-Length"
+	headerUploadOffset      = "Upload-Offset"
+	headerUploadMetadata    = "Upload-Metadata"
+	headerUploadDeferLength = "Upload-Defer-Length"
+	contentTypeTusPatch     = "application/offset+octet-stream"
+	tusMetadataFilename     = "filename"
+	tusMetadataFiletype     = "filetype"
+)
+
+// Tus serves resumable uploads with the tus core protocol and its creation and
+// termination extensions. Completed uploads become files of the uploader.
+type Tus struct {
+	Usecase file.Usecase
+	maxSize int64
+}
+
+func NewTus(usecase file.Usecase, maxSize int64) *Tus {
+	return &Tus{
+		Usecase: usecase,
+		maxSize: maxSize,
+	}
+}
+
+// Resumable rejects requests of other protocol versions, every response carries Tus-Resumable
+func (h *Tus) Resumable(ctx *fiber.Ctx) error {
+	ctx.Set(headerTusResumable, tusVersion)
+
+	if ctx.Method() != fiber.MethodOptions && ctx.Get(headerTusResumable) != tusVersion {
+		ctx.Set(headerTusVersion, tusVersion)
+		return response.CustomError(ctx, fiber.StatusPreconditionFailed, "Unsupported tus version", nil)
+	}
+
+	return ctx.Next()
+}
+
+// @Summary      Discover tus capabilities
+// @Tags         files
+// @Success      204
+// @Failure      401  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/uploads [options]
+func (h *Tus) Options(ctx *fiber.Ctx) error {
+	ctx.Set(headerTusVersion, tusVersion)
+	ctx.Set(headerTusExtension, tusExtensions)
+	if h.maxSize > 0 {
+		ctx.Set(headerTusMaxSize, strconv.FormatInt(h.maxSize, 10))
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary      Create a resumable upload
+// @Tags         files
+// @Param        Tus-Resumable    header    string  true   "1.0.0"
+// @Param        Upload-Length    header    int     true   "Size of the file in bytes"
+// @Param        Upload-Metadata  header    string  false  "Comma separated key and base64 value pairs, filename and filetype are used"
+// @Success      201
+// @Header       201  {string}  Location  "URL of the upload"
+// @Failure      400  {object}  response.BaseResponse
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      412  {object}  response.BaseResponse
+// @Failure      413  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/uploads [post]
+func (h *Tus) Create(ctx *fiber.Ctx) error {
+	if ctx.Get(headerUploadDeferLength) != "" {
+		return response.BadRequest(ctx, "Upload-Defer-Length is not supported", nil)
+	}
+
+	length, err := strconv.ParseInt(ctx.Get(headerUploadLength), 10, 64)
+	if err != nil || length < 0 {
+		return response.BadRequest(ctx, "Invalid Upload-Length", nil)
+	}
+
+	metadata, err := parseTusMetadata(ctx.Get(headerUploadMetadata))
+	if err != nil {
+		return response.BadRequest(ctx, "Invalid Upload-Metadata", nil)
+	}
+
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	upload, err := h.Usecase.CreateResumable(ctx.UserContext(), userID, &file.ResumableRequest{
+		Filename: metadata[tusMetadataFilename],
+		MimeType: metadata[tusMetadataFiletype],
+		Size:     length,
+	})
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderLocation, ctx.BaseURL()+strings.TrimSuffix(ctx.Path(), "/")+"/"+upload.ID)
+	return ctx.SendStatus(fiber.StatusCreated)
+}
+
+// @Summary      Get the offset of a resumable upload
+// @Tags         files
+// @Param        Tus-Resumable  header    string  true  "1.0.0"
+// @Param        id             path      string  true  "Upload ID"
+// @Success      200
+// @Header       200  {int}  Upload-Offset  "Bytes received so far"
+// @Header       200  {int}  Upload-Length  "Size of the file in bytes"
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      412  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/uploads/{id} [head]
+func (h *Tus) Head(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	upload, err := h.Usecase.GetResumable(ctx.UserContext(), userID, ctx.Params("id"))
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set(headerUploadOffset, strconv.FormatInt(upload.Staged.Offset, 10))
+	ctx.Set(headerUploadLength, strconv.FormatInt(upload.Staged.Size, 10))
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// @Summary      Append a chunk to a resumable upload
+// @Description  The upload becomes a file once its last byte arrived, Content-Location of that response points to the file.
+// @Tags         files
+// @Accept       application/offset+octet-stream
+// @Param        Tus-Resumable  header    string  true  "1.0.0"
+// @Param        Upload-Offset  header    int     true  "Offset the chunk starts at"
+// @Param        id             path      string  true  "Upload ID"
+// @Success      204
+// @Header       204            {int}     Upload-Offset     "Bytes received so far"
+// @Header       204            {string}  Content-Location  "URL of the file, once the upload is complete"
+// @Failure      400            {object}  response.BaseResponse
+// @Failure      401            {object}  response.BaseResponse
+// @Failure      404            {object}  response.BaseResponse
+// @Failure      409            {object}  response.BaseResponse
+// @Failure      412            {object}  response.BaseResponse
+// @Failure      413            {object}  response.BaseResponse
+// @Failure      415            {object}  response.BaseResponse
+// @Failure      423            {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/uploads/{id} [patch]
+func (h *Tus) Patch(ctx *fiber.Ctx) error {
+	if ctx.Get(fiber.HeaderContentType) != contentTypeTusPatch {
+		return response.CustomError(ctx, fiber.StatusUnsupportedMediaType, "Content-Type must be "+contentTypeTusPatch, nil)
+	}
+
+	offset, err := strconv.ParseInt(ctx.Get(headerUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		return response.BadRequest(ctx, "Invalid Upload-Offset", nil)
+	}
+
+	var body io.Reader = bytes.NewReader(ctx.Body())
+	if stream := ctx.Request().BodyStream(); stream != nil {
+		body = stream
+	}
+
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	upload, created, err := h.Usecase.AppendResumable(ctx.UserContext(), userID, ctx.Params("id"), offset, body)
+	if err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	ctx.Set(headerUploadOffset, strconv.FormatInt(upload.Staged.Offset, 10))
+	if created != nil {
+		ctx.Set(fiber.HeaderContentLocation, ctx.BaseURL()+"/api/v1/files/"+created.ID)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary      Terminate a resumable upload
+// @Tags         files
+// @Param        Tus-Resumable  header    string  true  "1.0.0"
+// @Param        id             path      string  true  "Upload ID"
+// @Success      204
+// @Failure      401            {object}  response.BaseResponse
+// @Failure      404            {object}  response.BaseResponse
+// @Failure      412            {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/uploads/{id} [delete]
+func (h *Tus) Delete(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	if err := h.Usecase.TerminateResumable(ctx.UserContext(), userID, ctx.Params("id")); err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// parseTusMetadata decodes Upload-Metadata, comma separated pairs of a key and a base64 value
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
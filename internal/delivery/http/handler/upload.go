@@ -1,9 +1,12 @@
 package handler
 
 import (
-	dtoresponse "goilerplate/internal/delivery/http/dto/response"
+	dtorequest "goilerplate/internal/delivery/http/dto/request"
+	"goilerplate/internal/delivery/http/presenter"
+	"goilerplate/internal/delivery/http/request"
+	"goilerplate/internal/domain/file"
 	"goilerplate/pkg/constants"
-	"goilerplate/pkg/filesystem"
+	"goilerplate/pkg/pagination"
 	"goilerplate/pkg/response"
 
 	"github.com/go-playground/validator/v10"
@@ -11,154 +14,241 @@ import (
 )
 
 type Upload struct {
-	validator     *validator.Validate
-	filesystemMgr *filesystem.Manager
-	maxFileSize   int64
+	validator *validator.Validate
+	Usecase   file.Usecase
 }
 
-func NewUpload(validator *validator.Validate, filesystemMgr *filesystem.Manager, maxFileSize int64) *Upload {
+func NewUpload(validator *validator.Validate, usecase file.Usecase) *Upload {
 	return &Upload{
-		validator:     validator,
-		filesystemMgr: filesystemMgr,
-		maxFileSize:   maxFileSize,
+		validator: validator,
+		Usecase:   usecase,
 	}
 }
 
-// UploadFile handles single file upload
+// @Summary      Upload file
+// @Tags         files
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "File to upload"
+// @Success      201   {object}  response.BaseResponse{data=dtoresponse.UploadFileResponse}
+// @Failure      400   {object}  response.BaseResponse
+// @Failure      401   {object}  response.BaseResponse
+// @Failure      413   {object}  response.BaseResponse
+// @Failure      500   {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files [post]
 func (h *Upload) UploadFile(ctx *fiber.Ctx) error {
-	// Parse multipart form
-	file, err := ctx.FormFile("file")
+	header, err := ctx.FormFile("file")
 	if err != nil {
 		ctx.Locals("error_detail", err.Error())
-		return response.BadRequest(ctx, "File is required", nil)
+		return response.BadRequest(ctx, file.ErrFileRequired.Message, nil)
 	}
 
-	// Parse upload options from form
-	// Build upload options - server controls everything
-	opts := filesystem.UploadOptions{
-		Path:     "", // Empty = root folder
-		Filename: "", // Empty = auto-generate
-		Public:   true,
-	}
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
 
-	// Upload file
-	result, err := h.filesystemMgr.Upload(file, opts)
+	result, err := h.Usecase.Upload(ctx.UserContext(), userID, header)
 	if err != nil {
-		// Log the error for debugging
-		ctx.Locals("upload_error", err.Error())
-		ctx.Locals("file_name", file.Filename)
-		ctx.Locals("file_size", file.Size)
+		ctx.Locals("file_name", header.Filename)
+		ctx.Locals("file_size", header.Size)
 		return response.HandleError(ctx, err)
 	}
 
-	// Map to response DTO
-	responseData := &dtoresponse.UploadFileResponse{
-		OriginalName: result.OriginalName,
-		Filename:     result.Filename,
-		Path:         result.Path,
-		Size:         result.Size,
-		MimeType:     result.MimeType,
-		URL:          result.URL,
-		Driver:       string(result.Driver),
-	}
-
-	return response.Success(ctx, responseData, response.WithMessage("File uploaded successfully"))
+	return response.Created(ctx, presenter.ToFileResponse(result), response.WithMessage(file.MsgFileUploadedSuccessfully))
 }
 
-// UploadMultipleFiles handles multiple file uploads
+// @Summary      Upload multiple files
+// @Tags         files
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        files  formData  file  true  "Files to upload, every file is stored or none"
+// @Success      201    {object}  response.BaseResponse{data=[]dtoresponse.UploadFileResponse}
+// @Failure      400    {object}  response.BaseResponse
+// @Failure      401    {object}  response.BaseResponse
+// @Failure      413    {object}  response.BaseResponse
+// @Failure      500    {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/multiple [post]
 func (h *Upload) UploadMultipleFiles(ctx *fiber.Ctx) error {
-	// Parse multipart form
 	form, err := ctx.MultipartForm()
 	if err != nil {
 		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
 	}
 
-	files := form.File["files"]
-	if len(files) == 0 {
+	headers := form.File["files"]
+	if len(headers) == 0 {
 		return response.BadRequest(ctx, "At least one file is required", nil)
 	}
 
-	// Parse upload options from form
-	// Build upload options - server controls everything
-	opts := filesystem.UploadOptions{
-		Path:     "trash", // Empty = root folder
-		Filename: "",      // Empty = auto-generate
-		Public:   true,
-		MaxSize:  h.maxFileSize,
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	result, err := h.Usecase.UploadMany(ctx.UserContext(), userID, headers)
+	if err != nil {
+		return response.HandleError(ctx, err)
 	}
 
-	// Upload all files
-	var results []*dtoresponse.UploadFileResponse
-	for _, file := range files {
-		result, err := h.filesystemMgr.Upload(file, opts)
-		if err != nil {
-			return response.HandleError(ctx, err)
-		}
+	return response.Created(ctx, presenter.ToFileListResponse(result), response.WithMessage(file.MsgFilesUploadedSuccessfully))
+}
 
-		results = append(results, &dtoresponse.UploadFileResponse{
-			OriginalName: result.OriginalName,
-			Filename:     result.Filename,
-			Path:         result.Path,
-			Size:         result.Size,
-			MimeType:     result.MimeType,
-			URL:          result.URL,
-			Driver:       string(result.Driver),
-		})
+// @Summary      Presign a direct upload
+// @Description  Records a pending file and returns the request uploading its content straight to the storage. The size, type and checksum are enforced by the storage. Complete the upload afterwards.
+// @Tags         files
+// @Accept       json
+// @Produce      json
+// @Param        request  body      dtorequest.FilePresignRequest  true  "File to upload"
+// @Success      201      {object}  response.BaseResponse{data=dtoresponse.PresignUploadResponse}
+// @Failure      400      {object}  response.BaseResponse
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      413      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Failure      501      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/presign [post]
+func (h *Upload) Presign(ctx *fiber.Ctx) error {
+	var req dtorequest.FilePresignRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
 	}
 
-	if len(results) == 0 {
-		return response.BadRequest(ctx, "Failed to upload any file", nil)
+	if err := h.validator.Struct(&req); err != nil {
+		validationErrors := response.FormatValidationErrors(err)
+		return response.ValidationError(ctx, validationErrors)
 	}
 
-	return response.Success(ctx, results, response.WithMessage("Files uploaded successfully"))
-}
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
 
-// DeleteFile handles file deletion
-func (h *Upload) DeleteFile(ctx *fiber.Ctx) error {
-	path := ctx.Query("path")
-	if path == "" {
-		return response.BadRequest(ctx, "Path parameter is required", nil)
+	result, err := h.Usecase.PresignUpload(ctx.UserContext(), userID, &file.PresignRequest{
+		Filename: req.Filename,
+		Size:     req.Size,
+		MimeType: req.MimeType,
+		Checksum: req.Checksum,
+	})
+	if err != nil {
+		return response.HandleError(ctx, err)
 	}
 
-	if err := h.filesystemMgr.Delete(path); err != nil {
+	return response.Created(ctx, presenter.ToPresignUploadResponse(result), response.WithMessage(file.MsgFileUploadPresigned))
+}
+
+// @Summary      Complete a direct upload
+// @Tags         files
+// @Produce      json
+// @Param        id   path      string  true  "File ID"
+// @Success      200  {object}  response.BaseResponse{data=dtoresponse.UploadFileResponse}
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      409  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/{id}/complete [post]
+func (h *Upload) Complete(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	entity, err := h.Usecase.CompleteUpload(ctx.UserContext(), userID, id)
+	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
-	return response.Success(ctx, nil, response.WithMessage("File deleted successfully"))
+	return response.Success(ctx, presenter.ToFileResponse(entity), response.WithMessage(file.MsgFileUploadedSuccessfully))
 }
 
-// GetFileURL gets public URL for a file
-func (h *Upload) GetFileURL(ctx *fiber.Ctx) error {
-	path := ctx.Query("path")
-	if path == "" {
-		return response.BadRequest(ctx, "Path parameter is required", nil)
+// @Summary      List own files
+// @Tags         files
+// @Produce      json
+// @Param        keyword  query     string  false  "Search keyword, matched against the original name"
+// @Param        sort     query     string  false  "Sort fields, prefix with - for descending (e.g. -size,created_at)"
+// @Param        filter   query     string  false  "Filters as filter[field][op]=value (ops: eq, ne, gt, gte, lt, lte, like, in, null)"
+// @Param        page     query     int     false  "Page number"   default(1)
+// @Param        limit    query     int     false  "Page size"     default(10)
+// @Success      200      {object}  response.PaginatedResponse{data=[]dtoresponse.UploadFileResponse}
+// @Failure      400      {object}  response.BaseResponse
+// @Failure      401      {object}  response.BaseResponse
+// @Failure      500      {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files [get]
+func (h *Upload) List(ctx *fiber.Ctx) error {
+	var req dtorequest.FileListRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		return response.BadRequest(ctx, constants.MsgInvalidRequestBody, nil)
+	}
+
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	filter, err := request.ToFileFilter(&req, userID, ctx)
+	if err != nil {
+		return response.HandleError(ctx, err)
 	}
 
-	url, err := h.filesystemMgr.URL(path)
+	result, total, err := h.Usecase.GetList(ctx.UserContext(), filter)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
-	return response.Success(ctx, fiber.Map{
-		"path": path,
-		"url":  url,
-	}, response.WithMessage("URL retrieved successfully"))
+	fileResponses := presenter.ToFileListResponse(result)
+	paginatedResponse := pagination.NewPaginatedResponse(fileResponses, total, filter.Pagination.Page, filter.Pagination.Limit)
+
+	return response.Success(ctx, paginatedResponse, response.WithMessage(file.MsgFileListFetchSuccessfully))
 }
 
-// CheckFileExists checks if a file exists
-func (h *Upload) CheckFileExists(ctx *fiber.Ctx) error {
-	path := ctx.Query("path")
-	if path == "" {
-		return response.BadRequest(ctx, "Path parameter is required", nil)
+// @Summary      Get own file by ID
+// @Tags         files
+// @Produce      json
+// @Param        id   path      string  true  "File ID"
+// @Success      200  {object}  response.BaseResponse{data=dtoresponse.UploadFileResponse}
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/{id} [get]
+func (h *Upload) Get(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	entity, err := h.Usecase.GetByID(ctx.UserContext(), userID, id)
+	if err != nil {
+		return response.HandleError(ctx, err)
 	}
 
-	exists, err := h.filesystemMgr.Exists(path)
+	return response.Success(ctx, presenter.ToFileResponse(entity), response.WithMessage(file.MsgFileFetchedSuccessfully))
+}
+
+// @Summary      Delete own file
+// @Tags         files
+// @Produce      json
+// @Param        id   path      string  true  "File ID"
+// @Success      204  {object}  response.BaseResponse
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      404  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/files/{id} [delete]
+func (h *Upload) Delete(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	if err := h.Usecase.Delete(ctx.UserContext(), userID, id); err != nil {
+		return response.HandleError(ctx, err)
+	}
+
+	return response.NoContent(ctx)
+}
+
+// @Summary      Get own storage usage
+// @Description  Bytes stored by the user and by their tenant, with the configured quotas. Pending and unfinished resumable uploads reserve their declared size.
+// @Tags         files
+// @Produce      json
+// @Success      200  {object}  response.BaseResponse{data=dtoresponse.StorageUsageResponse}
+// @Failure      401  {object}  response.BaseResponse
+// @Failure      500  {object}  response.BaseResponse
+// @Security     BearerAuth
+// @Router       /api/v1/me/storage [get]
+func (h *Upload) Usage(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(string(constants.ContextKeyUserID)).(string)
+
+	usage, err := h.Usecase.GetUsage(ctx.UserContext(), userID)
 	if err != nil {
 		return response.HandleError(ctx, err)
 	}
 
-	return response.Success(ctx, fiber.Map{
-		"path":   path,
-		"exists": exists,
-	}, response.WithMessage("File existence checked successfully"))
+	return response.Success(ctx, presenter.ToStorageUsageResponse(userID, usage), response.WithMessage(file.MsgStorageUsageFetched))
 }
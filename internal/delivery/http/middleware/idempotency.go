@@ -17,7 +17,7 @@ type idempotencyCached struct {
 	Body       []byte `json:"body"`
 }
 
-// NewIdempotency returns a middleware that deduplicates POST requests using
+// NewIdempotency returns a middleware that deduplicates requests using
 // the Idempotency-Key header. Requests without the header pass through normally.
 // When storage is nil (Redis disabled), the middleware is a no-op.
 // Apply per-route on sensitive endpoints (payments, transfers, etc.).
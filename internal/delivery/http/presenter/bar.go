@@ -1,17 +1,28 @@
 package presenter
 
 import (
+	"errors"
+
 	dtoresponse "goilerplate/internal/delivery/http/dto/response"
 	"goilerplate/internal/domain/bar"
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/utils"
 )
 
 // ToBarResponse converts a single bar entity to DTO
 func ToBarResponse(entity *bar.Bar) *dtoresponse.BarResponse {
-	return &dtoresponse.BarResponse{
+	res := &dtoresponse.BarResponse{
 		ID:   entity.ID,
 		Code: entity.Code,
 		Bar:  entity.Bar,
 	}
+	if entity.Creator != nil {
+		res.Creator = &dtoresponse.BarCreatorResponse{
+			ID:   entity.Creator.ID,
+			Name: entity.Creator.Name,
+		}
+	}
+	return res
 }
 
 // ToBarListResponse converts multiple bar entities to DTOs
@@ -22,3 +33,102 @@ func ToBarListResponse(entities []*bar.Bar) []*dtoresponse.BarResponse {
 	}
 	return responses
 }
+
+// ToBarPatchResponse converts a patched bar and its changed fields to DTO
+func ToBarPatchResponse(entity *bar.Bar, changed []string) *dtoresponse.BarPatchResponse {
+	if changed == nil {
+		changed = []string{}
+	}
+	return &dtoresponse.BarPatchResponse{
+		Bar:     ToBarResponse(entity),
+		Changed: changed,
+	}
+}
+
+// ToBarTrashListResponse converts soft deleted bar entities to DTOs
+func ToBarTrashListResponse(entities []*bar.Bar) []*dtoresponse.BarTrashResponse {
+	responses := make([]*dtoresponse.BarTrashResponse, len(entities))
+	for i, entity := range entities {
+		responses[i] = &dtoresponse.BarTrashResponse{
+			ID:        entity.ID,
+			Code:      entity.Code,
+			Bar:       entity.Bar,
+			DeletedAt: entity.DeletedAt,
+			DeletedBy: entity.DeletedBy,
+		}
+	}
+	return responses
+}
+
+// ToBarBulkResponse converts a bulk result to DTO. Only client errors are exposed per item.
+func ToBarBulkResponse(result *bar.BulkResult) *dtoresponse.BarBulkResponse {
+	items := make([]*dtoresponse.BarBulkItemResponse, len(result.Items))
+	for i, item := range result.Items {
+		items[i] = &dtoresponse.BarBulkItemResponse{
+			Index:  item.Index,
+			Status: string(item.Status),
+		}
+
+		if item.Err != nil {
+			items[i].Error = bulkErrorMessage(item.Err)
+			continue
+		}
+
+		if item.Status != bar.BulkStatusSkipped {
+			items[i].Data = ToBarResponse(item.Bar)
+		}
+	}
+
+	return &dtoresponse.BarBulkResponse{
+		Mode:      string(result.Mode),
+		Total:     len(result.Items),
+		Succeeded: result.Succeeded(),
+		Failed:    result.Failed(),
+		Items:     items,
+	}
+}
+
+// ToBarImportResponse converts an import result to DTO, rows maps item indexes to file row numbers
+func ToBarImportResponse(result *bar.BulkResult, rows []int, dryRun bool) *dtoresponse.BarImportResponse {
+	items := make([]*dtoresponse.BarImportRowResponse, len(result.Items))
+	for i, item := range result.Items {
+		items[i] = &dtoresponse.BarImportRowResponse{
+			Row:    rows[item.Index],
+			Status: string(item.Status),
+		}
+
+		if item.Err != nil {
+			items[i].Error = bulkErrorMessage(item.Err)
+			continue
+		}
+
+		if item.Status == bar.BulkStatusCreated {
+			items[i].ID = item.Bar.ID
+		}
+	}
+
+	return &dtoresponse.BarImportResponse{
+		DryRun:    dryRun,
+		Total:     len(result.Items),
+		Succeeded: result.Succeeded(),
+		Failed:    result.Failed(),
+		Rows:      items,
+	}
+}
+
+// BarExportHeader is the header row of bar exports, it matches the import columns
+var BarExportHeader = []string{"id", "code", "bar"}
+
+// ToBarExportRow converts a bar entity to an export row
+func ToBarExportRow(entity *bar.Bar) []string {
+	return []string{entity.ID, entity.Code, entity.Bar}
+}
+
+// bulkErrorMessage exposes client errors only
+func bulkErrorMessage(err error) string {
+	var clientErr *utils.ClientError
+	if errors.As(err, &clientErr) {
+		return clientErr.Message
+	}
+	return constants.MsgInternalServerError
+}
@@ -0,0 +1,78 @@
+package presenter
+
+import (
+	dtoresponse "goilerplate/internal/delivery/http/dto/response"
+	"goilerplate/internal/domain/file"
+)
+
+// ToFileResponse converts a single file entity to DTO
+func ToFileResponse(entity *file.File) *dtoresponse.UploadFileResponse {
+	var variants []dtoresponse.FileVariantResponse
+	for _, variant := range entity.Variants {
+		variants = append(variants, dtoresponse.FileVariantResponse{
+			Name:     variant.Name,
+			URL:      variant.URL,
+			MimeType: variant.MimeType,
+			Width:    variant.Width,
+			Height:   variant.Height,
+			Size:     variant.Size,
+		})
+	}
+
+	return &dtoresponse.UploadFileResponse{
+		ID:           entity.ID,
+		OriginalName: entity.OriginalName,
+		Path:         entity.Path,
+		Size:         entity.Size,
+		URL:          entity.URL,
+		MimeType:     entity.MimeType,
+		Checksum:     entity.Checksum,
+		Driver:       entity.Driver,
+		CreatedAt:    entity.CreatedAt,
+		Variants:     variants,
+	}
+}
+
+// ToFileListResponse converts multiple file entities to DTOs
+func ToFileListResponse(entities []*file.File) []*dtoresponse.UploadFileResponse {
+	responses := make([]*dtoresponse.UploadFileResponse, len(entities))
+	for i, entity := range entities {
+		responses[i] = ToFileResponse(entity)
+	}
+	return responses
+}
+
+// ToPresignUploadResponse converts a pending file and its presigned upload to DTO
+func ToPresignUploadResponse(presigned *file.PresignedFile) *dtoresponse.PresignUploadResponse {
+	return &dtoresponse.PresignUploadResponse{
+		File: ToFileResponse(presigned.File),
+		Upload: &dtoresponse.PresignedUploadRequest{
+			URL:       presigned.Upload.URL,
+			Method:    presigned.Upload.Method,
+			Headers:   presigned.Upload.Headers,
+			ExpiresAt: presigned.Upload.ExpiresAt,
+		},
+	}
+}
+
+// ToStorageUsageResponse converts the storage usage of a user and their tenant to DTO
+func ToStorageUsageResponse(userID string, usage *file.StorageUsage) *dtoresponse.StorageUsageResponse {
+	response := &dtoresponse.StorageUsageResponse{
+		User: toUsageResponse(userID, &usage.User),
+	}
+	if usage.Tenant != nil {
+		tenant := toUsageResponse(usage.TenantID, usage.Tenant)
+		response.Tenant = &tenant
+	}
+	return response
+}
+
+func toUsageResponse(id string, usage *file.Usage) dtoresponse.UsageResponse {
+	return dtoresponse.UsageResponse{
+		ID:        id,
+		Bytes:     usage.Bytes,
+		Files:     usage.Files,
+		Quota:     usage.Quota,
+		Remaining: usage.Remaining(),
+	}
+}
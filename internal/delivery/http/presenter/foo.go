@@ -7,10 +7,18 @@ import (
 
 // ToFooResponse converts a single foo entity to DTO
 func ToFooResponse(entity *foo.Foo) *dtoresponse.FooResponse {
-	panic("Implement me")
+	return &dtoresponse.FooResponse{
+		ID:   entity.ID,
+		Code: entity.Code,
+		Foo:  entity.Foo,
+	}
 }
 
-// ToFooListResponse converts multiple bar entities to DTOs
+// ToFooListResponse converts multiple foo entities to DTOs
 func ToFooListResponse(entities []*foo.Foo) []*dtoresponse.FooResponse {
-	panic("Implement me")
+	responses := make([]*dtoresponse.FooResponse, len(entities))
+	for i, entity := range entities {
+		responses[i] = ToFooResponse(entity)
+	}
+	return responses
 }
@@ -1,18 +1,158 @@
 package request
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
 	dtorequest "goilerplate/internal/delivery/http/dto/request"
 	"goilerplate/internal/domain/bar"
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/jsonpatch"
+	"goilerplate/pkg/listquery"
 	"goilerplate/pkg/pagination"
+	"goilerplate/pkg/spreadsheet"
+	"goilerplate/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func ToBarFilter(req *dtorequest.BarListRequest, ctx *fiber.Ctx) *bar.Filter {
+func ToBarFilter(req *dtorequest.BarListRequest, ctx *fiber.Ctx) (*bar.Filter, error) {
+	query, err := listquery.ParseHTTP(ctx, bar.ListAllowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := fieldset.ParseHTTP(ctx, bar.FieldAllowlist)
+	if err != nil {
+		return nil, err
+	}
+
 	filter := &bar.Filter{
+		Keyword:    req.Keyword,
+		Query:      query,
+		Selection:  selection,
+		Pagination: pagination.ParsePagination(ctx),
+	}
+
+	return filter, nil
+}
+
+// ToBarSelection parses the fields and expand parameters of a single bar request
+func ToBarSelection(ctx *fiber.Ctx) (*fieldset.Selection, error) {
+	return fieldset.ParseHTTP(ctx, bar.FieldAllowlist)
+}
+
+// ToBarTrashFilter builds the trash list filter, the trash is always sorted by deletion time
+func ToBarTrashFilter(req *dtorequest.BarListRequest, ctx *fiber.Ctx) *bar.Filter {
+	return &bar.Filter{
 		Keyword:    req.Keyword,
 		Pagination: pagination.ParsePagination(ctx),
 	}
+}
+
+// ToBarPatch applies a merge patch (RFC 7386) or a JSON patch (RFC 6902), selected by
+// content type, to the JSON view of current and returns the resulting domain patch
+func ToBarPatch(current *bar.Bar, contentType string, body []byte) (*bar.Patch, error) {
+	document, err := json.Marshal(&dtorequest.BarPatchDocument{
+		ID:   current.ID,
+		Code: current.Code,
+		Bar:  current.Bar,
+	})
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
 
-	return filter
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case jsonpatch.ContentTypeMergePatch, fiber.MIMEApplicationJSON:
+		document, err = jsonpatch.MergePatch(document, body)
+	case jsonpatch.ContentTypeJSONPatch:
+		document, err = jsonpatch.Apply(document, body)
+	default:
+		return nil, utils.ClientErr(415, fmt.Sprintf("Content-Type must be %s or %s", jsonpatch.ContentTypeMergePatch, jsonpatch.ContentTypeJSONPatch))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var patched dtorequest.BarPatchDocument
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return nil, utils.ClientErr(400, "Patched bar is invalid", err)
+	}
+	if patched.ID != current.ID {
+		return nil, utils.ClientErr(400, "id cannot be changed")
+	}
+
+	return &bar.Patch{
+		Code: &patched.Code,
+		Bar:  &patched.Bar,
+	}, nil
+}
+
+// ToBarImportRows reads the bars of an import file. The first row is the header and must
+// contain the code and bar columns in any order, other columns are ignored. Blank rows are
+// skipped. It also returns the file row number of every entity for error reporting.
+func ToBarImportRows(reader spreadsheet.Reader, maxRows int) ([]*bar.Bar, []int, error) {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, utils.ClientErr(400, "Import file is empty")
+	}
+	if err != nil {
+		return nil, nil, utils.ClientErr(400, "Import file could not be read", err)
+	}
+
+	columns := map[string]int{"code": -1, "bar": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if index, ok := columns[name]; ok && index < 0 {
+			columns[name] = i
+		}
+	}
+	if columns["code"] < 0 || columns["bar"] < 0 {
+		return nil, nil, utils.ClientErr(400, "Import file must have code and bar columns")
+	}
+
+	var (
+		entities []*bar.Bar
+		rows     []int
+	)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, utils.ClientErr(400, fmt.Sprintf("Import file could not be read at row %d", line), err)
+		}
+
+		code, barValue := cell(row, columns["code"]), cell(row, columns["bar"])
+		if code == "" && barValue == "" {
+			continue
+		}
+		if len(entities) == maxRows {
+			return nil, nil, utils.ClientErr(400, fmt.Sprintf("Import file exceeds the maximum of %d rows", maxRows))
+		}
+
+		entities = append(entities, &bar.Bar{Code: code, Bar: barValue})
+		rows = append(rows, line)
+	}
+
+	if len(entities) == 0 {
+		return nil, nil, utils.ClientErr(400, "Import file has no rows")
+	}
+
+	return entities, rows, nil
+}
+
+func cell(row []string, index int) string {
+	if index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
 }
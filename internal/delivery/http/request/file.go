@@ -0,0 +1,26 @@
+package request
+
+import (
+	dtorequest "goilerplate/internal/delivery/http/dto/request"
+	"goilerplate/internal/domain/file"
+	"goilerplate/pkg/listquery"
+	"goilerplate/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ToFileFilter(req *dtorequest.FileListRequest, ownerID string, ctx *fiber.Ctx) (*file.Filter, error) {
+	query, err := listquery.ParseHTTP(ctx, file.ListAllowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &file.Filter{
+		OwnerID:    ownerID,
+		Keyword:    req.Keyword,
+		Query:      query,
+		Pagination: pagination.ParsePagination(ctx),
+	}
+
+	return filter, nil
+}
@@ -3,10 +3,23 @@ package request
 import (
 	dtorequest "goilerplate/internal/delivery/http/dto/request"
 	"goilerplate/internal/domain/foo"
+	"goilerplate/pkg/listquery"
+	"goilerplate/pkg/pagination"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func ToFooFilter(req *dtorequest.FooListRequest, ctx *fiber.Ctx) *foo.Filter {
-	panic("Implement me")
+func ToFooFilter(req *dtorequest.FooListRequest, ctx *fiber.Ctx) (*foo.Filter, error) {
+	query, err := listquery.ParseHTTP(ctx, foo.ListAllowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &foo.Filter{
+		Keyword:    req.Keyword,
+		Query:      query,
+		Pagination: pagination.ParsePagination(ctx),
+	}
+
+	return filter, nil
 }
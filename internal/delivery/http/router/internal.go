@@ -17,6 +17,7 @@ func (r *InternalRouteRegistry) register(route fiber.Router) {
 
 	r.foo(internal)
 	r.bar(internal)
+	// scaffold:internal-routes
 }
 
 func (r *InternalRouteRegistry) foo(internal fiber.Router) {
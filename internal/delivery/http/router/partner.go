@@ -18,6 +18,7 @@ func (r *PartnerRouteRegistry) register(route fiber.Router) {
 
 	r.foo(v1)
 	r.bar(v1)
+	// scaffold:partner-routes
 }
 
 func (r *PartnerRouteRegistry) foo(v1 fiber.Router) {
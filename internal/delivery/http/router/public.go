@@ -27,6 +27,9 @@ func (r *PublicRouteRegistry) register(route fiber.Router) {
 
 	r.foo(v1)
 	r.bar(v1)
+	r.file(v1)
+	r.me(v1)
+	// scaffold:public-routes
 }
 
 func (r *PublicRouteRegistry) foo(v1 fiber.Router) {
@@ -59,10 +62,42 @@ func (r *PublicRouteRegistry) bar(v1 fiber.Router) {
 		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarCreate),
 		r.Wired.Handlers.Bar.Create)
 
+	// Static paths (import, bulk, export, trash) are registered before /:id so they are not taken for an id
+	bar.Post("/import",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarImport),
+		r.Wired.Handlers.Bar.Import)
+
+	bar.Post("/bulk",
+		middleware.RequireIdempotencyKey(), r.Wired.Middleware.Idempotency,
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarCreate),
+		r.Wired.Handlers.Bar.BulkCreate)
+
+	bar.Put("/bulk",
+		middleware.RequireIdempotencyKey(), r.Wired.Middleware.Idempotency,
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarUpdate),
+		r.Wired.Handlers.Bar.BulkUpdate)
+
+	bar.Delete("/bulk",
+		middleware.RequireIdempotencyKey(), r.Wired.Middleware.Idempotency,
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarDelete),
+		r.Wired.Handlers.Bar.BulkDelete)
+
+	bar.Post("/trash/:id/restore",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarRestore),
+		r.Wired.Handlers.Bar.Restore)
+
+	bar.Delete("/trash/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarHardDelete),
+		r.Wired.Handlers.Bar.HardDelete)
+
 	bar.Put("/:id",
 		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarUpdate),
 		r.Wired.Handlers.Bar.Update)
 
+	bar.Patch("/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarUpdate),
+		r.Wired.Handlers.Bar.Patch)
+
 	bar.Delete("/:id",
 		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarDelete),
 		r.Wired.Handlers.Bar.Delete)
@@ -71,7 +106,72 @@ func (r *PublicRouteRegistry) bar(v1 fiber.Router) {
 		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarList),
 		r.Wired.Handlers.Bar.List)
 
+	bar.Get("/export",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarExport),
+		r.Wired.Handlers.Bar.Export)
+
+	bar.Get("/trash",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarTrash),
+		r.Wired.Handlers.Bar.Trash)
+
 	bar.Get("/:id",
 		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionBarGet),
 		r.Wired.Handlers.Bar.Get)
 }
+
+func (r *PublicRouteRegistry) file(v1 fiber.Router) {
+	file := v1.Group("files")
+	file.Post("",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Upload.UploadFile)
+
+	file.Post("/multiple",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Upload.UploadMultipleFiles)
+
+	file.Post("/presign",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Upload.Presign)
+
+	file.Post("/:id/complete",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Upload.Complete)
+
+	// Resumable uploads (tus), registered before /:id so they are not taken for an id
+	uploads := file.Group("/uploads", r.Wired.Handlers.Tus.Resumable)
+	uploads.Options("", r.Wired.Handlers.Tus.Options)
+	uploads.Post("",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Tus.Create)
+
+	uploads.Head("/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Tus.Head)
+
+	uploads.Patch("/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Tus.Patch)
+
+	uploads.Delete("/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileUpload),
+		r.Wired.Handlers.Tus.Delete)
+
+	file.Get("",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileList),
+		r.Wired.Handlers.Upload.List)
+
+	file.Get("/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileGet),
+		r.Wired.Handlers.Upload.Get)
+
+	file.Delete("/:id",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileDelete),
+		r.Wired.Handlers.Upload.Delete)
+}
+
+func (r *PublicRouteRegistry) me(v1 fiber.Router) {
+	me := v1.Group("me")
+	me.Get("/storage",
+		r.Wired.Middleware.Auth.RequiredPermission(constants.PermissionFileList),
+		r.Wired.Handlers.Upload.Usage)
+}
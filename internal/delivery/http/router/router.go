@@ -136,6 +136,10 @@ func (r *RouteRegistry) Register() {
 	}
 	http.Use(r.Wired.Middleware.RequestLogger.LogRequest())
 
+	// Signed URLs of the local driver, the signature is the authorization
+	http.Get("/storage/signed/*", r.Wired.Handlers.Storage.Download)
+	http.Put("/storage/signed/*", r.Wired.Handlers.Storage.Upload)
+
 	(&InternalRouteRegistry{
 		App:   r.App,
 		Wired: r.Wired,
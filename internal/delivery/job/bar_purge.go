@@ -0,0 +1,61 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goilerplate/config"
+	"goilerplate/internal/domain/bar"
+	"goilerplate/pkg/logger"
+	"goilerplate/pkg/utils"
+)
+
+// Defaults applied when the trash config is not set
+const (
+	defaultRetentionDays = 30
+	defaultPurgeInterval = 24 * time.Hour
+)
+
+// BarPurge permanently deletes bars that stayed in the trash longer than the retention period
+type BarPurge struct {
+	usecase   bar.Usecase
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewBarPurge(usecase bar.Usecase, cfg config.Trash) *BarPurge {
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = defaultRetentionDays
+	}
+	if cfg.PurgeInterval <= 0 {
+		cfg.PurgeInterval = defaultPurgeInterval
+	}
+
+	return &BarPurge{
+		usecase:   usecase,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		interval:  cfg.PurgeInterval,
+	}
+}
+
+func (j *BarPurge) Name() string {
+	return "bar_purge"
+}
+
+func (j *BarPurge) Interval() time.Duration {
+	return j.interval
+}
+
+func (j *BarPurge) Run(ctx context.Context) error {
+	purged, err := j.usecase.Purge(ctx, utils.Now().Add(-j.retention))
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		logger.Info(ctx, fmt.Sprintf("purged %d deleted bars", purged))
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goilerplate/config"
+	"goilerplate/internal/domain/file"
+	"goilerplate/pkg/logger"
+)
+
+// defaultReconcileInterval is applied when the quota config does not set one
+const defaultReconcileInterval = 24 * time.Hour
+
+// FileReconcile recomputes the stored sizes counted against storage quotas from the storage listing
+type FileReconcile struct {
+	usecase  file.Usecase
+	interval time.Duration
+}
+
+func NewFileReconcile(usecase file.Usecase, cfg config.StorageQuota) *FileReconcile {
+	if cfg.ReconcileInterval <= 0 {
+		cfg.ReconcileInterval = defaultReconcileInterval
+	}
+
+	return &FileReconcile{
+		usecase:  usecase,
+		interval: cfg.ReconcileInterval,
+	}
+}
+
+func (j *FileReconcile) Name() string {
+	return "file_reconcile"
+}
+
+func (j *FileReconcile) Interval() time.Duration {
+	return j.interval
+}
+
+func (j *FileReconcile) Run(ctx context.Context) error {
+	result, err := j.usecase.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+
+	if result.Updated > 0 || result.Released > 0 {
+		logger.Info(ctx, fmt.Sprintf("reconciled %d of %d files, released %d expired uploads", result.Updated, result.Checked, result.Released))
+	}
+	if result.Missing > 0 || result.Orphans > 0 {
+		logger.Warn(ctx, fmt.Sprintf("%d files are missing from the storage, %d stored files (%d bytes) have no metadata", result.Missing, result.Orphans, result.OrphanBytes))
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goilerplate/config"
+	"goilerplate/internal/domain/file"
+	"goilerplate/pkg/logger"
+)
+
+// defaultCleanupInterval is applied when the resumable config does not set one
+const defaultCleanupInterval = time.Hour
+
+// FileUploadCleanup discards resumable uploads that expired unfinished, with their staged chunks
+type FileUploadCleanup struct {
+	usecase  file.Usecase
+	interval time.Duration
+}
+
+func NewFileUploadCleanup(usecase file.Usecase, cfg config.ResumableUpload) *FileUploadCleanup {
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultCleanupInterval
+	}
+
+	return &FileUploadCleanup{
+		usecase:  usecase,
+		interval: cfg.CleanupInterval,
+	}
+}
+
+func (j *FileUploadCleanup) Name() string {
+	return "file_upload_cleanup"
+}
+
+func (j *FileUploadCleanup) Interval() time.Duration {
+	return j.interval
+}
+
+func (j *FileUploadCleanup) Run(ctx context.Context) error {
+	removed, err := j.usecase.CleanupResumable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if removed > 0 {
+		logger.Info(ctx, fmt.Sprintf("removed %d expired uploads", removed))
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+// Package job runs background jobs inside the server process
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"goilerplate/pkg/logger"
+)
+
+// Job is a task that runs periodically
+type Job interface {
+	Name() string
+	Interval() time.Duration
+	Run(ctx context.Context) error
+}
+
+// Scheduler runs every registered job once on start and then at its interval
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs: jobs,
+	}
+}
+
+// Start runs the jobs in the background until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Wait blocks until every running job returned, call it after cancelling the Start context
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval())
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes a single run, a failing or panicking job is logged and retried at the next tick
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(ctx, fmt.Errorf("job %s panicked: %v", job.Name(), r))
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		logger.Error(ctx, fmt.Errorf("job %s failed: %w", job.Name(), err))
+	}
+}
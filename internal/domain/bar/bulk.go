@@ -0,0 +1,108 @@
+package bar
+
+// BulkMode controls how a bulk operation reacts to items that fail
+type BulkMode string
+
+const (
+	BulkModeAtomic  BulkMode = "atomic"  // nothing is written when any item fails
+	BulkModePartial BulkMode = "partial" // valid items are written, failed items are reported
+)
+
+// BulkStatus is the outcome of a single bulk item
+type BulkStatus string
+
+const (
+	BulkStatusCreated BulkStatus = "created"
+	BulkStatusUpdated BulkStatus = "updated"
+	BulkStatusDeleted BulkStatus = "deleted"
+	BulkStatusFailed  BulkStatus = "failed"
+	BulkStatusSkipped BulkStatus = "skipped" // valid, but not written because the atomic batch was rejected
+	BulkStatusValid   BulkStatus = "valid"   // valid, not written because of a dry run
+)
+
+// BulkItemResult reports the outcome of the item at Index in the request
+type BulkItemResult struct {
+	Index  int
+	Bar    *Bar
+	Status BulkStatus
+	Err    error
+}
+
+// BulkResult holds the per-item outcome of a bulk operation, in request order
+type BulkResult struct {
+	Mode  BulkMode
+	Items []*BulkItemResult
+}
+
+// Succeeded returns the number of items that were written
+func (r *BulkResult) Succeeded() int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Err == nil && item.Status != BulkStatusSkipped && item.Status != BulkStatusValid {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed returns the number of items that were rejected
+func (r *BulkResult) Failed() int {
+	count := 0
+	for _, item := range r.Items {
+		if item.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func newBulkResult(mode BulkMode, size int) *BulkResult {
+	if mode != BulkModePartial {
+		mode = BulkModeAtomic
+	}
+	return &BulkResult{Mode: mode, Items: make([]*BulkItemResult, 0, size)}
+}
+
+func (r *BulkResult) fail(item *BulkItemResult, err error) {
+	item.Status = BulkStatusFailed
+	item.Err = err
+}
+
+// pending returns the items that passed validation and wait to be written
+func (r *BulkResult) pending() []*BulkItemResult {
+	var items []*BulkItemResult
+	for _, item := range r.Items {
+		if item.Err == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// rejectAtomic marks the pending items as skipped when an atomic batch has failures.
+// It reports whether the batch was rejected.
+func (r *BulkResult) rejectAtomic() bool {
+	if r.Mode != BulkModeAtomic || r.Failed() == 0 {
+		return false
+	}
+	for _, item := range r.pending() {
+		item.Status = BulkStatusSkipped
+	}
+	return true
+}
+
+// complete marks every pending item as written with status
+func (r *BulkResult) complete(status BulkStatus) {
+	for _, item := range r.pending() {
+		item.Status = status
+	}
+}
+
+// entitiesOf returns the entities of items
+func entitiesOf(items []*BulkItemResult) []*Bar {
+	entities := make([]*Bar, len(items))
+	for i, item := range items {
+		entities[i] = item.Bar
+	}
+	return entities
+}
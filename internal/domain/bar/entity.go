@@ -2,6 +2,7 @@ package bar
 
 import (
 	"strings"
+	"time"
 
 	"goilerplate/pkg/utils"
 )
@@ -10,6 +11,25 @@ type Bar struct {
 	ID   string
 	Code string
 	Bar  string
+
+	// Version is incremented on every update. On updates and deletes a non-zero
+	// version is the version the client expects, see ErrVersionMismatch.
+	Version int64
+
+	// CreatedBy is the id of the user who created the bar, Creator is set only
+	// when the creator expansion was requested
+	CreatedBy string
+	Creator   *Creator
+
+	// Set only for bars in the trash
+	DeletedAt *time.Time
+	DeletedBy string
+}
+
+// Creator is the public profile of the user who created a bar, anyone reading the bar sees it
+type Creator struct {
+	ID   string
+	Name string
 }
 
 func (e *Bar) validate() error {
@@ -27,9 +47,21 @@ func (e *Bar) validate() error {
 }
 
 func (e *Bar) Clone() *Bar {
-	return &Bar{
-		ID:   e.ID,
-		Code: e.Code,
-		Bar:  e.Bar,
+	clone := &Bar{
+		ID:        e.ID,
+		Code:      e.Code,
+		Bar:       e.Bar,
+		Version:   e.Version,
+		CreatedBy: e.CreatedBy,
+		DeletedBy: e.DeletedBy,
+	}
+	if e.Creator != nil {
+		creator := *e.Creator
+		clone.Creator = &creator
+	}
+	if e.DeletedAt != nil {
+		deletedAt := *e.DeletedAt
+		clone.DeletedAt = &deletedAt
 	}
+	return clone
 }
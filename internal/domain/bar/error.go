@@ -7,6 +7,17 @@ var (
 	ErrCodeAlreadyExists = utils.ClientErr(409, "Code already exists")
 	ErrAlreadyDeleted    = utils.ClientErr(410, "Bar is already deleted")
 	ErrCannotBeDeleted   = utils.ClientErr(403, "Bar cannot be deleted due to business rules")
+	ErrVersionMismatch   = utils.ClientErr(412, "Bar was modified by another request, fetch it again and retry")
+
+	// Bulk errors
+	ErrIDRequired     = utils.ClientErr(400, "id is required")
+	ErrDuplicateCode  = utils.ClientErr(400, "Code appears more than once in the batch")
+	ErrDuplicateID    = utils.ClientErr(400, "Bar appears more than once in the batch")
+	ErrBulkRejected   = utils.ClientErr(422, "Bulk request rejected, no bars were written")
+	ErrImportRejected = utils.ClientErr(422, "Import rejected, no bars were written")
+
+	// Trash errors
+	ErrNotInTrash = utils.ClientErr(404, "Bar not found in trash")
 
 	// Operation errors
 	ErrNotFound = utils.ClientErr(404, "Bar not found")
@@ -1,6 +1,8 @@
 package bar
 
 import (
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/listquery"
 	"goilerplate/pkg/pagination"
 )
 
@@ -8,5 +10,34 @@ type Filter struct {
 	Keyword string
 	Code    string
 
+	Query      *listquery.Query
+	Selection  *fieldset.Selection
 	Pagination *pagination.PaginationRequest
 }
+
+// ExpandCreator embeds the user who created a bar
+const ExpandCreator = "creator"
+
+// ListAllowlist lists the fields clients may sort and filter bars by
+var ListAllowlist = listquery.Allowlist{
+	Fields: map[string]listquery.Field{
+		"code":       {Column: "code", Ops: listquery.StringOps, Sortable: true},
+		"bar":        {Column: "bar", Ops: listquery.StringOps, Sortable: true},
+		"created_at": {Column: "created_at", Type: listquery.TypeTime, Ops: listquery.TimeOps, Sortable: true},
+		"updated_at": {Column: "updated_at", Type: listquery.TypeTime, Ops: listquery.TimeOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+// FieldAllowlist lists the fields clients may select and the relations they may expand
+var FieldAllowlist = fieldset.Allowlist{
+	Fields: map[string]string{
+		"id":   "id",
+		"code": "code",
+		"bar":  "bar",
+	},
+	Expansions: map[string]fieldset.Expansion{
+		ExpandCreator: {Columns: []string{"created_by"}},
+	},
+	Always: []string{"id"},
+}
@@ -2,9 +2,14 @@ package bar
 
 // Success Messages
 const (
-	MsgBarCreatedSuccessfully   = "Bar created successfully"
-	MsgBarUpdatedSuccessfully   = "Bar updated successfully"
-	MsgBarDeletedSuccessfully   = "Bar deleted successfully"
-	MsgBarFetchedSuccessfully   = "Bar fetched successfully"
-	MsgBarListFetchSuccessfully = "Bars fetched successfully"
+	MsgBarCreatedSuccessfully    = "Bar created successfully"
+	MsgBarUpdatedSuccessfully    = "Bar updated successfully"
+	MsgBarDeletedSuccessfully    = "Bar deleted successfully"
+	MsgBarFetchedSuccessfully    = "Bar fetched successfully"
+	MsgBarListFetchSuccessfully  = "Bars fetched successfully"
+	MsgBarBulkProcessed          = "Bulk request processed"
+	MsgBarImportValidated        = "Import file is valid, no bars were written"
+	MsgBarImportedSuccessfully   = "Bars imported successfully"
+	MsgBarTrashFetchSuccessfully = "Deleted bars fetched successfully"
+	MsgBarRestoredSuccessfully   = "Bar restored successfully"
 )
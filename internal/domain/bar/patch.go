@@ -0,0 +1,44 @@
+package bar
+
+import "strings"
+
+// Patchable fields, the names match the JSON view of a bar
+const (
+	FieldCode = "code"
+	FieldBar  = "bar"
+)
+
+// Patch holds the fields of a partial update, nil fields are left unchanged
+type Patch struct {
+	Code *string
+	Bar  *string
+}
+
+// applyPatch returns a copy of e with the patch applied and normalized, along with the
+// names of the fields whose value actually changed
+func (e *Bar) applyPatch(patch *Patch) (*Bar, []string, error) {
+	patched := e.Clone()
+	if patch.Code != nil {
+		patched.Code = *patch.Code
+	}
+	if patch.Bar != nil {
+		patched.Bar = *patch.Bar
+	}
+
+	if err := patched.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	patched.Code = strings.ToUpper(strings.TrimSpace(patched.Code))
+	patched.Bar = strings.TrimSpace(patched.Bar)
+
+	var changed []string
+	if patched.Code != e.Code {
+		changed = append(changed, FieldCode)
+	}
+	if patched.Bar != e.Bar {
+		changed = append(changed, FieldBar)
+	}
+
+	return patched, changed, nil
+}
@@ -2,6 +2,10 @@ package bar
 
 import (
 	"context"
+	"time"
+
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/pagination"
 )
 
 type Repository interface {
@@ -9,10 +13,28 @@ type Repository interface {
 
 	CreateBar(ctx context.Context, entities *Bar) (*Bar, error)
 	UpdateBar(ctx context.Context, entities *Bar) error
+	PatchBar(ctx context.Context, entity *Bar, fields []string) error
 	DeleteBar(ctx context.Context, entities *Bar) error
 	BulkCreate(ctx context.Context, entities []*Bar) error
+	BulkUpdate(ctx context.Context, entities []*Bar) error
+	BulkDelete(ctx context.Context, ids []string) error
 
 	CountBar(ctx context.Context, filter *Filter) (int64, error)
 	GetBarList(ctx context.Context, filter *Filter) ([]*Bar, error)
+	GetBarListByCursor(ctx context.Context, filter *Filter) ([]*Bar, *pagination.CursorPage, error)
 	GetBarByID(ctx context.Context, id string) (*Bar, error)
+	// GetCurrentBar always reads the database, writes check their version against it
+	GetCurrentBar(ctx context.Context, id string) (*Bar, error)
+	GetBarView(ctx context.Context, id string, selection *fieldset.Selection) (*Bar, error)
+	GetBarsByIDs(ctx context.Context, ids []string) ([]*Bar, error)
+	GetBarsByCodes(ctx context.Context, codes []string) ([]*Bar, error)
+	StreamBars(ctx context.Context, filter *Filter, fn func(*Bar) error) error
+
+	// Trash, these only see soft deleted bars
+	CountTrash(ctx context.Context, filter *Filter) (int64, error)
+	GetTrashList(ctx context.Context, filter *Filter) ([]*Bar, error)
+	GetDeletedBarByID(ctx context.Context, id string) (*Bar, error)
+	RestoreBar(ctx context.Context, id string) error
+	HardDeleteBar(ctx context.Context, id string) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
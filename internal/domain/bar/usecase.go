@@ -4,19 +4,41 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
+
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/pagination"
 )
 
 type Usecase interface {
 	Create(ctx context.Context, entity *Bar) (*Bar, error)
 	Update(ctx context.Context, entity *Bar) (*Bar, error)
+	Patch(ctx context.Context, id string, version int64, patch *Patch) (*Bar, []string, error)
 	Delete(ctx context.Context, entity *Bar) error
 
 	GetByID(ctx context.Context, id string) (*Bar, error)
+	// GetForUpdate reads the stored bar past any cache, for reads that precede a write
+	GetForUpdate(ctx context.Context, id string) (*Bar, error)
+	GetView(ctx context.Context, id string, selection *fieldset.Selection) (*Bar, error)
 	GetList(ctx context.Context, filter *Filter) ([]*Bar, int64, error)
+	GetListByCursor(ctx context.Context, filter *Filter) ([]*Bar, *pagination.CursorPage, error)
+
+	BulkCreate(ctx context.Context, entities []*Bar, mode BulkMode) (*BulkResult, error)
+	BulkUpdate(ctx context.Context, entities []*Bar, mode BulkMode) (*BulkResult, error)
+	BulkDelete(ctx context.Context, ids []string, mode BulkMode) (*BulkResult, error)
 
-	BulkCreate(ctx context.Context, entities []*Bar) error
+	Import(ctx context.Context, entities []*Bar, dryRun bool) (*BulkResult, error)
+	Export(ctx context.Context, filter *Filter, fn func(*Bar) error) error
+
+	ListTrash(ctx context.Context, filter *Filter) ([]*Bar, int64, error)
+	Restore(ctx context.Context, id string) (*Bar, error)
+	HardDelete(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
+// importChunkSize is the number of rows inserted per statement during an import
+const importChunkSize = 500
+
 type usecase struct {
 	repo Repository
 }
@@ -32,14 +54,7 @@ func (uc *usecase) Create(ctx context.Context, entity *Bar) (*Bar, error) {
 		return nil, err
 	}
 
-	exists, err := uc.ExistsByCode(ctx, entity.Code)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check code existence: %w", err)
-	}
-	if exists {
-		return nil, ErrCodeAlreadyExists
-	}
-
+	// Code uniqueness is enforced by the database, the repository returns ErrCodeAlreadyExists
 	entity.Code = strings.ToUpper(strings.TrimSpace(entity.Code))
 	entity.Bar = strings.TrimSpace(entity.Bar)
 
@@ -51,37 +66,20 @@ func (uc *usecase) Create(ctx context.Context, entity *Bar) (*Bar, error) {
 	return created, nil
 }
 
-func (uc *usecase) ExistsByCode(ctx context.Context, code string) (bool, error) {
-	filter := &Filter{
-		Code: strings.ToUpper(strings.TrimSpace(code)),
-	}
-
-	bars, err := uc.repo.GetBarList(ctx, filter)
-	if err != nil {
-		return false, fmt.Errorf("failed to check code existence: %w", err)
-	}
-
-	return len(bars) > 0, nil
-}
-
+// Update updates a bar, a non-zero entity.Version must match the stored version.
+// The returned bar carries the new version.
 func (uc *usecase) Update(ctx context.Context, entity *Bar) (*Bar, error) {
 	if err := entity.validate(); err != nil {
 		return nil, err
 	}
 
-	existing, err := uc.repo.GetBarByID(ctx, entity.ID)
+	existing, err := uc.repo.GetCurrentBar(ctx, entity.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing bar: %w", err)
 	}
 
-	if existing.Code != entity.Code {
-		exists, err := uc.ExistsByCode(ctx, entity.Code)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check code existence: %w", err)
-		}
-		if exists {
-			return nil, ErrCodeAlreadyExists
-		}
+	if entity.Version > 0 && entity.Version != existing.Version {
+		return nil, ErrVersionMismatch
 	}
 
 	entity.Code = strings.ToUpper(strings.TrimSpace(entity.Code))
@@ -94,12 +92,45 @@ func (uc *usecase) Update(ctx context.Context, entity *Bar) (*Bar, error) {
 	return entity, nil
 }
 
+// Patch applies a partial update. Only the changed fields are written, a patch that
+// changes nothing writes nothing. A non-zero version must match the stored version.
+// It returns the patched bar and the names of the changed fields.
+func (uc *usecase) Patch(ctx context.Context, id string, version int64, patch *Patch) (*Bar, []string, error) {
+	existing, err := uc.repo.GetCurrentBar(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get existing bar: %w", err)
+	}
+
+	if version > 0 && version != existing.Version {
+		return nil, nil, ErrVersionMismatch
+	}
+
+	patched, changed, err := existing.applyPatch(patch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changed) == 0 {
+		return existing, nil, nil
+	}
+
+	if err = uc.repo.PatchBar(ctx, patched, changed); err != nil {
+		return nil, nil, fmt.Errorf("failed to patch bar: %w", err)
+	}
+
+	return patched, changed, nil
+}
+
+// Delete soft deletes a bar, a non-zero entity.Version must match the stored version
 func (uc *usecase) Delete(ctx context.Context, entity *Bar) error {
-	existing, err := uc.repo.GetBarByID(ctx, entity.ID)
+	existing, err := uc.repo.GetCurrentBar(ctx, entity.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get bar: %w", err)
 	}
 
+	if entity.Version > 0 && entity.Version != existing.Version {
+		return ErrVersionMismatch
+	}
+
 	if err = uc.repo.DeleteBar(ctx, existing); err != nil {
 		return fmt.Errorf("failed to delete bar: %w", err)
 	}
@@ -116,6 +147,25 @@ func (uc *usecase) GetByID(ctx context.Context, id string) (*Bar, error) {
 	return bar, nil
 }
 
+func (uc *usecase) GetForUpdate(ctx context.Context, id string) (*Bar, error) {
+	bar, err := uc.repo.GetCurrentBar(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bar: %w", err)
+	}
+
+	return bar, nil
+}
+
+// GetView returns a bar narrowed to the selected fields, with the requested expansions
+func (uc *usecase) GetView(ctx context.Context, id string, selection *fieldset.Selection) (*Bar, error) {
+	bar, err := uc.repo.GetBarView(ctx, id, selection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bar: %w", err)
+	}
+
+	return bar, nil
+}
+
 func (uc *usecase) GetList(ctx context.Context, filter *Filter) ([]*Bar, int64, error) {
 	if filter == nil {
 		filter = &Filter{}
@@ -138,6 +188,24 @@ func (uc *usecase) GetList(ctx context.Context, filter *Filter) ([]*Bar, int64,
 	return bars, total, nil
 }
 
+// GetListByCursor returns one keyset page, it never counts the matching rows
+func (uc *usecase) GetListByCursor(ctx context.Context, filter *Filter) ([]*Bar, *pagination.CursorPage, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	if filter.Keyword != "" {
+		filter.Keyword = strings.TrimSpace(filter.Keyword)
+	}
+
+	bars, page, err := uc.repo.GetBarListByCursor(ctx, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bars: %w", err)
+	}
+
+	return bars, page, nil
+}
+
 func (uc *usecase) Count(ctx context.Context, filter *Filter) (int64, error) {
 	if filter == nil {
 		filter = &Filter{}
@@ -151,37 +219,332 @@ func (uc *usecase) Count(ctx context.Context, filter *Filter) (int64, error) {
 	return count, nil
 }
 
-func (uc *usecase) BulkCreate(ctx context.Context, entities []*Bar) error {
+// BulkCreate validates every entity and creates the valid ones. In atomic mode a single
+// failure rejects the batch with ErrBulkRejected, the result still carries every item.
+func (uc *usecase) BulkCreate(ctx context.Context, entities []*Bar, mode BulkMode) (*BulkResult, error) {
+	result, err := uc.prepareCreate(ctx, entities, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.rejectAtomic() {
+		return result, ErrBulkRejected
+	}
+
+	if pending := result.pending(); len(pending) > 0 {
+		if err := uc.repo.BulkCreate(ctx, entitiesOf(pending)); err != nil {
+			return nil, fmt.Errorf("failed to bulk create bars: %w", err)
+		}
+	}
+	result.complete(BulkStatusCreated)
+
+	return result, nil
+}
+
+// Import validates every row and, unless dryRun, creates all of them in chunks. Any failed
+// row rejects the import with ErrImportRejected. Run it inside transaction.Transaction,
+// the writes use the transaction from ctx.
+func (uc *usecase) Import(ctx context.Context, entities []*Bar, dryRun bool) (*BulkResult, error) {
+	result, err := uc.prepareCreate(ctx, entities, BulkModeAtomic)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.rejectAtomic() {
+		return result, ErrImportRejected
+	}
+
+	if dryRun {
+		result.complete(BulkStatusValid)
+		return result, nil
+	}
+
+	repo := uc.repo.WithTx(ctx)
+	pending := entitiesOf(result.pending())
+	for start := 0; start < len(pending); start += importChunkSize {
+		end := min(start+importChunkSize, len(pending))
+		if err := repo.BulkCreate(ctx, pending[start:end]); err != nil {
+			return nil, fmt.Errorf("failed to import bars: %w", err)
+		}
+	}
+	result.complete(BulkStatusCreated)
+
+	return result, nil
+}
+
+// Export calls fn for every bar matching the filter in sort order, without loading them all
+func (uc *usecase) Export(ctx context.Context, filter *Filter, fn func(*Bar) error) error {
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	if filter.Keyword != "" {
+		filter.Keyword = strings.TrimSpace(filter.Keyword)
+	}
+
+	if err := uc.repo.StreamBars(ctx, filter, fn); err != nil {
+		return fmt.Errorf("failed to export bars: %w", err)
+	}
+
+	return nil
+}
+
+// ListTrash returns the soft deleted bars, most recently deleted first
+func (uc *usecase) ListTrash(ctx context.Context, filter *Filter) ([]*Bar, int64, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	if filter.Keyword != "" {
+		filter.Keyword = strings.TrimSpace(filter.Keyword)
+	}
+
+	bars, err := uc.repo.GetTrashList(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get deleted bars: %w", err)
+	}
+
+	total, err := uc.repo.CountTrash(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted bars: %w", err)
+	}
+
+	return bars, total, nil
+}
+
+// Restore moves a bar out of the trash. It fails with ErrCodeAlreadyExists when
+// another bar took its code in the meantime.
+func (uc *usecase) Restore(ctx context.Context, id string) (*Bar, error) {
+	deleted, err := uc.repo.GetDeletedBarByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted bar: %w", err)
+	}
+
+	if err = uc.repo.RestoreBar(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to restore bar: %w", err)
+	}
+
+	deleted.DeletedAt = nil
+	deleted.DeletedBy = ""
+
+	return deleted, nil
+}
+
+// HardDelete permanently removes a bar from the trash, active bars must be deleted first
+func (uc *usecase) HardDelete(ctx context.Context, id string) error {
+	if _, err := uc.repo.GetDeletedBarByID(ctx, id); err != nil {
+		return fmt.Errorf("failed to get deleted bar: %w", err)
+	}
+
+	if err := uc.repo.HardDeleteBar(ctx, id); err != nil {
+		return fmt.Errorf("failed to permanently delete bar: %w", err)
+	}
+
+	return nil
+}
+
+// Purge permanently removes the bars soft deleted before the given time and returns their number
+func (uc *usecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := uc.repo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted bars: %w", err)
+	}
+
+	return purged, nil
+}
+
+// prepareCreate validates and normalizes entities for creation and checks their codes
+func (uc *usecase) prepareCreate(ctx context.Context, entities []*Bar, mode BulkMode) (*BulkResult, error) {
+	result := newBulkResult(mode, len(entities))
+
 	codes := make(map[string]bool)
 	for i, entity := range entities {
+		item := &BulkItemResult{Index: i, Bar: entity}
+		result.Items = append(result.Items, item)
+
 		if err := entity.validate(); err != nil {
-			return fmt.Errorf("validation failed for entity %d: %w", i, err)
+			result.fail(item, err)
+			continue
 		}
 
-		code := strings.ToUpper(strings.TrimSpace(entity.Code))
-		if codes[code] {
-			return fmt.Errorf("duplicate code '%s' in batch", code)
+		entity.Code = strings.ToUpper(strings.TrimSpace(entity.Code))
+		entity.Bar = strings.TrimSpace(entity.Bar)
+
+		if codes[entity.Code] {
+			result.fail(item, ErrDuplicateCode)
+			continue
+		}
+		codes[entity.Code] = true
+	}
+
+	if err := uc.failTakenCodes(ctx, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BulkUpdate validates every entity and updates the valid ones, see BulkCreate for the modes
+func (uc *usecase) BulkUpdate(ctx context.Context, entities []*Bar, mode BulkMode) (*BulkResult, error) {
+	result := newBulkResult(mode, len(entities))
+
+	ids := make(map[string]bool)
+	codes := make(map[string]bool)
+	for i, entity := range entities {
+		item := &BulkItemResult{Index: i, Bar: entity}
+		result.Items = append(result.Items, item)
+
+		if strings.TrimSpace(entity.ID) == "" {
+			result.fail(item, ErrIDRequired)
+			continue
+		}
+		if err := entity.validate(); err != nil {
+			result.fail(item, err)
+			continue
 		}
-		codes[code] = true
 
-		entity.Code = code
+		entity.Code = strings.ToUpper(strings.TrimSpace(entity.Code))
 		entity.Bar = strings.TrimSpace(entity.Bar)
+
+		if ids[entity.ID] {
+			result.fail(item, ErrDuplicateID)
+			continue
+		}
+		ids[entity.ID] = true
+
+		if codes[entity.Code] {
+			result.fail(item, ErrDuplicateCode)
+			continue
+		}
+		codes[entity.Code] = true
 	}
 
-	// Check existing codes in database
-	for code := range codes {
-		exists, err := uc.ExistsByCode(ctx, code)
-		if err != nil {
-			return fmt.Errorf("failed to check code existence for '%s': %w", code, err)
+	if err := uc.failMissingIDs(ctx, result); err != nil {
+		return nil, err
+	}
+	if err := uc.failTakenCodes(ctx, result); err != nil {
+		return nil, err
+	}
+
+	if result.rejectAtomic() {
+		return result, ErrBulkRejected
+	}
+
+	if pending := result.pending(); len(pending) > 0 {
+		if err := uc.repo.BulkUpdate(ctx, entitiesOf(pending)); err != nil {
+			return nil, fmt.Errorf("failed to bulk update bars: %w", err)
+		}
+	}
+	result.complete(BulkStatusUpdated)
+
+	return result, nil
+}
+
+// BulkDelete deletes the bars with the given ids, see BulkCreate for the modes
+func (uc *usecase) BulkDelete(ctx context.Context, ids []string, mode BulkMode) (*BulkResult, error) {
+	result := newBulkResult(mode, len(ids))
+
+	seen := make(map[string]bool)
+	for i, id := range ids {
+		item := &BulkItemResult{Index: i, Bar: &Bar{ID: strings.TrimSpace(id)}}
+		result.Items = append(result.Items, item)
+
+		if item.Bar.ID == "" {
+			result.fail(item, ErrIDRequired)
+			continue
 		}
-		if exists {
-			return fmt.Errorf("code '%s' already exists", code)
+		if seen[item.Bar.ID] {
+			result.fail(item, ErrDuplicateID)
+			continue
 		}
+		seen[item.Bar.ID] = true
+	}
+
+	if err := uc.failMissingIDs(ctx, result); err != nil {
+		return nil, err
 	}
 
-	// Bulk create
-	if err := uc.repo.BulkCreate(ctx, entities); err != nil {
-		return fmt.Errorf("failed to bulk create bars: %w", err)
+	if result.rejectAtomic() {
+		return result, ErrBulkRejected
+	}
+
+	if pending := result.pending(); len(pending) > 0 {
+		deleteIDs := make([]string, len(pending))
+		for i, item := range pending {
+			deleteIDs[i] = item.Bar.ID
+		}
+		if err := uc.repo.BulkDelete(ctx, deleteIDs); err != nil {
+			return nil, fmt.Errorf("failed to bulk delete bars: %w", err)
+		}
+	}
+	result.complete(BulkStatusDeleted)
+
+	return result, nil
+}
+
+// failMissingIDs fails the pending items whose bar does not exist, using a single query.
+// Items of bulk deletes receive the stored bar so the response can echo it.
+func (uc *usecase) failMissingIDs(ctx context.Context, result *BulkResult) error {
+	pending := result.pending()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(pending))
+	for i, item := range pending {
+		ids[i] = item.Bar.ID
+	}
+
+	existing, err := uc.repo.GetBarsByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get bars: %w", err)
+	}
+
+	byID := make(map[string]*Bar, len(existing))
+	for _, bar := range existing {
+		byID[bar.ID] = bar
+	}
+
+	for _, item := range pending {
+		stored, ok := byID[item.Bar.ID]
+		if !ok {
+			result.fail(item, ErrNotFound)
+			continue
+		}
+		if item.Bar.Code == "" {
+			item.Bar = stored
+		}
+	}
+
+	return nil
+}
+
+// failTakenCodes fails the pending items whose code belongs to another bar, using a single query
+func (uc *usecase) failTakenCodes(ctx context.Context, result *BulkResult) error {
+	pending := result.pending()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	codes := make([]string, len(pending))
+	for i, item := range pending {
+		codes[i] = item.Bar.Code
+	}
+
+	existing, err := uc.repo.GetBarsByCodes(ctx, codes)
+	if err != nil {
+		return fmt.Errorf("failed to check code existence: %w", err)
+	}
+
+	owners := make(map[string]string, len(existing))
+	for _, bar := range existing {
+		owners[bar.Code] = bar.ID
+	}
+
+	for _, item := range pending {
+		if owner, ok := owners[item.Bar.Code]; ok && owner != item.Bar.ID {
+			result.fail(item, ErrCodeAlreadyExists)
+		}
 	}
 
 	return nil
@@ -0,0 +1,579 @@
+package bar
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/pagination"
+	"goilerplate/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository is an in-memory Repository used to exercise the usecase
+type fakeRepository struct {
+	items   map[string]*Bar
+	trash   map[string]*Bar
+	seq     int
+	queries int      // lookups by ids or codes
+	patched []string // fields written by the last PatchBar
+}
+
+func newFakeRepository(codes ...string) *fakeRepository {
+	r := &fakeRepository{items: make(map[string]*Bar), trash: make(map[string]*Bar)}
+	for _, code := range codes {
+		_, _ = r.CreateBar(context.Background(), &Bar{Code: code, Bar: "seed"})
+	}
+	return r
+}
+
+func (r *fakeRepository) WithTx(ctx context.Context) Repository { return r }
+
+// codeTaken emulates the unique index on the codes of live bars, which the real
+// repository translates into ErrCodeAlreadyExists
+func (r *fakeRepository) codeTaken(code, id string) bool {
+	for _, item := range r.items {
+		if item.Code == code && item.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRepository) CreateBar(ctx context.Context, entity *Bar) (*Bar, error) {
+	if r.codeTaken(entity.Code, "") {
+		return nil, ErrCodeAlreadyExists
+	}
+	r.seq++
+	created := entity.Clone()
+	created.ID = fmt.Sprintf("id-%d", r.seq)
+	created.Version = 1
+	r.items[created.ID] = created
+	return created.Clone(), nil
+}
+
+func (r *fakeRepository) UpdateBar(ctx context.Context, entity *Bar) error {
+	item, ok := r.items[entity.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if entity.Version > 0 && entity.Version != item.Version {
+		return ErrVersionMismatch
+	}
+	if r.codeTaken(entity.Code, entity.ID) {
+		return ErrCodeAlreadyExists
+	}
+	entity.Version = item.Version + 1
+	r.items[entity.ID] = entity.Clone()
+	return nil
+}
+
+func (r *fakeRepository) PatchBar(ctx context.Context, entity *Bar, fields []string) error {
+	item, ok := r.items[entity.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if entity.Version != item.Version {
+		return ErrVersionMismatch
+	}
+	if r.codeTaken(entity.Code, entity.ID) {
+		return ErrCodeAlreadyExists
+	}
+	r.patched = fields
+	entity.Version++
+	r.items[entity.ID] = entity.Clone()
+	return nil
+}
+
+func (r *fakeRepository) DeleteBar(ctx context.Context, entity *Bar) error {
+	if item, ok := r.items[entity.ID]; ok && entity.Version > 0 && entity.Version != item.Version {
+		return ErrVersionMismatch
+	}
+	return r.BulkDelete(ctx, []string{entity.ID})
+}
+
+func (r *fakeRepository) BulkCreate(ctx context.Context, entities []*Bar) error {
+	for _, entity := range entities {
+		created, err := r.CreateBar(ctx, entity)
+		if err != nil {
+			return err
+		}
+		entity.ID = created.ID
+	}
+	return nil
+}
+
+func (r *fakeRepository) BulkUpdate(ctx context.Context, entities []*Bar) error {
+	for _, entity := range entities {
+		if err := r.UpdateBar(ctx, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) BulkDelete(ctx context.Context, ids []string) error {
+	now := time.Now()
+	for _, id := range ids {
+		if item, ok := r.items[id]; ok {
+			item.DeletedAt = &now
+			r.trash[id] = item
+			delete(r.items, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) CountBar(ctx context.Context, filter *Filter) (int64, error) {
+	list, err := r.GetBarList(ctx, filter)
+	return int64(len(list)), err
+}
+
+func (r *fakeRepository) GetBarList(ctx context.Context, filter *Filter) ([]*Bar, error) {
+	var out []*Bar
+	for _, item := range r.items {
+		if filter != nil && filter.Code != "" && item.Code != filter.Code {
+			continue
+		}
+		out = append(out, item.Clone())
+	}
+	return out, nil
+}
+
+func (r *fakeRepository) GetBarListByCursor(ctx context.Context, filter *Filter) ([]*Bar, *pagination.CursorPage, error) {
+	list, err := r.GetBarList(ctx, filter)
+	return list, &pagination.CursorPage{}, err
+}
+
+func (r *fakeRepository) GetBarByID(ctx context.Context, id string) (*Bar, error) {
+	item, ok := r.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return item.Clone(), nil
+}
+
+func (r *fakeRepository) GetCurrentBar(ctx context.Context, id string) (*Bar, error) {
+	return r.GetBarByID(ctx, id)
+}
+
+func (r *fakeRepository) GetBarView(ctx context.Context, id string, selection *fieldset.Selection) (*Bar, error) {
+	return r.GetBarByID(ctx, id)
+}
+
+func (r *fakeRepository) GetBarsByIDs(ctx context.Context, ids []string) ([]*Bar, error) {
+	r.queries++
+	var out []*Bar
+	for _, id := range ids {
+		if item, ok := r.items[id]; ok {
+			out = append(out, item.Clone())
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepository) GetBarsByCodes(ctx context.Context, codes []string) ([]*Bar, error) {
+	r.queries++
+	var out []*Bar
+	for _, code := range codes {
+		for _, item := range r.items {
+			if item.Code == code {
+				out = append(out, item.Clone())
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepository) StreamBars(ctx context.Context, filter *Filter, fn func(*Bar) error) error {
+	list, err := r.GetBarList(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, item := range list {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) CountTrash(ctx context.Context, filter *Filter) (int64, error) {
+	return int64(len(r.trash)), nil
+}
+
+func (r *fakeRepository) GetTrashList(ctx context.Context, filter *Filter) ([]*Bar, error) {
+	var out []*Bar
+	for _, item := range r.trash {
+		out = append(out, item.Clone())
+	}
+	return out, nil
+}
+
+func (r *fakeRepository) GetDeletedBarByID(ctx context.Context, id string) (*Bar, error) {
+	item, ok := r.trash[id]
+	if !ok {
+		return nil, ErrNotInTrash
+	}
+	return item.Clone(), nil
+}
+
+func (r *fakeRepository) RestoreBar(ctx context.Context, id string) error {
+	item, ok := r.trash[id]
+	if !ok {
+		return ErrNotInTrash
+	}
+	if r.codeTaken(item.Code, id) {
+		return ErrCodeAlreadyExists
+	}
+	item.DeletedAt = nil
+	r.items[id] = item
+	delete(r.trash, id)
+	return nil
+}
+
+func (r *fakeRepository) HardDeleteBar(ctx context.Context, id string) error {
+	if _, ok := r.trash[id]; !ok {
+		return ErrNotInTrash
+	}
+	delete(r.trash, id)
+	return nil
+}
+
+func (r *fakeRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for id, item := range r.trash {
+		if item.DeletedAt.Before(before) {
+			delete(r.trash, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func statuses(result *BulkResult) []BulkStatus {
+	out := make([]BulkStatus, len(result.Items))
+	for i, item := range result.Items {
+		out[i] = item.Status
+	}
+	return out
+}
+
+func TestUsecase_Update(t *testing.T) {
+	t.Run("should bump the version", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+
+		updated, err := uc.Update(context.Background(), &Bar{ID: "id-1", Code: "EXP-A", Bar: "renamed", Version: 1})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), updated.Version)
+		assert.Equal(t, "renamed", repo.items["id-1"].Bar)
+	})
+
+	t.Run("should reject a stale version", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+		_, err := uc.Update(context.Background(), &Bar{ID: "id-1", Code: "EXP-A", Bar: "first", Version: 1})
+		require.NoError(t, err)
+
+		_, err = uc.Update(context.Background(), &Bar{ID: "id-1", Code: "EXP-A", Bar: "second", Version: 1})
+
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.Equal(t, "first", repo.items["id-1"].Bar)
+	})
+}
+
+func TestUsecase_Patch(t *testing.T) {
+	t.Run("should only write the changed fields", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+
+		patched, changed, err := uc.Patch(context.Background(), "id-1", 0, &Patch{Code: utils.Pointer(" exp-a "), Bar: utils.Pointer("renamed")})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{FieldBar}, changed)
+		assert.Equal(t, []string{FieldBar}, repo.patched)
+		assert.Equal(t, "EXP-A", patched.Code)
+		assert.Equal(t, int64(2), patched.Version)
+	})
+
+	t.Run("should not write a patch without changes", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+
+		patched, changed, err := uc.Patch(context.Background(), "id-1", 1, &Patch{Bar: utils.Pointer("seed")})
+
+		require.NoError(t, err)
+		assert.Empty(t, changed)
+		assert.Nil(t, repo.patched)
+		assert.Equal(t, int64(1), patched.Version)
+	})
+
+	t.Run("should validate the patched bar", func(t *testing.T) {
+		uc := NewUseCase(newFakeRepository("EXP-A"))
+
+		_, _, err := uc.Patch(context.Background(), "id-1", 0, &Patch{Bar: utils.Pointer(" ")})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject a taken code and a stale version", func(t *testing.T) {
+		uc := NewUseCase(newFakeRepository("EXP-A", "EXP-B"))
+
+		_, _, err := uc.Patch(context.Background(), "id-1", 0, &Patch{Code: utils.Pointer("EXP-B")})
+		assert.ErrorIs(t, err, ErrCodeAlreadyExists)
+
+		_, _, err = uc.Patch(context.Background(), "id-1", 3, &Patch{Bar: utils.Pointer("x")})
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+}
+
+func TestUsecase_Delete(t *testing.T) {
+	t.Run("should reject a stale version", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+
+		err := uc.Delete(context.Background(), &Bar{ID: "id-1", Version: 2})
+
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.Contains(t, repo.items, "id-1")
+	})
+}
+
+func TestUsecase_BulkCreate(t *testing.T) {
+	newBatch := func() []*Bar {
+		return []*Bar{
+			{Code: "exp-1", Bar: "one"},
+			{Code: "EXP-TAKEN", Bar: "taken"},
+			{Code: "nope", Bar: "invalid"},
+			{Code: "EXP-1", Bar: "duplicate"},
+		}
+	}
+
+	t.Run("should reject the whole batch in atomic mode", func(t *testing.T) {
+		repo := newFakeRepository("EXP-TAKEN")
+		uc := NewUseCase(repo)
+
+		result, err := uc.BulkCreate(context.Background(), newBatch(), BulkModeAtomic)
+
+		assert.ErrorIs(t, err, ErrBulkRejected)
+		assert.Equal(t, []BulkStatus{BulkStatusSkipped, BulkStatusFailed, BulkStatusFailed, BulkStatusFailed}, statuses(result))
+		assert.ErrorIs(t, result.Items[1].Err, ErrCodeAlreadyExists)
+		assert.ErrorIs(t, result.Items[3].Err, ErrDuplicateCode)
+		assert.Len(t, repo.items, 1)
+	})
+
+	t.Run("should write the valid items in partial mode", func(t *testing.T) {
+		repo := newFakeRepository("EXP-TAKEN")
+		uc := NewUseCase(repo)
+
+		result, err := uc.BulkCreate(context.Background(), newBatch(), BulkModePartial)
+
+		require.NoError(t, err)
+		assert.Equal(t, []BulkStatus{BulkStatusCreated, BulkStatusFailed, BulkStatusFailed, BulkStatusFailed}, statuses(result))
+		assert.Equal(t, 1, result.Succeeded())
+		assert.Equal(t, 3, result.Failed())
+		assert.NotEmpty(t, result.Items[0].Bar.ID)
+		assert.Equal(t, "EXP-1", result.Items[0].Bar.Code)
+		assert.Len(t, repo.items, 2)
+	})
+
+	t.Run("should check codes with a single query", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc := NewUseCase(repo)
+
+		_, err := uc.BulkCreate(context.Background(), []*Bar{
+			{Code: "EXP-A", Bar: "a"}, {Code: "EXP-B", Bar: "b"}, {Code: "EXP-C", Bar: "c"},
+		}, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.queries)
+	})
+
+	t.Run("should reuse the code of a trashed bar", func(t *testing.T) {
+		repo := newFakeRepository("EXP-TRASHED")
+		require.NoError(t, repo.BulkDelete(context.Background(), []string{"id-1"}))
+		uc := NewUseCase(repo)
+
+		result, err := uc.BulkCreate(context.Background(), []*Bar{
+			{Code: "EXP-A", Bar: "a"}, {Code: "EXP-TRASHED", Bar: "again"},
+		}, BulkModePartial)
+
+		require.NoError(t, err)
+		assert.Equal(t, []BulkStatus{BulkStatusCreated, BulkStatusCreated}, statuses(result))
+		assert.Contains(t, repo.trash, "id-1")
+	})
+}
+
+func TestUsecase_BulkUpdate(t *testing.T) {
+	t.Run("should allow keeping the own code and reject taken codes", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A", "EXP-B")
+		uc := NewUseCase(repo)
+
+		result, err := uc.BulkUpdate(context.Background(), []*Bar{
+			{ID: "id-1", Code: "EXP-A", Bar: "renamed"},
+			{ID: "id-2", Code: "EXP-A", Bar: "conflict"},
+			{ID: "id-9", Code: "EXP-Z", Bar: "missing"},
+			{Code: "EXP-Y", Bar: "no id"},
+		}, BulkModePartial)
+
+		require.NoError(t, err)
+		assert.Equal(t, []BulkStatus{BulkStatusUpdated, BulkStatusFailed, BulkStatusFailed, BulkStatusFailed}, statuses(result))
+		assert.ErrorIs(t, result.Items[1].Err, ErrDuplicateCode)
+		assert.ErrorIs(t, result.Items[2].Err, ErrNotFound)
+		assert.ErrorIs(t, result.Items[3].Err, ErrIDRequired)
+		assert.Equal(t, "renamed", repo.items["id-1"].Bar)
+	})
+}
+
+func TestUsecase_BulkDelete(t *testing.T) {
+	t.Run("should keep every bar when an atomic batch fails", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A", "EXP-B")
+		uc := NewUseCase(repo)
+
+		result, err := uc.BulkDelete(context.Background(), []string{"id-1", "id-9"}, BulkModeAtomic)
+
+		assert.ErrorIs(t, err, ErrBulkRejected)
+		assert.Equal(t, []BulkStatus{BulkStatusSkipped, BulkStatusFailed}, statuses(result))
+		assert.Len(t, repo.items, 2)
+	})
+
+	t.Run("should delete the existing bars in partial mode", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A", "EXP-B")
+		uc := NewUseCase(repo)
+
+		result, err := uc.BulkDelete(context.Background(), []string{"id-1", "id-1", "id-9"}, BulkModePartial)
+
+		require.NoError(t, err)
+		assert.Equal(t, []BulkStatus{BulkStatusDeleted, BulkStatusFailed, BulkStatusFailed}, statuses(result))
+		assert.Equal(t, "EXP-A", result.Items[0].Bar.Code)
+		assert.Len(t, repo.items, 1)
+	})
+}
+
+func TestUsecase_Import(t *testing.T) {
+	t.Run("should only validate on a dry run", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc := NewUseCase(repo)
+
+		result, err := uc.Import(context.Background(), []*Bar{{Code: "EXP-A", Bar: "a"}}, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, []BulkStatus{BulkStatusValid}, statuses(result))
+		assert.Equal(t, 0, result.Succeeded())
+		assert.Empty(t, repo.items)
+	})
+
+	t.Run("should reject the file when a row fails", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc := NewUseCase(repo)
+
+		result, err := uc.Import(context.Background(), []*Bar{{Code: "EXP-A", Bar: "a"}, {Code: "EXP-B"}}, false)
+
+		assert.ErrorIs(t, err, ErrImportRejected)
+		assert.Equal(t, []BulkStatus{BulkStatusSkipped, BulkStatusFailed}, statuses(result))
+		assert.Empty(t, repo.items)
+	})
+
+	t.Run("should import the code of a trashed bar", func(t *testing.T) {
+		repo := newFakeRepository("EXP-TRASHED")
+		require.NoError(t, repo.BulkDelete(context.Background(), []string{"id-1"}))
+		uc := NewUseCase(repo)
+
+		result, err := uc.Import(context.Background(), []*Bar{{Code: "EXP-TRASHED", Bar: "again"}}, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, []BulkStatus{BulkStatusCreated}, statuses(result))
+		assert.Len(t, repo.items, 1)
+	})
+
+	t.Run("should create every row", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc := NewUseCase(repo)
+
+		entities := make([]*Bar, importChunkSize+1)
+		for i := range entities {
+			entities[i] = &Bar{Code: fmt.Sprintf("EXP-%d", i), Bar: "bar"}
+		}
+
+		result, err := uc.Import(context.Background(), entities, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, importChunkSize+1, result.Succeeded())
+		assert.Len(t, repo.items, importChunkSize+1)
+	})
+}
+
+func TestUsecase_Restore(t *testing.T) {
+	t.Run("should move the bar out of the trash", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+		require.NoError(t, uc.Delete(context.Background(), &Bar{ID: "id-1"}))
+
+		restored, err := uc.Restore(context.Background(), "id-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "EXP-A", restored.Code)
+		assert.Nil(t, restored.DeletedAt)
+		assert.Contains(t, repo.items, "id-1")
+		assert.Empty(t, repo.trash)
+	})
+
+	t.Run("should reject a code taken in the meantime", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+		require.NoError(t, uc.Delete(context.Background(), &Bar{ID: "id-1"}))
+		_, err := uc.Create(context.Background(), &Bar{Code: "EXP-A", Bar: "again"})
+		require.NoError(t, err)
+
+		_, err = uc.Restore(context.Background(), "id-1")
+
+		assert.ErrorIs(t, err, ErrCodeAlreadyExists)
+		assert.Contains(t, repo.trash, "id-1")
+	})
+
+	t.Run("should not restore an active bar", func(t *testing.T) {
+		uc := NewUseCase(newFakeRepository("EXP-A"))
+
+		_, err := uc.Restore(context.Background(), "id-1")
+
+		assert.ErrorIs(t, err, ErrNotInTrash)
+	})
+}
+
+func TestUsecase_HardDelete(t *testing.T) {
+	t.Run("should only delete bars in the trash", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A", "EXP-B")
+		uc := NewUseCase(repo)
+		require.NoError(t, uc.Delete(context.Background(), &Bar{ID: "id-1"}))
+
+		require.NoError(t, uc.HardDelete(context.Background(), "id-1"))
+		assert.ErrorIs(t, uc.HardDelete(context.Background(), "id-2"), ErrNotInTrash)
+
+		assert.Empty(t, repo.trash)
+		assert.Contains(t, repo.items, "id-2")
+	})
+}
+
+func TestUsecase_Purge(t *testing.T) {
+	t.Run("should purge bars deleted before the cutoff", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A", "EXP-B")
+		uc := NewUseCase(repo)
+		require.NoError(t, uc.Delete(context.Background(), &Bar{ID: "id-1"}))
+		require.NoError(t, uc.Delete(context.Background(), &Bar{ID: "id-2"}))
+		old := time.Now().Add(-48 * time.Hour)
+		repo.trash["id-1"].DeletedAt = &old
+
+		purged, err := uc.Purge(context.Background(), time.Now().Add(-24*time.Hour))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.NotContains(t, repo.trash, "id-1")
+		assert.Contains(t, repo.trash, "id-2")
+	})
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"slices"
+	"time"
+
+	"goilerplate/pkg/filesystem"
+)
+
+// Statuses of a file
+const (
+	StatusPending = "pending" // presigned upload not completed yet
+	StatusReady   = "ready"
+)
+
+// File is the metadata of an uploaded file, the content lives on the filesystem driver
+type File struct {
+	ID           string
+	OwnerID      string
+	TenantID     string // tenant of the owner at upload time, empty without one
+	Disk         string // name of the disk holding the file, see filesystem.Manager.Disk
+	Driver       string
+	Path         string
+	OriginalName string
+	Size         int64
+	MimeType     string
+	Checksum     string // hex encoded SHA-256 of the content
+	Status       string
+	Variants     []Variant // image variants stored next to the file
+	StoredSize   int64     // bytes stored for the file and its variants, counted against quotas
+
+	// URL is resolved by the filesystem driver on reads, it is not stored
+	URL string
+
+	CreatedAt time.Time
+}
+
+// Variant is a resized copy of an image file
+type Variant struct {
+	Name     string
+	Path     string
+	MimeType string
+	Width    int
+	Height   int
+	Size     int64
+
+	// URL is resolved by the filesystem driver on reads, it is not stored
+	URL string
+}
+
+func (e *File) Clone() *File {
+	clone := *e
+	clone.Variants = slices.Clone(e.Variants)
+	return &clone
+}
+
+// paths returns the stored paths of the file and its variants
+func (e *File) paths() []string {
+	paths := []string{e.Path}
+	for _, variant := range e.Variants {
+		paths = append(paths, variant.Path)
+	}
+	return paths
+}
+
+// storedSize returns the bytes stored for the file and its variants
+func (e *File) storedSize() int64 {
+	size := e.Size
+	for _, variant := range e.Variants {
+		size += variant.Size
+	}
+	return size
+}
+
+// ResumableUpload is a resumable upload in progress, finalized into a File once every byte arrived
+type ResumableUpload struct {
+	ID        string
+	OwnerID   string
+	TenantID  string
+	Filename  string
+	MimeType  string
+	Staged    filesystem.StagedUpload // size, offset and staging state of the driver
+	HashState []byte                  // serialized SHA-256 of the received bytes
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (e *ResumableUpload) Clone() *ResumableUpload {
+	clone := *e
+	clone.Staged.Parts = slices.Clone(e.Staged.Parts)
+	clone.HashState = slices.Clone(e.HashState)
+	return &clone
+}
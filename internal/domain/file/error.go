@@ -0,0 +1,25 @@
+package file
+
+import "goilerplate/pkg/utils"
+
+var (
+	// Upload errors
+	ErrFileRequired = utils.ClientErr(400, "File is required")
+
+	// Direct upload errors
+	ErrDirectUploadNotSupported = utils.ClientErr(501, "Direct uploads are not supported by the storage driver")
+	ErrUploadIncomplete         = utils.ClientErr(409, "File content has not been uploaded yet")
+
+	// Resumable upload errors
+	ErrResumableNotFound       = utils.ClientErr(404, "Upload not found")
+	ErrResumableOffsetMismatch = utils.ClientErr(409, "Upload offset does not match")
+	ErrResumableTooLarge       = utils.ClientErr(413, "Chunk exceeds the upload length")
+	ErrResumableLocked         = utils.ClientErr(423, "Upload is receiving another chunk")
+
+	// Quota errors
+	ErrQuotaExceeded       = utils.ClientErr(413, "Storage quota exceeded")
+	ErrTenantQuotaExceeded = utils.ClientErr(413, "Storage quota of the tenant exceeded")
+
+	// Operation errors
+	ErrNotFound = utils.ClientErr(404, "File not found")
+)
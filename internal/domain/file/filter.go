@@ -0,0 +1,25 @@
+package file
+
+import (
+	"goilerplate/pkg/listquery"
+	"goilerplate/pkg/pagination"
+)
+
+type Filter struct {
+	OwnerID string // set by the usecase caller, users only see their own files
+	Keyword string
+
+	Query      *listquery.Query
+	Pagination *pagination.PaginationRequest
+}
+
+// ListAllowlist lists the fields clients may sort and filter files by
+var ListAllowlist = listquery.Allowlist{
+	Fields: map[string]listquery.Field{
+		"original_name": {Column: "original_name", Ops: listquery.StringOps, Sortable: true},
+		"mime_type":     {Column: "mime_type", Ops: listquery.StringOps, Sortable: true},
+		"size":          {Column: "size", Type: listquery.TypeInt, Ops: listquery.NumberOps, Sortable: true},
+		"created_at":    {Column: "created_at", Type: listquery.TypeTime, Ops: listquery.TimeOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
@@ -0,0 +1,12 @@
+package file
+
+// Success Messages
+const (
+	MsgFileUploadedSuccessfully  = "File uploaded successfully"
+	MsgFilesUploadedSuccessfully = "Files uploaded successfully"
+	MsgFileUploadPresigned       = "Upload presigned successfully"
+	MsgFileDeletedSuccessfully   = "File deleted successfully"
+	MsgFileFetchedSuccessfully   = "File fetched successfully"
+	MsgFileListFetchSuccessfully = "Files fetched successfully"
+	MsgStorageUsageFetched       = "Storage usage fetched successfully"
+)
@@ -0,0 +1,39 @@
+package file
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	WithTx(ctx context.Context) Repository
+
+	CreateFile(ctx context.Context, entity *File) (*File, error)
+	MarkFileReady(ctx context.Context, id string) error
+	DeleteFile(ctx context.Context, entity *File) error
+
+	CountFile(ctx context.Context, filter *Filter) (int64, error)
+	GetFileList(ctx context.Context, filter *Filter) ([]*File, error)
+	GetFileByID(ctx context.Context, ownerID, id string) (*File, error)
+	GetPendingFile(ctx context.Context, ownerID, id string) (*File, error)
+
+	// GetOwnerUsage sums the files of the owner and the sizes reserved by their unfinished uploads
+	GetOwnerUsage(ctx context.Context, ownerID string) (*Usage, error)
+	// GetTenantUsage sums the files of the tenant and the sizes reserved by its unfinished uploads
+	GetTenantUsage(ctx context.Context, tenantID string) (*Usage, error)
+	// GetStoredFiles returns up to limit files of disk, pending ones included, ordered by ID after afterID
+	GetStoredFiles(ctx context.Context, disk, afterID string, limit int) ([]*File, error)
+	UpdateStoredSize(ctx context.Context, id string, size int64) error
+
+	CreateResumableUpload(ctx context.Context, entity *ResumableUpload) (*ResumableUpload, error)
+	// AdvanceResumableUpload saves the state of entity when its stored offset is still from
+	AdvanceResumableUpload(ctx context.Context, entity *ResumableUpload, from int64) error
+	// ClaimResumableUpload reserves the upload at offset for one request until until,
+	// ErrResumableLocked when another request holds it or the offset moved
+	ClaimResumableUpload(ctx context.Context, id string, offset int64, until time.Time) error
+	ReleaseResumableUpload(ctx context.Context, id string) error
+	DeleteResumableUpload(ctx context.Context, id string) error
+	GetResumableUpload(ctx context.Context, ownerID, id string) (*ResumableUpload, error)
+	// GetExpiredResumableUploads returns up to limit expired uploads no request is writing to
+	GetExpiredResumableUploads(ctx context.Context, limit int) ([]*ResumableUpload, error)
+}
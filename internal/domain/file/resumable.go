@@ -0,0 +1,37 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"fmt"
+	"hash"
+)
+
+// ResumableRequest describes a file uploaded in chunks
+type ResumableRequest struct {
+	Filename string
+	MimeType string // detected from the filename when empty
+	Size     int64
+}
+
+// restoreHash resumes the SHA-256 of the bytes received before
+func restoreHash(state []byte) (hash.Hash, error) {
+	h := sha256.New()
+	if len(state) == 0 {
+		return h, nil
+	}
+
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, fmt.Errorf("failed to restore checksum state: %w", err)
+	}
+	return h, nil
+}
+
+// hashState serializes h so it can be resumed with the next chunk
+func hashState(h hash.Hash) ([]byte, error) {
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save checksum state: %w", err)
+	}
+	return state, nil
+}
@@ -0,0 +1,51 @@
+package file
+
+import (
+	"context"
+
+	"goilerplate/pkg/constants"
+)
+
+// Usage is the storage consumed by a user or a tenant
+type Usage struct {
+	Bytes int64 // stored files and variants, pending and unfinished resumable uploads reserve their size
+	Files int64 // stored files, pending ones included
+	Quota int64 // maximum bytes, 0 is unlimited
+}
+
+// Remaining returns the bytes left below the quota, -1 when unlimited
+func (u *Usage) Remaining() int64 {
+	if u.Quota <= 0 {
+		return -1
+	}
+	return max(u.Quota-u.Bytes, 0)
+}
+
+// allows reports whether size more bytes fit in the quota
+func (u *Usage) allows(size int64) bool {
+	return u.Quota <= 0 || u.Bytes+size <= u.Quota
+}
+
+// StorageUsage is the storage consumed by a user and by their tenant
+type StorageUsage struct {
+	User     Usage
+	TenantID string
+	Tenant   *Usage // nil without a tenant
+}
+
+// ReconcileResult summarizes a reconciliation of the recorded sizes with the storage listing
+type ReconcileResult struct {
+	Checked     int   // files compared with the listing
+	Updated     int   // files whose stored size was corrected
+	Missing     int   // ready files whose content is not in the storage
+	Released    int   // expired pending files removed, releasing their reservation
+	Orphans     int   // stored objects without metadata, left in place
+	OrphanBytes int64 // size of the orphans
+}
+
+// tenantOf returns the tenant of the request. The middleware resolving tenants stores
+// its ID under constants.ContextKeyStoreID, requests without one have no tenant.
+func tenantOf(ctx context.Context) string {
+	tenantID, _ := ctx.Value(constants.ContextKeyStoreID).(string)
+	return tenantID
+}
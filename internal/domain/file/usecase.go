@@ -0,0 +1,892 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"goilerplate/pkg/filesystem"
+	"goilerplate/pkg/logger"
+	"goilerplate/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// uploadPath is the folder of uploaded files on the filesystem driver
+const uploadPath = "files"
+
+// reconcileBatchSize is the number of files Reconcile loads at once
+const reconcileBatchSize = 500
+
+// cleanupBatchSize is the number of expired uploads CleanupResumable loads at once
+const cleanupBatchSize = 100
+
+// resumableClaimTTL bounds how long a chunk holds its upload, a request that dies
+// mid-chunk does not lock the upload for longer
+const resumableClaimTTL = 10 * time.Minute
+
+// Defaults of Options
+const (
+	DefaultURLTTL       = 15 * time.Minute
+	DefaultUploadTTL    = 15 * time.Minute
+	DefaultResumableTTL = 24 * time.Hour
+)
+
+// Options configures the file usecase
+type Options struct {
+	MaxFileSize      int64         // Maximum file size in bytes
+	URLTTL           time.Duration // Lifetime of signed download URLs, defaults to DefaultURLTTL
+	UploadTTL        time.Duration // Lifetime of presigned uploads, defaults to DefaultUploadTTL
+	MaxResumableSize int64         // Maximum size of resumable uploads in bytes, defaults to MaxFileSize
+	ResumableTTL     time.Duration // Time to finish a resumable upload, defaults to DefaultResumableTTL
+	StagingPath      string        // Local folder staging resumable chunks, defaults to a temporary folder
+	Policies         Policies      // Restrictions per upload route
+	UserQuota        int64         // Maximum bytes stored per user, 0 is unlimited
+	TenantQuota      int64         // Maximum bytes stored per tenant, 0 is unlimited
+}
+
+// Policies restrict the files accepted per upload route. A zero MaxSize falls back to
+// MaxFileSize, for resumable uploads to MaxResumableSize.
+type Policies struct {
+	Upload    filesystem.UploadPolicy // POST /files
+	Multiple  filesystem.UploadPolicy // POST /files/multiple
+	Presign   filesystem.UploadPolicy // POST /files/presign, the declared type is checked
+	Resumable filesystem.UploadPolicy // tus uploads, the type is sniffed from the first chunk
+}
+
+// PresignRequest describes the file a client is about to upload directly to the storage
+type PresignRequest struct {
+	Filename string
+	Size     int64
+	MimeType string
+	Checksum string // hex encoded SHA-256 of the content
+}
+
+// PresignedFile is a pending file together with the request uploading its content
+type PresignedFile struct {
+	File   *File
+	Upload *filesystem.PresignedUpload
+}
+
+type Usecase interface {
+	Upload(ctx context.Context, ownerID string, header *multipart.FileHeader) (*File, error)
+	UploadMany(ctx context.Context, ownerID string, headers []*multipart.FileHeader) ([]*File, error)
+	Delete(ctx context.Context, ownerID, id string) error
+
+	PresignUpload(ctx context.Context, ownerID string, req *PresignRequest) (*PresignedFile, error)
+	CompleteUpload(ctx context.Context, ownerID, id string) (*File, error)
+
+	CreateResumable(ctx context.Context, ownerID string, req *ResumableRequest) (*ResumableUpload, error)
+	// AppendResumable writes chunk at offset, the file is returned once the upload is complete
+	AppendResumable(ctx context.Context, ownerID, id string, offset int64, chunk io.Reader) (*ResumableUpload, *File, error)
+	TerminateResumable(ctx context.Context, ownerID, id string) error
+	GetResumable(ctx context.Context, ownerID, id string) (*ResumableUpload, error)
+	// CleanupResumable discards expired resumable uploads and their staged chunks
+	CleanupResumable(ctx context.Context) (int, error)
+
+	GetByID(ctx context.Context, ownerID, id string) (*File, error)
+	GetList(ctx context.Context, filter *Filter) ([]*File, int64, error)
+
+	// GetUsage returns the storage used by the owner and by the tenant of the request
+	GetUsage(ctx context.Context, ownerID string) (*StorageUsage, error)
+	// Reconcile corrects the recorded sizes of files from the storage listing
+	Reconcile(ctx context.Context) (*ReconcileResult, error)
+}
+
+type usecase struct {
+	repo    Repository
+	storage *filesystem.Manager
+	stager  filesystem.Stager
+	opts    Options
+}
+
+func NewUseCase(repo Repository, storage *filesystem.Manager, opts Options) Usecase {
+	if opts.URLTTL <= 0 {
+		opts.URLTTL = DefaultURLTTL
+	}
+	if opts.UploadTTL <= 0 {
+		opts.UploadTTL = DefaultUploadTTL
+	}
+	if opts.MaxResumableSize <= 0 {
+		opts.MaxResumableSize = opts.MaxFileSize
+	}
+	if opts.ResumableTTL <= 0 {
+		opts.ResumableTTL = DefaultResumableTTL
+	}
+	for _, policy := range []*filesystem.UploadPolicy{&opts.Policies.Upload, &opts.Policies.Multiple, &opts.Policies.Presign} {
+		if policy.MaxSize <= 0 {
+			policy.MaxSize = opts.MaxFileSize
+		}
+	}
+	if opts.Policies.Resumable.MaxSize <= 0 {
+		opts.Policies.Resumable.MaxSize = opts.MaxResumableSize
+	}
+	if opts.StagingPath == "" {
+		opts.StagingPath = filepath.Join(os.TempDir(), "goilerplate-uploads")
+	}
+
+	return &usecase{
+		repo:    repo,
+		storage: storage,
+		stager:  storage.Stager(opts.StagingPath),
+		opts:    opts,
+	}
+}
+
+func (uc *usecase) Upload(ctx context.Context, ownerID string, header *multipart.FileHeader) (*File, error) {
+	return uc.upload(ctx, ownerID, header, uc.opts.Policies.Upload)
+}
+
+// upload stores the file on the filesystem driver and records its metadata. The driver
+// only receives files of an allowed type, sniffed from the content, that passed the scanner.
+// The stored file is removed again when the metadata cannot be saved.
+func (uc *usecase) upload(ctx context.Context, ownerID string, header *multipart.FileHeader, policy filesystem.UploadPolicy) (*File, error) {
+	if header == nil {
+		return nil, ErrFileRequired
+	}
+
+	if err := uc.checkQuota(ctx, ownerID, header.Size); err != nil {
+		return nil, err
+	}
+
+	checksum, err := checksumOf(header)
+	if err != nil {
+		return nil, err
+	}
+
+	originalName := filesystem.SanitizeFilename(header.Filename)
+
+	result, err := uc.storage.Upload(ctx, header, filesystem.UploadOptions{
+		Path:             uploadPath,
+		MaxSize:          policy.MaxSize,
+		AllowedMimeTypes: policy.AllowedMimeTypes,
+	})
+	if err != nil {
+		if errors.Is(err, filesystem.ErrFileInfected) {
+			logger.Warn(ctx, fmt.Sprintf("rejected upload %s of %s: %v", originalName, ownerID, err))
+		}
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	size := result.Size
+	if size == 0 {
+		size = header.Size
+	}
+
+	created, err := uc.createFile(ctx, &File{
+		OwnerID:      ownerID,
+		Disk:         uc.storage.Name(),
+		Driver:       string(result.Driver),
+		Path:         result.Path,
+		OriginalName: originalName,
+		Size:         size,
+		MimeType:     result.MimeType,
+		Checksum:     checksum,
+		Status:       StatusReady,
+		Variants:     variantsOf(result),
+	})
+	if err != nil {
+		uc.removeOrphan(ctx, result)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	uc.resolveURL(ctx, created)
+
+	return created, nil
+}
+
+// UploadMany uploads every file or none, files uploaded before a failure are deleted again
+func (uc *usecase) UploadMany(ctx context.Context, ownerID string, headers []*multipart.FileHeader) ([]*File, error) {
+	if len(headers) == 0 {
+		return nil, ErrFileRequired
+	}
+
+	var total int64
+	for _, header := range headers {
+		if header != nil {
+			total += header.Size
+		}
+	}
+	if err := uc.checkQuota(ctx, ownerID, total); err != nil {
+		return nil, err
+	}
+
+	files := make([]*File, 0, len(headers))
+	for _, header := range headers {
+		created, err := uc.upload(ctx, ownerID, header, uc.opts.Policies.Multiple)
+		if err != nil {
+			for _, uploaded := range files {
+				if deleteErr := uc.Delete(ctx, ownerID, uploaded.ID); deleteErr != nil {
+					logger.Warn(ctx, fmt.Sprintf("failed to roll back upload %s: %v", uploaded.ID, deleteErr))
+				}
+			}
+			return nil, fmt.Errorf("failed to upload %s: %w", header.Filename, err)
+		}
+		files = append(files, created)
+	}
+
+	return files, nil
+}
+
+// Delete removes the metadata first, so a failing driver never leaves a file that can
+// still be fetched. The soft deleted row keeps the path of files the driver failed to remove.
+func (uc *usecase) Delete(ctx context.Context, ownerID, id string) error {
+	existing, err := uc.repo.GetFileByID(ctx, ownerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+
+	if err := uc.repo.DeleteFile(ctx, existing); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	disk, err := uc.diskOf(existing)
+	if err != nil {
+		logger.Warn(ctx, fmt.Sprintf("failed to remove file %s: %v", existing.ID, err))
+		return nil
+	}
+
+	for _, path := range existing.paths() {
+		if err := disk.Delete(ctx, path); err != nil {
+			logger.Warn(ctx, fmt.Sprintf("failed to remove file %s from disk %s: %v", path, existing.Disk, err))
+		}
+	}
+
+	return nil
+}
+
+// PresignUpload records a pending file and returns the request uploading its content
+// straight to the storage. The size, type and checksum are signed into the request,
+// so the recorded metadata matches whatever content the storage accepts.
+func (uc *usecase) PresignUpload(ctx context.Context, ownerID string, req *PresignRequest) (*PresignedFile, error) {
+	if err := uc.validatePresign(req); err != nil {
+		return nil, err
+	}
+	if err := uc.checkQuota(ctx, ownerID, req.Size); err != nil {
+		return nil, err
+	}
+
+	filename := filesystem.SanitizeFilename(req.Filename)
+	path := uploadPath + "/" + uuid.New().String() + strings.ToLower(filepath.Ext(filename))
+	checksum := strings.ToLower(req.Checksum)
+
+	upload, err := uc.storage.PresignUpload(ctx, path, filesystem.PresignOptions{
+		TTL:         uc.opts.UploadTTL,
+		ContentType: req.MimeType,
+		Size:        req.Size,
+		Checksum:    checksum,
+	})
+	if err != nil {
+		if errors.Is(err, filesystem.ErrSigningNotSupported) {
+			return nil, ErrDirectUploadNotSupported
+		}
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	created, err := uc.createFile(ctx, &File{
+		OwnerID:      ownerID,
+		Disk:         uc.storage.Name(),
+		Driver:       string(uc.storage.GetDriver()),
+		Path:         path,
+		OriginalName: filename,
+		Size:         req.Size,
+		MimeType:     req.MimeType,
+		Checksum:     checksum,
+		Status:       StatusPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	return &PresignedFile{File: created, Upload: upload}, nil
+}
+
+// CompleteUpload marks a pending file ready once its content reached the storage and passed
+// the checks of the manager. Rejected content is removed with its metadata.
+func (uc *usecase) CompleteUpload(ctx context.Context, ownerID, id string) (*File, error) {
+	pending, err := uc.repo.GetPendingFile(ctx, ownerID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	disk, err := uc.diskOf(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := disk.Exists(ctx, pending.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check file: %w", err)
+	}
+	if !exists {
+		return nil, ErrUploadIncomplete
+	}
+
+	// Direct uploads never passed the manager, their content is checked once it arrived
+	if _, err := disk.Inspect(ctx, pending.Path, filesystem.UploadOptions{
+		ContentType:      pending.MimeType,
+		AllowedMimeTypes: uc.opts.Policies.Presign.AllowedMimeTypes,
+	}); err != nil {
+		var rejected *utils.ClientError
+		if errors.As(err, &rejected) {
+			if err := uc.repo.DeleteFile(ctx, pending); err != nil {
+				logger.Warn(ctx, fmt.Sprintf("failed to remove rejected upload %s: %v", pending.ID, err))
+			}
+		}
+		return nil, err
+	}
+
+	if err := uc.repo.MarkFileReady(ctx, pending.ID); err != nil {
+		return nil, fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	pending.Status = StatusReady
+	uc.resolveURL(ctx, pending)
+
+	return pending, nil
+}
+
+// CreateResumable starts staging an upload sent in chunks
+func (uc *usecase) CreateResumable(ctx context.Context, ownerID string, req *ResumableRequest) (*ResumableUpload, error) {
+	if req == nil || req.Size <= 0 {
+		return nil, utils.ClientErr(400, "Upload length must be greater than zero")
+	}
+	policy := uc.opts.Policies.Resumable
+	if policy.MaxSize > 0 && req.Size > policy.MaxSize {
+		return nil, utils.ClientErr(413, fmt.Sprintf("File size exceeds maximum of %d bytes", policy.MaxSize))
+	}
+
+	filename := "upload"
+	if strings.TrimSpace(req.Filename) != "" {
+		filename = filesystem.SanitizeFilename(req.Filename)
+	}
+	mimeType := req.MimeType
+	if mimeType == "" {
+		mimeType = filesystem.DetectMimeType(filename)
+	}
+
+	// The declared type is checked early, the first chunk decides
+	if !filesystem.MimeTypeAllowed(policy.AllowedMimeTypes, mimeType) {
+		return nil, policy.Validate(req.Size, mimeType)
+	}
+
+	// The declared length is reserved until the upload is finished or expires
+	if err := uc.checkQuota(ctx, ownerID, req.Size); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	staged := filesystem.StagedUpload{
+		ID:   id,
+		Path: uploadPath + "/" + id + strings.ToLower(filepath.Ext(filename)),
+		Size: req.Size,
+	}
+	if err := uc.stager.Begin(ctx, &staged); err != nil {
+		return nil, fmt.Errorf("failed to begin upload: %w", err)
+	}
+
+	state, err := hashState(sha256.New())
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := uc.repo.CreateResumableUpload(ctx, &ResumableUpload{
+		ID:        id,
+		OwnerID:   ownerID,
+		TenantID:  tenantOf(ctx),
+		Filename:  filename,
+		MimeType:  mimeType,
+		Staged:    staged,
+		HashState: state,
+		ExpiresAt: utils.Now().Add(uc.opts.ResumableTTL),
+	})
+	if err != nil {
+		if abortErr := uc.stager.Abort(context.WithoutCancel(ctx), &staged); abortErr != nil {
+			logger.Warn(ctx, fmt.Sprintf("failed to abort upload %s: %v", id, abortErr))
+		}
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+
+	return created, nil
+}
+
+// AppendResumable stages the chunk and saves the new offset, one chunk at a time. The upload is
+// finalized into a file with the chunk completing it, a failed finalization is retried with an empty chunk.
+func (uc *usecase) AppendResumable(ctx context.Context, ownerID, id string, offset int64, chunk io.Reader) (*ResumableUpload, *File, error) {
+	upload, err := uc.repo.GetResumableUpload(ctx, ownerID, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	from := upload.Staged.Offset
+	if offset != from {
+		return nil, nil, ErrResumableOffsetMismatch
+	}
+
+	// Concurrent chunks for the same offset would overwrite each other's staged bytes
+	if err := uc.repo.ClaimResumableUpload(ctx, upload.ID, from, utils.Now().Add(resumableClaimTTL)); err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := uc.repo.ReleaseResumableUpload(context.WithoutCancel(ctx), upload.ID); err != nil {
+			logger.Warn(ctx, fmt.Sprintf("failed to release upload %s: %v", upload.ID, err))
+		}
+	}()
+
+	// The type of the content replaces the declared one, as soon as its first bytes arrive
+	if from == 0 {
+		head, mimeType, err := filesystem.Sniff(chunk, upload.Filename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read chunk: %w", err)
+		}
+		if len(head) > 0 {
+			if err := uc.opts.Policies.Resumable.Validate(0, mimeType); err != nil {
+				return nil, nil, err
+			}
+			upload.MimeType = mimeType
+			upload.Staged.MimeType = mimeType
+		}
+		chunk = io.MultiReader(bytes.NewReader(head), chunk)
+	}
+
+	hash, err := restoreHash(upload.HashState)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := uc.stager.Append(ctx, &upload.Staged, io.TeeReader(chunk, hash)); err != nil {
+		return nil, nil, fmt.Errorf("failed to stage chunk: %w", err)
+	}
+
+	// The stager stops at the upload length, anything left over is rejected
+	if _, err := io.ReadFull(chunk, make([]byte, 1)); err == nil {
+		return nil, nil, ErrResumableTooLarge
+	}
+
+	if upload.HashState, err = hashState(hash); err != nil {
+		return nil, nil, err
+	}
+
+	if err := uc.repo.AdvanceResumableUpload(ctx, upload, from); err != nil {
+		return nil, nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+
+	if !upload.Staged.Complete() {
+		return upload, nil, nil
+	}
+
+	created, err := uc.finalizeResumable(ctx, upload, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return upload, created, nil
+}
+
+// finalizeResumable stores the complete upload on the driver and records the file
+func (uc *usecase) finalizeResumable(ctx context.Context, upload *ResumableUpload, checksum string) (*File, error) {
+	result, err := uc.stager.Finalize(ctx, &upload.Staged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize upload: %w", err)
+	}
+
+	created, err := uc.createFile(ctx, &File{
+		OwnerID:      upload.OwnerID,
+		TenantID:     upload.TenantID,
+		Disk:         uc.storage.Name(),
+		Driver:       string(result.Driver),
+		Path:         result.Path,
+		OriginalName: upload.Filename,
+		Size:         upload.Staged.Size,
+		MimeType:     upload.MimeType,
+		Checksum:     checksum,
+		Status:       StatusReady,
+		Variants:     variantsOf(result),
+	})
+	if err != nil {
+		uc.removeOrphan(ctx, result)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	if err := uc.repo.DeleteResumableUpload(ctx, upload.ID); err != nil {
+		logger.Warn(ctx, fmt.Sprintf("failed to remove finished upload %s: %v", upload.ID, err))
+	}
+
+	uc.resolveURL(ctx, created)
+
+	return created, nil
+}
+
+// TerminateResumable discards an upload and its staged chunks
+func (uc *usecase) TerminateResumable(ctx context.Context, ownerID, id string) error {
+	upload, err := uc.repo.GetResumableUpload(ctx, ownerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get upload: %w", err)
+	}
+
+	if err := uc.repo.DeleteResumableUpload(ctx, upload.ID); err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+
+	if err := uc.stager.Abort(ctx, &upload.Staged); err != nil {
+		logger.Warn(ctx, fmt.Sprintf("failed to abort upload %s: %v", upload.ID, err))
+	}
+
+	return nil
+}
+
+// CleanupResumable aborts the staging of expired uploads before deleting their rows, an upload
+// whose staging cannot be aborted keeps its row so the next run retries it
+func (uc *usecase) CleanupResumable(ctx context.Context) (int, error) {
+	removed := 0
+
+	for {
+		uploads, err := uc.repo.GetExpiredResumableUploads(ctx, cleanupBatchSize)
+		if err != nil {
+			return removed, fmt.Errorf("failed to get expired uploads: %w", err)
+		}
+
+		failed := 0
+		for _, upload := range uploads {
+			if err := uc.stager.Abort(ctx, &upload.Staged); err != nil {
+				logger.Warn(ctx, fmt.Sprintf("failed to abort expired upload %s: %v", upload.ID, err))
+				failed++
+				continue
+			}
+
+			if err := uc.repo.DeleteResumableUpload(ctx, upload.ID); err != nil {
+				return removed, fmt.Errorf("failed to delete upload: %w", err)
+			}
+			removed++
+		}
+
+		// Failed uploads would be loaded again
+		if len(uploads) < cleanupBatchSize || failed > 0 {
+			return removed, nil
+		}
+	}
+}
+
+func (uc *usecase) GetResumable(ctx context.Context, ownerID, id string) (*ResumableUpload, error) {
+	upload, err := uc.repo.GetResumableUpload(ctx, ownerID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+	return upload, nil
+}
+
+func (uc *usecase) validatePresign(req *PresignRequest) error {
+	if req == nil || strings.TrimSpace(req.Filename) == "" {
+		return ErrFileRequired
+	}
+
+	if req.Size <= 0 {
+		return utils.ClientErr(400, "File size must be greater than zero")
+	}
+	policy := uc.opts.Policies.Presign
+	if policy.MaxSize > 0 && req.Size > policy.MaxSize {
+		return utils.ClientErr(400, fmt.Sprintf("File size exceeds maximum of %d bytes", policy.MaxSize))
+	}
+	if err := policy.Validate(req.Size, req.MimeType); err != nil {
+		return err
+	}
+
+	if decoded, err := hex.DecodeString(req.Checksum); err != nil || len(decoded) != sha256.Size {
+		return utils.ClientErr(400, "Checksum must be a hex encoded SHA-256")
+	}
+
+	return nil
+}
+
+func (uc *usecase) GetByID(ctx context.Context, ownerID, id string) (*File, error) {
+	file, err := uc.repo.GetFileByID(ctx, ownerID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	uc.resolveURL(ctx, file)
+
+	return file, nil
+}
+
+func (uc *usecase) GetList(ctx context.Context, filter *Filter) ([]*File, int64, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	if filter.Keyword != "" {
+		filter.Keyword = strings.TrimSpace(filter.Keyword)
+	}
+
+	files, err := uc.repo.GetFileList(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get files: %w", err)
+	}
+
+	total, err := uc.repo.CountFile(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count files: %w", err)
+	}
+
+	for _, file := range files {
+		uc.resolveURL(ctx, file)
+	}
+
+	return files, total, nil
+}
+
+func (uc *usecase) GetUsage(ctx context.Context, ownerID string) (*StorageUsage, error) {
+	usage, err := uc.repo.GetOwnerUsage(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	usage.Quota = uc.opts.UserQuota
+
+	result := &StorageUsage{User: *usage, TenantID: tenantOf(ctx)}
+	if result.TenantID == "" {
+		return result, nil
+	}
+
+	tenant, err := uc.repo.GetTenantUsage(ctx, result.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", err)
+	}
+	tenant.Quota = uc.opts.TenantQuota
+	result.Tenant = tenant
+
+	return result, nil
+}
+
+// checkQuota rejects storing size more bytes for the owner or the tenant of the request
+// once it would exceed their quota. Concurrent uploads are checked against the same usage,
+// so each of them may overshoot the quota by at most its own size.
+func (uc *usecase) checkQuota(ctx context.Context, ownerID string, size int64) error {
+	if uc.opts.UserQuota <= 0 && uc.opts.TenantQuota <= 0 {
+		return nil
+	}
+
+	usage, err := uc.GetUsage(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if !usage.User.allows(size) {
+		return ErrQuotaExceeded
+	}
+	if usage.Tenant != nil && !usage.Tenant.allows(size) {
+		return ErrTenantQuotaExceeded
+	}
+
+	return nil
+}
+
+// Reconcile compares the files recorded on the current driver with the storage listing.
+// Stored sizes are corrected to what the storage holds, expired pending files whose
+// content never arrived are removed, releasing their reservation. Ready files missing
+// from the storage and objects without metadata are only reported.
+func (uc *usecase) Reconcile(ctx context.Context) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+	expired := utils.Now().Add(-uc.opts.UploadTTL)
+
+	for _, disk := range uc.storage.Disks() {
+		// A mirror holds copies of the files of its primary disk
+		if disk.IsMirror() {
+			continue
+		}
+		if err := uc.reconcileDisk(ctx, disk, expired, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// reconcileDisk compares the files recorded on disk with its listing
+func (uc *usecase) reconcileDisk(ctx context.Context, disk *filesystem.Manager, expired time.Time, result *ReconcileResult) error {
+	listing, err := disk.List(ctx, uploadPath+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list files of disk %s: %w", disk.Name(), err)
+	}
+
+	// Files are matched by the identifier the driver addresses them with, which is what
+	// File.Path records. Drive lists by name, but its identifier is the file ID.
+	sizes := make(map[string]int64, len(listing))
+	for _, info := range listing {
+		sizes[info.Path] = info.Size
+	}
+
+	for afterID := ""; ; {
+		files, err := uc.repo.GetStoredFiles(ctx, disk.Name(), afterID, reconcileBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get files: %w", err)
+		}
+
+		for _, file := range files {
+			afterID = file.ID
+			if err := uc.reconcileFile(ctx, disk, file, sizes, expired, result); err != nil {
+				return err
+			}
+		}
+
+		if len(files) < reconcileBatchSize {
+			break
+		}
+	}
+
+	// Every referenced path was taken out of sizes
+	for path, size := range sizes {
+		result.Orphans++
+		result.OrphanBytes += size
+		logger.Debug(ctx, fmt.Sprintf("stored file %s on disk %s has no metadata", path, disk.Name()))
+	}
+
+	return nil
+}
+
+// reconcileFile corrects the stored size of file and takes its paths out of sizes
+func (uc *usecase) reconcileFile(ctx context.Context, disk *filesystem.Manager, file *File, sizes map[string]int64, expired time.Time, result *ReconcileResult) error {
+	result.Checked++
+
+	stored, ok := sizes[file.Path]
+	if !ok && file.Status == StatusReady {
+		// Drive lists by name, a file named outside the upload folder is still found by its ID
+		info, err := disk.Stat(ctx, file.Path)
+		switch {
+		case err == nil:
+			stored, ok = info.Size, true
+		case !errors.Is(err, filesystem.ErrFileNotFound):
+			return fmt.Errorf("failed to check file %s: %w", file.ID, err)
+		}
+	}
+	if !ok {
+		switch {
+		case file.Status == StatusPending && file.CreatedAt.Before(expired):
+			if err := uc.repo.DeleteFile(ctx, file); err != nil {
+				return fmt.Errorf("failed to remove expired upload %s: %w", file.ID, err)
+			}
+			result.Released++
+		case file.Status == StatusReady:
+			// The recorded size stays counted, a missing file must not free quota
+			result.Missing++
+			logger.Warn(ctx, fmt.Sprintf("file %s is missing from disk %s at %s", file.ID, file.Disk, file.Path))
+		}
+		return nil
+	}
+	delete(sizes, file.Path)
+
+	for _, variant := range file.Variants {
+		if size, ok := sizes[variant.Path]; ok {
+			stored += size
+			delete(sizes, variant.Path)
+		}
+	}
+
+	if stored == file.StoredSize {
+		return nil
+	}
+
+	if err := uc.repo.UpdateStoredSize(ctx, file.ID, stored); err != nil {
+		return fmt.Errorf("failed to update file %s: %w", file.ID, err)
+	}
+	result.Updated++
+
+	return nil
+}
+
+// createFile records entity for the tenant of the request, counting its variants
+func (uc *usecase) createFile(ctx context.Context, entity *File) (*File, error) {
+	if entity.TenantID == "" {
+		entity.TenantID = tenantOf(ctx)
+	}
+	entity.StoredSize = entity.storedSize()
+
+	return uc.repo.CreateFile(ctx, entity)
+}
+
+// resolveURL sets expiring signed URLs of the file and its variants. Drivers that cannot
+// sign fall back to their public URL, drivers without either leave it empty.
+func (uc *usecase) resolveURL(ctx context.Context, file *File) {
+	disk, err := uc.diskOf(file)
+	if err != nil {
+		logger.Debug(ctx, fmt.Sprintf("no URL for %s: %v", file.ID, err))
+		return
+	}
+
+	file.URL = uc.urlOf(ctx, disk, file.Path)
+	for i := range file.Variants {
+		file.Variants[i].URL = uc.urlOf(ctx, disk, file.Variants[i].Path)
+	}
+}
+
+func (uc *usecase) urlOf(ctx context.Context, disk *filesystem.Manager, path string) string {
+	url, err := disk.SignedURL(ctx, path, uc.opts.URLTTL)
+	if errors.Is(err, filesystem.ErrSigningNotSupported) {
+		url, err = disk.URL(ctx, path)
+	}
+	if err != nil {
+		logger.Debug(ctx, fmt.Sprintf("no URL for %s: %v", path, err))
+		return ""
+	}
+	return url
+}
+
+// diskOf returns the disk holding file, the one it was stored on
+func (uc *usecase) diskOf(file *File) (*filesystem.Manager, error) {
+	return uc.storage.Disk(file.Disk)
+}
+
+// removeOrphan deletes a stored upload and its variants whose metadata could not be saved
+func (uc *usecase) removeOrphan(ctx context.Context, result *filesystem.UploadResult) {
+	ctx = context.WithoutCancel(ctx) // the request may be gone already
+	paths := []string{result.Path}
+	for _, variant := range result.Variants {
+		paths = append(paths, variant.Path)
+	}
+
+	for _, path := range paths {
+		if err := uc.storage.Delete(ctx, path); err != nil {
+			logger.Warn(ctx, fmt.Sprintf("failed to remove orphaned upload %s: %v", path, err))
+		}
+	}
+}
+
+// variantsOf returns the image variants stored with result
+func variantsOf(result *filesystem.UploadResult) []Variant {
+	var variants []Variant
+	for _, variant := range result.Variants {
+		variants = append(variants, Variant{
+			Name:     variant.Name,
+			Path:     variant.Path,
+			MimeType: variant.MimeType,
+			Width:    variant.Width,
+			Height:   variant.Height,
+			Size:     variant.Size,
+		})
+	}
+	return variants
+}
+
+// checksumOf returns the hex encoded SHA-256 of the uploaded content
+func checksumOf(header *multipart.FileHeader) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,1031 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/filesystem"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository is an in-memory Repository used to exercise the usecase
+type fakeRepository struct {
+	items     map[string]*File
+	uploads   map[string]*ResumableUpload
+	claims    map[string]bool
+	seq       int
+	createErr error // returned by CreateFile once set
+	failAfter int   // CreateFile fails after this many creates when > 0
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{items: make(map[string]*File), uploads: make(map[string]*ResumableUpload), claims: make(map[string]bool)}
+}
+
+func (r *fakeRepository) WithTx(ctx context.Context) Repository { return r }
+
+func (r *fakeRepository) CreateFile(ctx context.Context, entity *File) (*File, error) {
+	if r.createErr != nil || (r.failAfter > 0 && r.seq >= r.failAfter) {
+		return nil, errors.New("insert failed")
+	}
+	r.seq++
+	created := entity.Clone()
+	created.ID = fmt.Sprintf("id-%d", r.seq)
+	created.CreatedAt = time.Now()
+	r.items[created.ID] = created
+	return created.Clone(), nil
+}
+
+func (r *fakeRepository) MarkFileReady(ctx context.Context, id string) error {
+	if item, ok := r.items[id]; ok && item.Status == StatusPending {
+		item.Status = StatusReady
+	}
+	return nil
+}
+
+func (r *fakeRepository) DeleteFile(ctx context.Context, entity *File) error {
+	delete(r.items, entity.ID)
+	return nil
+}
+
+func (r *fakeRepository) CountFile(ctx context.Context, filter *Filter) (int64, error) {
+	files, _ := r.GetFileList(ctx, filter)
+	return int64(len(files)), nil
+}
+
+func (r *fakeRepository) GetFileList(ctx context.Context, filter *Filter) ([]*File, error) {
+	var files []*File
+	for _, item := range r.items {
+		if item.OwnerID == filter.OwnerID && item.Status == StatusReady {
+			files = append(files, item.Clone())
+		}
+	}
+	return files, nil
+}
+
+func (r *fakeRepository) GetFileByID(ctx context.Context, ownerID, id string) (*File, error) {
+	return r.getFile(ownerID, id, StatusReady)
+}
+
+func (r *fakeRepository) GetPendingFile(ctx context.Context, ownerID, id string) (*File, error) {
+	return r.getFile(ownerID, id, StatusPending)
+}
+
+func (r *fakeRepository) getFile(ownerID, id, status string) (*File, error) {
+	item, ok := r.items[id]
+	if !ok || item.OwnerID != ownerID || item.Status != status {
+		return nil, ErrNotFound
+	}
+	return item.Clone(), nil
+}
+
+func (r *fakeRepository) GetOwnerUsage(ctx context.Context, ownerID string) (*Usage, error) {
+	return r.usage(func(item *File) bool { return item.OwnerID == ownerID },
+		func(upload *ResumableUpload) bool { return upload.OwnerID == ownerID }), nil
+}
+
+func (r *fakeRepository) GetTenantUsage(ctx context.Context, tenantID string) (*Usage, error) {
+	return r.usage(func(item *File) bool { return item.TenantID == tenantID },
+		func(upload *ResumableUpload) bool { return upload.TenantID == tenantID }), nil
+}
+
+func (r *fakeRepository) usage(file func(*File) bool, upload func(*ResumableUpload) bool) *Usage {
+	usage := &Usage{}
+	for _, item := range r.items {
+		if file(item) {
+			usage.Bytes += item.StoredSize
+			usage.Files++
+		}
+	}
+	for _, item := range r.uploads {
+		if upload(item) {
+			usage.Bytes += item.Staged.Size
+		}
+	}
+	return usage
+}
+
+func (r *fakeRepository) GetStoredFiles(ctx context.Context, disk, afterID string, limit int) ([]*File, error) {
+	var files []*File
+	for _, item := range r.items {
+		if item.Disk == disk && item.ID > afterID {
+			files = append(files, item.Clone())
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	return files[:min(limit, len(files))], nil
+}
+
+func (r *fakeRepository) UpdateStoredSize(ctx context.Context, id string, size int64) error {
+	if item, ok := r.items[id]; ok {
+		item.StoredSize = size
+	}
+	return nil
+}
+
+func (r *fakeRepository) CreateResumableUpload(ctx context.Context, entity *ResumableUpload) (*ResumableUpload, error) {
+	r.uploads[entity.ID] = entity.Clone()
+	return entity.Clone(), nil
+}
+
+func (r *fakeRepository) AdvanceResumableUpload(ctx context.Context, entity *ResumableUpload, from int64) error {
+	item, ok := r.uploads[entity.ID]
+	if !ok || item.Staged.Offset != from {
+		return ErrResumableOffsetMismatch
+	}
+	r.uploads[entity.ID] = entity.Clone()
+	return nil
+}
+
+func (r *fakeRepository) ClaimResumableUpload(ctx context.Context, id string, offset int64, until time.Time) error {
+	item, ok := r.uploads[id]
+	if !ok || item.Staged.Offset != offset || r.claims[id] {
+		return ErrResumableLocked
+	}
+	r.claims[id] = true
+	return nil
+}
+
+func (r *fakeRepository) ReleaseResumableUpload(ctx context.Context, id string) error {
+	delete(r.claims, id)
+	return nil
+}
+
+func (r *fakeRepository) DeleteResumableUpload(ctx context.Context, id string) error {
+	delete(r.uploads, id)
+	return nil
+}
+
+func (r *fakeRepository) GetResumableUpload(ctx context.Context, ownerID, id string) (*ResumableUpload, error) {
+	item, ok := r.uploads[id]
+	if !ok || item.OwnerID != ownerID {
+		return nil, ErrResumableNotFound
+	}
+	return item.Clone(), nil
+}
+
+func (r *fakeRepository) GetExpiredResumableUploads(ctx context.Context, limit int) ([]*ResumableUpload, error) {
+	var uploads []*ResumableUpload
+	for _, item := range r.uploads {
+		if item.ExpiresAt.Before(time.Now()) && !r.claims[item.ID] {
+			uploads = append(uploads, item.Clone())
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].ID < uploads[j].ID })
+	if len(uploads) > limit {
+		uploads = uploads[:limit]
+	}
+	return uploads, nil
+}
+
+func newTestUsecase(t *testing.T, repo Repository) (Usecase, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	storage := filesystem.NewManager(filesystem.NewLocalStorage(dir, "http://localhost/storage"))
+
+	return NewUseCase(repo, storage, Options{MaxFileSize: 1024, StagingPath: t.TempDir()}), dir
+}
+
+// newSigningUsecase stores files on a local driver issuing signed URLs
+func newSigningUsecase(t *testing.T, repo Repository) (Usecase, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	local := filesystem.NewLocalStorage(dir, "http://localhost/storage").
+		WithSigner(filesystem.NewURLSigner("secret", "http://localhost/storage/signed"))
+
+	return NewUseCase(repo, filesystem.NewManager(local), Options{MaxFileSize: 1024, StagingPath: t.TempDir()}), dir
+}
+
+// newPolicyUsecase stores files on a local driver restricted by policies
+func newPolicyUsecase(t *testing.T, repo Repository, policies Policies) (Usecase, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	storage := filesystem.NewManager(filesystem.NewLocalStorage(dir, ""))
+
+	return NewUseCase(repo, storage, Options{MaxFileSize: 1024, StagingPath: t.TempDir(), Policies: policies}), dir
+}
+
+// newQuotaUsecase stores files on a local driver limited by storage quotas
+func newQuotaUsecase(t *testing.T, repo Repository, userQuota, tenantQuota int64) (Usecase, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	local := filesystem.NewLocalStorage(dir, "http://localhost/storage").
+		WithSigner(filesystem.NewURLSigner("secret", "http://localhost/storage/signed"))
+
+	return NewUseCase(repo, filesystem.NewManager(local), Options{
+		MaxFileSize: 1024,
+		StagingPath: t.TempDir(),
+		UserQuota:   userQuota,
+		TenantQuota: tenantQuota,
+	}), dir
+}
+
+// fileHeader builds the multipart header a client upload would produce
+func fileHeader(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = form.RemoveAll() })
+
+	return form.File["file"][0]
+}
+
+// stalledReader holds its first read until resume is closed, signalling reading once it got there
+type stalledReader struct {
+	io.Reader
+	reading chan struct{}
+	resume  chan struct{}
+	once    sync.Once
+}
+
+func newStalledReader(content []byte) *stalledReader {
+	return &stalledReader{Reader: bytes.NewReader(content), reading: make(chan struct{}), resume: make(chan struct{})}
+}
+
+func (r *stalledReader) Read(p []byte) (int, error) {
+	r.once.Do(func() {
+		close(r.reading)
+		<-r.resume
+	})
+	return r.Reader.Read(p)
+}
+
+func storedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(filepath.Join(dir, uploadPath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestUsecase_Upload(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello world")
+	sum := sha256.Sum256(content)
+
+	t.Run("should store the file and record its metadata", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, dir := newTestUsecase(t, repo)
+
+		created, err := uc.Upload(ctx, "user-1", fileHeader(t, "hello.txt", content))
+
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", created.OwnerID)
+		assert.Equal(t, "local", created.Driver)
+		assert.Equal(t, "hello.txt", created.OriginalName)
+		assert.Equal(t, int64(len(content)), created.Size)
+		assert.Equal(t, "text/plain", created.MimeType)
+		assert.Equal(t, hex.EncodeToString(sum[:]), created.Checksum)
+		assert.Contains(t, created.URL, "http://localhost/storage/files/")
+		assert.Len(t, storedFiles(t, dir), 1)
+	})
+
+	t.Run("should remove the stored file when the metadata cannot be saved", func(t *testing.T) {
+		repo := newFakeRepository()
+		repo.createErr = errors.New("insert failed")
+		uc, dir := newTestUsecase(t, repo)
+
+		_, err := uc.Upload(ctx, "user-1", fileHeader(t, "hello.txt", content))
+
+		require.Error(t, err)
+		assert.Empty(t, storedFiles(t, dir))
+	})
+
+	t.Run("should reject files over the size limit", func(t *testing.T) {
+		uc, dir := newTestUsecase(t, newFakeRepository())
+
+		_, err := uc.Upload(ctx, "user-1", fileHeader(t, "big.txt", bytes.Repeat([]byte("a"), 2048)))
+
+		require.Error(t, err)
+		assert.Empty(t, storedFiles(t, dir))
+	})
+
+	t.Run("should record a sanitized original name", func(t *testing.T) {
+		uc, _ := newTestUsecase(t, newFakeRepository())
+
+		created, err := uc.Upload(ctx, "user-1", fileHeader(t, ".hello<world>?.txt", content))
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello_world__.txt", created.OriginalName)
+	})
+
+	t.Run("should apply the policy of the route to the sniffed type", func(t *testing.T) {
+		uc, dir := newPolicyUsecase(t, newFakeRepository(), Policies{
+			Upload: filesystem.UploadPolicy{AllowedMimeTypes: []string{"image/*"}},
+		})
+
+		_, err := uc.Upload(ctx, "user-1", fileHeader(t, "photo.png", content))
+		require.Error(t, err)
+		assert.Empty(t, storedFiles(t, dir))
+
+		_, err = uc.UploadMany(ctx, "user-1", []*multipart.FileHeader{fileHeader(t, "photo.png", content)})
+		require.NoError(t, err, "multiple uploads have their own policy")
+	})
+}
+
+func TestUsecase_UploadMany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should delete the uploaded files when one fails", func(t *testing.T) {
+		repo := newFakeRepository()
+		repo.failAfter = 1
+		uc, dir := newTestUsecase(t, repo)
+
+		_, err := uc.UploadMany(ctx, "user-1", []*multipart.FileHeader{
+			fileHeader(t, "a.txt", []byte("a")),
+			fileHeader(t, "b.txt", []byte("b")),
+		})
+
+		require.Error(t, err)
+		assert.Empty(t, repo.items)
+		assert.Empty(t, storedFiles(t, dir))
+	})
+
+	t.Run("should require at least one file", func(t *testing.T) {
+		uc, _ := newTestUsecase(t, newFakeRepository())
+
+		_, err := uc.UploadMany(ctx, "user-1", nil)
+
+		assert.ErrorIs(t, err, ErrFileRequired)
+	})
+}
+
+func TestUsecase_OwnerScope(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	uc, dir := newTestUsecase(t, repo)
+
+	created, err := uc.Upload(ctx, "user-1", fileHeader(t, "hello.txt", []byte("hello")))
+	require.NoError(t, err)
+
+	t.Run("should hide files of other users", func(t *testing.T) {
+		_, err := uc.GetByID(ctx, "user-2", created.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		files, total, err := uc.GetList(ctx, &Filter{OwnerID: "user-2"})
+		require.NoError(t, err)
+		assert.Empty(t, files)
+		assert.Zero(t, total)
+
+		assert.ErrorIs(t, uc.Delete(ctx, "user-2", created.ID), ErrNotFound)
+	})
+
+	t.Run("should delete the metadata and the stored file", func(t *testing.T) {
+		got, err := uc.GetByID(ctx, "user-1", created.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, got.URL)
+
+		require.NoError(t, uc.Delete(ctx, "user-1", created.ID))
+
+		assert.Empty(t, repo.items)
+		assert.Empty(t, storedFiles(t, dir))
+	})
+}
+
+func TestUsecase_SignedURL(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newSigningUsecase(t, newFakeRepository())
+
+	created, err := uc.Upload(ctx, "user-1", fileHeader(t, "hello.txt", []byte("hello")))
+	require.NoError(t, err)
+
+	assert.Contains(t, created.URL, "http://localhost/storage/signed/files/")
+	assert.Contains(t, created.URL, filesystem.SignedParamSignature+"=")
+}
+
+func TestUsecase_PresignUpload(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello world")
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	request := func() *PresignRequest {
+		return &PresignRequest{Filename: "hello.TXT", Size: int64(len(content)), MimeType: "text/plain", Checksum: checksum}
+	}
+
+	t.Run("should record a pending file and sign its upload", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, _ := newSigningUsecase(t, repo)
+
+		presigned, err := uc.PresignUpload(ctx, "user-1", request())
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, presigned.File.Status)
+		assert.Equal(t, checksum, presigned.File.Checksum)
+		assert.Regexp(t, `^files/[0-9a-f-]{36}\.txt$`, presigned.File.Path)
+		assert.Equal(t, "PUT", presigned.Upload.Method)
+		assert.Contains(t, presigned.Upload.URL, "http://localhost/storage/signed/"+presigned.File.Path)
+		assert.Equal(t, "text/plain", presigned.Upload.Headers["Content-Type"])
+
+		_, err = uc.GetByID(ctx, "user-1", presigned.File.ID)
+		assert.ErrorIs(t, err, ErrNotFound, "pending files are not readable")
+	})
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		uc, _ := newSigningUsecase(t, newFakeRepository())
+
+		tooBig := request()
+		tooBig.Size = 2048
+		badChecksum := request()
+		badChecksum.Checksum = "abc"
+		empty := request()
+		empty.Size = 0
+
+		for _, req := range []*PresignRequest{tooBig, badChecksum, empty, nil} {
+			_, err := uc.PresignUpload(ctx, "user-1", req)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("should fail when the driver cannot sign", func(t *testing.T) {
+		uc, _ := newTestUsecase(t, newFakeRepository())
+
+		_, err := uc.PresignUpload(ctx, "user-1", request())
+
+		assert.ErrorIs(t, err, ErrDirectUploadNotSupported)
+	})
+}
+
+func TestUsecase_CompleteUpload(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello world")
+	sum := sha256.Sum256(content)
+
+	repo := newFakeRepository()
+	uc, dir := newSigningUsecase(t, repo)
+
+	presigned, err := uc.PresignUpload(ctx, "user-1", &PresignRequest{
+		Filename: "hello.txt", Size: int64(len(content)), MimeType: "text/plain", Checksum: hex.EncodeToString(sum[:]),
+	})
+	require.NoError(t, err)
+	id := presigned.File.ID
+
+	t.Run("should refuse until the content is uploaded", func(t *testing.T) {
+		_, err := uc.CompleteUpload(ctx, "user-1", id)
+		assert.ErrorIs(t, err, ErrUploadIncomplete)
+	})
+
+	t.Run("should hide pending files of other users", func(t *testing.T) {
+		_, err := uc.CompleteUpload(ctx, "user-2", id)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("should mark the file ready once uploaded", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, uploadPath), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, presigned.File.Path), content, 0644))
+
+		completed, err := uc.CompleteUpload(ctx, "user-1", id)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusReady, completed.Status)
+		assert.Contains(t, completed.URL, filesystem.SignedParamSignature+"=")
+
+		_, err = uc.GetByID(ctx, "user-1", id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should remove content that is not of the declared type", func(t *testing.T) {
+		disguised, err := uc.PresignUpload(ctx, "user-1", &PresignRequest{
+			Filename: "photo.png", Size: int64(len(content)), MimeType: "image/png", Checksum: hex.EncodeToString(sum[:]),
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, disguised.File.Path), content, 0644))
+
+		_, err = uc.CompleteUpload(ctx, "user-1", disguised.File.ID)
+
+		assert.ErrorIs(t, err, filesystem.ErrTypeMismatch)
+		assert.NoFileExists(t, filepath.Join(dir, disguised.File.Path))
+		assert.NotContains(t, repo.items, disguised.File.ID, "the reservation is released")
+	})
+}
+
+func TestUsecase_Resumable(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello resumable world")
+	sum := sha256.Sum256(content)
+
+	create := func(t *testing.T, uc Usecase) *ResumableUpload {
+		t.Helper()
+		upload, err := uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "hello.txt", Size: int64(len(content))})
+		require.NoError(t, err)
+		return upload
+	}
+
+	t.Run("should finalize the file with the last chunk", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, dir := newTestUsecase(t, repo)
+		upload := create(t, uc)
+		assert.Equal(t, "text/plain", upload.MimeType)
+
+		progress, created, err := uc.AppendResumable(ctx, "user-1", upload.ID, 0, bytes.NewReader(content[:5]))
+		require.NoError(t, err)
+		assert.Nil(t, created)
+		assert.Equal(t, int64(5), progress.Staged.Offset)
+
+		got, err := uc.GetResumable(ctx, "user-1", upload.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), got.Staged.Offset)
+
+		progress, created, err = uc.AppendResumable(ctx, "user-1", upload.ID, 5, bytes.NewReader(content[5:]))
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		assert.Equal(t, int64(len(content)), progress.Staged.Offset)
+		assert.Equal(t, hex.EncodeToString(sum[:]), created.Checksum)
+		assert.Equal(t, "hello.txt", created.OriginalName)
+		assert.Equal(t, StatusReady, created.Status)
+
+		stored, err := os.ReadFile(filepath.Join(dir, created.Path))
+		require.NoError(t, err)
+		assert.Equal(t, content, stored)
+		assert.Empty(t, repo.uploads, "finished uploads are removed")
+	})
+
+	t.Run("should reject a chunk at another offset", func(t *testing.T) {
+		uc, _ := newTestUsecase(t, newFakeRepository())
+		upload := create(t, uc)
+
+		_, _, err := uc.AppendResumable(ctx, "user-1", upload.ID, 3, bytes.NewReader(content))
+
+		assert.ErrorIs(t, err, ErrResumableOffsetMismatch)
+	})
+
+	t.Run("should write one of concurrent chunks at the same offset", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, dir := newTestUsecase(t, repo)
+		upload := create(t, uc)
+
+		first := newStalledReader(content)
+		done := make(chan *File)
+		go func() {
+			_, created, err := uc.AppendResumable(ctx, "user-1", upload.ID, 0, first)
+			assert.NoError(t, err)
+			done <- created
+		}()
+		<-first.reading
+
+		// The second chunk arrives while the first one is being written
+		_, _, err := uc.AppendResumable(ctx, "user-1", upload.ID, 0, bytes.NewReader(bytes.Repeat([]byte("x"), len(content))))
+		assert.ErrorIs(t, err, ErrResumableLocked)
+
+		close(first.resume)
+		created := <-done
+		require.NotNil(t, created)
+
+		stored, err := os.ReadFile(filepath.Join(dir, created.Path))
+		require.NoError(t, err)
+		assert.Equal(t, content, stored)
+		assert.Empty(t, repo.claims, "the claim is released")
+	})
+
+	t.Run("should reject bytes past the upload length", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, _ := newTestUsecase(t, repo)
+		upload := create(t, uc)
+
+		_, _, err := uc.AppendResumable(ctx, "user-1", upload.ID, 0, bytes.NewReader(append(content, '!')))
+		assert.ErrorIs(t, err, ErrResumableTooLarge)
+
+		got, err := uc.GetResumable(ctx, "user-1", upload.ID)
+		require.NoError(t, err)
+		assert.Zero(t, got.Staged.Offset, "the rejected chunk is not accepted")
+		assert.Empty(t, repo.items)
+	})
+
+	t.Run("should reject uploads over the size limit", func(t *testing.T) {
+		uc, _ := newTestUsecase(t, newFakeRepository())
+
+		_, err := uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "big.bin", Size: 2048})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should hide uploads of other users", func(t *testing.T) {
+		uc, _ := newTestUsecase(t, newFakeRepository())
+		upload := create(t, uc)
+
+		_, _, err := uc.AppendResumable(ctx, "user-2", upload.ID, 0, bytes.NewReader(content))
+		assert.ErrorIs(t, err, ErrResumableNotFound)
+		assert.ErrorIs(t, uc.TerminateResumable(ctx, "user-2", upload.ID), ErrResumableNotFound)
+	})
+
+	t.Run("should record the type of the first chunk", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, _ := newTestUsecase(t, repo)
+		upload, err := uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "hello.pdf", MimeType: "application/pdf", Size: int64(len(content))})
+		require.NoError(t, err)
+
+		_, created, err := uc.AppendResumable(ctx, "user-1", upload.ID, 0, bytes.NewReader(content))
+
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", created.MimeType)
+	})
+
+	t.Run("should reject a first chunk of a type outside the allowlist", func(t *testing.T) {
+		uc, _ := newPolicyUsecase(t, newFakeRepository(), Policies{
+			Resumable: filesystem.UploadPolicy{AllowedMimeTypes: []string{"image/*"}},
+		})
+
+		_, err := uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "hello.txt", Size: int64(len(content))})
+		require.Error(t, err, "the declared type is checked first")
+
+		upload, err := uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "hello.png", Size: int64(len(content))})
+		require.NoError(t, err)
+
+		_, _, err = uc.AppendResumable(ctx, "user-1", upload.ID, 0, bytes.NewReader(content))
+		require.Error(t, err)
+
+		got, err := uc.GetResumable(ctx, "user-1", upload.ID)
+		require.NoError(t, err)
+		assert.Zero(t, got.Staged.Offset)
+	})
+
+	t.Run("should discard a terminated upload", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, _ := newTestUsecase(t, repo)
+		upload := create(t, uc)
+
+		require.NoError(t, uc.TerminateResumable(ctx, "user-1", upload.ID))
+
+		_, err := uc.GetResumable(ctx, "user-1", upload.ID)
+		assert.ErrorIs(t, err, ErrResumableNotFound)
+	})
+}
+
+func TestUsecase_CleanupResumable(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello resumable world")
+
+	repo := newFakeRepository()
+	staging := t.TempDir()
+	storage := filesystem.NewManager(filesystem.NewLocalStorage(t.TempDir(), ""))
+	uc := NewUseCase(repo, storage, Options{MaxFileSize: 1024, StagingPath: staging})
+
+	begin := func(t *testing.T) *ResumableUpload {
+		t.Helper()
+		upload, err := uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "hello.txt", Size: int64(len(content))})
+		require.NoError(t, err)
+		_, _, err = uc.AppendResumable(ctx, "user-1", upload.ID, 0, bytes.NewReader(content[:5]))
+		require.NoError(t, err)
+		return upload
+	}
+
+	expired, claimed, active := begin(t), begin(t), begin(t)
+	repo.uploads[expired.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	repo.uploads[claimed.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	repo.claims[claimed.ID] = true
+
+	removed, err := uc.CleanupResumable(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	assert.NotContains(t, repo.uploads, expired.ID)
+	assert.NoFileExists(t, filepath.Join(staging, expired.ID), "the staged chunks are removed")
+	assert.Contains(t, repo.uploads, claimed.ID, "uploads receiving a chunk are left alone")
+	assert.FileExists(t, filepath.Join(staging, claimed.ID))
+	assert.Contains(t, repo.uploads, active.ID)
+	assert.FileExists(t, filepath.Join(staging, active.ID))
+
+	usage, err := uc.GetUsage(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2*int64(len(content)), usage.User.Bytes, "the expired upload no longer reserves its size")
+}
+
+func TestUsecase_Quota(t *testing.T) {
+	ctx := context.Background()
+	content := bytes.Repeat([]byte("a"), 40)
+	sum := sha256.Sum256(content)
+
+	t.Run("should reject uploads past the user quota", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, dir := newQuotaUsecase(t, repo, 100, 0)
+
+		_, err := uc.Upload(ctx, "user-1", fileHeader(t, "a.txt", content))
+		require.NoError(t, err)
+		_, err = uc.Upload(ctx, "user-1", fileHeader(t, "b.txt", content))
+		require.NoError(t, err)
+
+		_, err = uc.Upload(ctx, "user-1", fileHeader(t, "c.txt", content))
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.Len(t, storedFiles(t, dir), 2, "rejected files never reach the driver")
+
+		_, err = uc.Upload(ctx, "user-2", fileHeader(t, "c.txt", content))
+		assert.NoError(t, err, "quotas are per user")
+	})
+
+	t.Run("should check the total of multiple uploads", func(t *testing.T) {
+		uc, dir := newQuotaUsecase(t, newFakeRepository(), 100, 0)
+
+		_, err := uc.UploadMany(ctx, "user-1", []*multipart.FileHeader{
+			fileHeader(t, "a.txt", content),
+			fileHeader(t, "b.txt", content),
+			fileHeader(t, "c.txt", content),
+		})
+
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.Empty(t, storedFiles(t, dir))
+	})
+
+	t.Run("should reserve the size of pending and resumable uploads", func(t *testing.T) {
+		uc, _ := newQuotaUsecase(t, newFakeRepository(), 100, 0)
+
+		_, err := uc.PresignUpload(ctx, "user-1", &PresignRequest{
+			Filename: "a.txt", Size: int64(len(content)), MimeType: "text/plain", Checksum: hex.EncodeToString(sum[:]),
+		})
+		require.NoError(t, err)
+		_, err = uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "b.txt", Size: int64(len(content))})
+		require.NoError(t, err)
+
+		_, err = uc.CreateResumable(ctx, "user-1", &ResumableRequest{Filename: "c.txt", Size: int64(len(content))})
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+		usage, err := uc.GetUsage(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(80), usage.User.Bytes)
+		assert.Equal(t, int64(1), usage.User.Files)
+		assert.Equal(t, int64(20), usage.User.Remaining())
+		assert.Nil(t, usage.Tenant)
+	})
+
+	t.Run("should share the tenant quota between its users", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc, _ := newQuotaUsecase(t, repo, 0, 100)
+		tenantCtx := context.WithValue(ctx, constants.ContextKeyStoreID, "store-1")
+
+		_, err := uc.Upload(tenantCtx, "user-1", fileHeader(t, "a.txt", content))
+		require.NoError(t, err)
+		_, err = uc.Upload(tenantCtx, "user-2", fileHeader(t, "b.txt", content))
+		require.NoError(t, err)
+
+		_, err = uc.Upload(tenantCtx, "user-3", fileHeader(t, "c.txt", content))
+		assert.ErrorIs(t, err, ErrTenantQuotaExceeded)
+
+		usage, err := uc.GetUsage(tenantCtx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "store-1", usage.TenantID)
+		require.NotNil(t, usage.Tenant)
+		assert.Equal(t, int64(80), usage.Tenant.Bytes)
+		assert.Equal(t, int64(-1), usage.User.Remaining(), "the user quota is unlimited")
+	})
+
+	t.Run("should free the quota of deleted files", func(t *testing.T) {
+		uc, _ := newQuotaUsecase(t, newFakeRepository(), 50, 0)
+
+		created, err := uc.Upload(ctx, "user-1", fileHeader(t, "a.txt", content))
+		require.NoError(t, err)
+		require.NoError(t, uc.Delete(ctx, "user-1", created.ID))
+
+		_, err = uc.Upload(ctx, "user-1", fileHeader(t, "b.txt", content))
+		assert.NoError(t, err)
+	})
+}
+
+func TestUsecase_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello world")
+	sum := sha256.Sum256(content)
+
+	repo := newFakeRepository()
+	uc, dir := newQuotaUsecase(t, repo, 0, 0)
+
+	ready, err := uc.Upload(ctx, "user-1", fileHeader(t, "ready.txt", content))
+	require.NoError(t, err)
+	grown, err := uc.Upload(ctx, "user-1", fileHeader(t, "grown.txt", content))
+	require.NoError(t, err)
+	missing, err := uc.Upload(ctx, "user-1", fileHeader(t, "missing.txt", content))
+	require.NoError(t, err)
+	presign := func() *PresignedFile {
+		presigned, err := uc.PresignUpload(ctx, "user-1", &PresignRequest{
+			Filename: "pending.txt", Size: int64(len(content)), MimeType: "text/plain", Checksum: hex.EncodeToString(sum[:]),
+		})
+		require.NoError(t, err)
+		return presigned
+	}
+	expired, pending := presign(), presign()
+	repo.items[expired.File.ID].CreatedAt = time.Now().Add(-time.Hour)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, grown.Path), bytes.Repeat(content, 2), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, missing.Path)))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, uploadPath, "orphan.txt"), []byte("orphan"), 0644))
+
+	result, err := uc.Reconcile(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{Checked: 5, Updated: 1, Missing: 1, Released: 1, Orphans: 1, OrphanBytes: 6}, result)
+
+	t.Run("should correct the stored size from the listing", func(t *testing.T) {
+		assert.Equal(t, int64(2*len(content)), repo.items[grown.ID].StoredSize)
+		assert.Equal(t, int64(len(content)), repo.items[ready.ID].StoredSize)
+	})
+
+	t.Run("should keep counting missing files", func(t *testing.T) {
+		assert.Equal(t, int64(len(content)), repo.items[missing.ID].StoredSize)
+	})
+
+	t.Run("should release expired pending files only", func(t *testing.T) {
+		assert.NotContains(t, repo.items, expired.File.ID)
+		assert.Contains(t, repo.items, pending.File.ID)
+	})
+}
+
+// idStorage addresses files by an ID like Drive does, their path is only the name it lists
+type idStorage struct {
+	*filesystem.MemoryStorage
+	names map[string]string // ID to path in the memory storage
+}
+
+func newIDStorage() *idStorage {
+	return &idStorage{MemoryStorage: filesystem.NewMemoryStorage(), names: make(map[string]string)}
+}
+
+func (s *idStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts filesystem.UploadOptions) (*filesystem.UploadResult, error) {
+	return s.identify(s.MemoryStorage.Upload(ctx, file, opts))
+}
+
+func (s *idStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts filesystem.UploadOptions) (*filesystem.UploadResult, error) {
+	return s.identify(s.MemoryStorage.UploadFromReader(ctx, reader, filename, opts))
+}
+
+func (s *idStorage) identify(result *filesystem.UploadResult, err error) (*filesystem.UploadResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	id := fmt.Sprintf("id-%d", len(s.names)+1)
+	s.names[id] = result.Path
+	result.Path, result.Driver = id, filesystem.DriverDrive
+	return result, nil
+}
+
+func (s *idStorage) List(ctx context.Context, prefix string) ([]filesystem.FileInfo, error) {
+	listing, err := s.MemoryStorage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i := range listing {
+		for id, name := range s.names {
+			if name == listing[i].Path {
+				listing[i].Path, listing[i].Name = id, name
+			}
+		}
+	}
+	return listing, nil
+}
+
+func (s *idStorage) Stat(ctx context.Context, id string) (*filesystem.FileInfo, error) {
+	name, ok := s.names[id]
+	if !ok {
+		return nil, filesystem.ErrFileNotFound
+	}
+	info, err := s.MemoryStorage.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	info.Path, info.Name = id, name
+	return info, nil
+}
+
+func (s *idStorage) GetDriver() filesystem.Driver {
+	return filesystem.DriverDrive
+}
+
+func TestUsecase_ReconcileByID(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello world")
+
+	repo := newFakeRepository()
+	storage := newIDStorage()
+	uc := NewUseCase(repo, filesystem.NewManager(storage), Options{MaxFileSize: 1024, StagingPath: t.TempDir()})
+
+	uploaded, err := uc.Upload(ctx, "user-1", fileHeader(t, "hello.txt", content))
+	require.NoError(t, err)
+	require.Equal(t, "id-1", uploaded.Path)
+
+	// Named outside the upload folder, so the listing misses it
+	renamed, err := uc.Upload(ctx, "user-1", fileHeader(t, "renamed.txt", content))
+	require.NoError(t, err)
+	_, err = storage.MemoryStorage.Move(ctx, storage.names[renamed.Path], "renamed.txt")
+	require.NoError(t, err)
+	storage.names[renamed.Path] = "renamed.txt"
+
+	result, err := uc.Reconcile(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{Checked: 2}, result, "files are found by their ID")
+	assert.Equal(t, int64(len(content)), repo.items[uploaded.ID].StoredSize)
+	assert.Equal(t, int64(len(content)), repo.items[renamed.ID].StoredSize)
+}
+
+func TestUsecase_FileDisk(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	publicDir, privateDir := t.TempDir(), t.TempDir()
+	private := filesystem.NewLocalStorage(privateDir, "http://private.example.com")
+	storage := filesystem.NewManager(filesystem.NewLocalStorage(publicDir, "http://public.example.com")).
+		WithDisk("private", filesystem.NewManager(private))
+	uc := NewUseCase(repo, storage, Options{MaxFileSize: 1024, StagingPath: t.TempDir()})
+
+	// Both disks use the same driver, only the recorded disk tells them apart
+	stored, err := private.UploadFromReader(ctx, bytes.NewReader([]byte("hello")), "hello.txt", filesystem.UploadOptions{Path: uploadPath})
+	require.NoError(t, err)
+	created, err := repo.CreateFile(ctx, &File{OwnerID: "user-1", Disk: "private", Driver: string(filesystem.DriverLocal), Path: stored.Path, Status: StatusReady})
+	require.NoError(t, err)
+
+	t.Run("should record the disk of uploads", func(t *testing.T) {
+		uploaded, err := uc.Upload(ctx, "user-1", fileHeader(t, "upload.txt", []byte("hello")))
+
+		require.NoError(t, err)
+		assert.Equal(t, filesystem.DefaultDisk, uploaded.Disk)
+	})
+
+	t.Run("should resolve URLs on the disk of the file", func(t *testing.T) {
+		got, err := uc.GetByID(ctx, "user-1", created.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "http://private.example.com/"+stored.Path, got.URL)
+	})
+
+	t.Run("should delete from the disk of the file", func(t *testing.T) {
+		require.NoError(t, uc.Delete(ctx, "user-1", created.ID))
+
+		assert.NoFileExists(t, filepath.Join(privateDir, stored.Path))
+	})
+
+	t.Run("should keep files of an unknown disk", func(t *testing.T) {
+		lost, err := repo.CreateFile(ctx, &File{OwnerID: "user-1", Disk: "removed", Path: "files/lost.txt", Status: StatusReady})
+		require.NoError(t, err)
+
+		got, err := uc.GetByID(ctx, "user-1", lost.ID)
+
+		require.NoError(t, err)
+		assert.Empty(t, got.URL)
+	})
+}
+
+func TestUsecase_ReconcileDisks(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello world")
+
+	storage, err := filesystem.NewManagerFromConfig(ctx, filesystem.Config{
+		Driver: filesystem.DriverMemory,
+		Mirror: "backup",
+		Disks: map[string]filesystem.DiskConfig{
+			"private": {Driver: filesystem.DriverMemory},
+			"backup":  {Driver: filesystem.DriverMemory},
+		},
+	})
+	require.NoError(t, err)
+	private, err := storage.Disk("private")
+	require.NoError(t, err)
+
+	repo := newFakeRepository()
+	uc := NewUseCase(repo, storage, Options{MaxFileSize: 1024, StagingPath: t.TempDir()})
+
+	public, err := uc.Upload(ctx, "user-1", fileHeader(t, "public.txt", content))
+	require.NoError(t, err)
+	stored, err := private.UploadFromReader(ctx, bytes.NewReader(bytes.Repeat(content, 2)), "private.txt", filesystem.UploadOptions{Path: uploadPath})
+	require.NoError(t, err)
+	document, err := repo.CreateFile(ctx, &File{OwnerID: "user-1", Disk: "private", Path: stored.Path, Size: int64(len(content)), StoredSize: int64(len(content)), Status: StatusReady})
+	require.NoError(t, err)
+
+	result, err := uc.Reconcile(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, &ReconcileResult{Checked: 2, Updated: 1}, result, "the mirror copy is no orphan")
+	assert.Equal(t, int64(len(content)), repo.items[public.ID].StoredSize)
+	assert.Equal(t, int64(2*len(content)), repo.items[document.ID].StoredSize)
+}
@@ -1,11 +1,31 @@
 package foo
 
+import (
+	"strings"
+
+	"goilerplate/pkg/utils"
+)
+
 type Foo struct {
 	ID   string
 	Code string
 	Foo  string
 }
 
+func (e *Foo) validate() error {
+	code := strings.TrimSpace(e.Code)
+	if code == "" {
+		return utils.ClientErr(400, "code is required")
+	}
+	if len(code) < 3 {
+		return utils.ClientErr(400, "code must be at least 3 characters")
+	}
+	if strings.TrimSpace(e.Foo) == "" {
+		return utils.ClientErr(400, "foo is required")
+	}
+	return nil
+}
+
 func (e *Foo) Clone() *Foo {
 	return &Foo{
 		ID:   e.ID,
@@ -1,6 +1,7 @@
 package foo
 
 import (
+	"goilerplate/pkg/listquery"
 	"goilerplate/pkg/pagination"
 )
 
@@ -8,5 +9,17 @@ type Filter struct {
 	Keyword string
 	Code    string
 
+	Query      *listquery.Query
 	Pagination *pagination.PaginationRequest
 }
+
+// ListAllowlist lists the fields clients may sort and filter foos by
+var ListAllowlist = listquery.Allowlist{
+	Fields: map[string]listquery.Field{
+		"code":       {Column: "code", Ops: listquery.StringOps, Sortable: true},
+		"foo":        {Column: "foo", Ops: listquery.StringOps, Sortable: true},
+		"created_at": {Column: "created_at", Type: listquery.TypeTime, Ops: listquery.TimeOps, Sortable: true},
+		"updated_at": {Column: "updated_at", Type: listquery.TypeTime, Ops: listquery.TimeOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
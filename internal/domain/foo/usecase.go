@@ -2,11 +2,13 @@ package foo
 
 import (
 	"context"
+	"fmt"
+	"strings"
 )
 
 type Usecase interface {
-	Create(ctx context.Context, entity *Foo) error
-	Update(ctx context.Context, entity *Foo) error
+	Create(ctx context.Context, entity *Foo) (*Foo, error)
+	Update(ctx context.Context, entity *Foo) (*Foo, error)
 	Delete(ctx context.Context, entity *Foo) error
 
 	GetByID(ctx context.Context, id string) (*Foo, error)
@@ -25,34 +27,120 @@ func NewUseCase(repo Repository) Usecase {
 	}
 }
 
-func (uc *usecase) Create(ctx context.Context, entity *Foo) error {
-	panic("Implement me")
-}
+func (uc *usecase) Create(ctx context.Context, entity *Foo) (*Foo, error) {
+	if err := entity.validate(); err != nil {
+		return nil, err
+	}
+
+	// Code uniqueness is enforced by the database, the repository returns ErrCodeAlreadyExists
+	entity.Code = strings.ToUpper(strings.TrimSpace(entity.Code))
+	entity.Foo = strings.TrimSpace(entity.Foo)
+
+	created, err := uc.repo.CreateFoo(ctx, entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create foo: %w", err)
+	}
 
-func (uc *usecase) ExistsByCode(ctx context.Context, code string) (bool, error) {
-	panic("Implement me")
+	return created, nil
 }
 
-func (uc *usecase) Update(ctx context.Context, entity *Foo) error {
-	panic("Implement me")
+func (uc *usecase) Update(ctx context.Context, entity *Foo) (*Foo, error) {
+	if err := entity.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.repo.GetFooByID(ctx, entity.ID); err != nil {
+		return nil, fmt.Errorf("failed to get existing foo: %w", err)
+	}
+
+	entity.Code = strings.ToUpper(strings.TrimSpace(entity.Code))
+	entity.Foo = strings.TrimSpace(entity.Foo)
+
+	if err := uc.repo.UpdateFoo(ctx, entity); err != nil {
+		return nil, fmt.Errorf("failed to update foo: %w", err)
+	}
+
+	return entity, nil
 }
 
 func (uc *usecase) Delete(ctx context.Context, entity *Foo) error {
-	panic("Implement me")
+	existing, err := uc.repo.GetFooByID(ctx, entity.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get foo: %w", err)
+	}
+
+	if err = uc.repo.DeleteFoo(ctx, existing); err != nil {
+		return fmt.Errorf("failed to delete foo: %w", err)
+	}
+
+	return nil
 }
 
 func (uc *usecase) GetByID(ctx context.Context, id string) (*Foo, error) {
-	panic("Implement me")
+	foo, err := uc.repo.GetFooByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get foo: %w", err)
+	}
+
+	return foo, nil
 }
 
 func (uc *usecase) GetList(ctx context.Context, filter *Filter) ([]*Foo, int64, error) {
-	panic("Implement me")
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	if filter.Keyword != "" {
+		filter.Keyword = strings.TrimSpace(filter.Keyword)
+	}
+
+	foos, err := uc.repo.GetFooList(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get foos: %w", err)
+	}
+
+	total, err := uc.repo.CountFoo(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count foos: %w", err)
+	}
+
+	return foos, total, nil
 }
 
 func (uc *usecase) Count(ctx context.Context, filter *Filter) (int64, error) {
-	panic("Implement me")
+	if filter == nil {
+		filter = &Filter{}
+	}
+
+	count, err := uc.repo.CountFoo(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count foos: %w", err)
+	}
+
+	return count, nil
 }
 
 func (uc *usecase) BulkCreate(ctx context.Context, entities []*Foo) error {
-	panic("Implement me")
+	codes := make(map[string]bool)
+	for i, entity := range entities {
+		if err := entity.validate(); err != nil {
+			return fmt.Errorf("validation failed for entity %d: %w", i, err)
+		}
+
+		code := strings.ToUpper(strings.TrimSpace(entity.Code))
+		if codes[code] {
+			return fmt.Errorf("duplicate code '%s' in batch", code)
+		}
+		codes[code] = true
+
+		entity.Code = code
+		entity.Foo = strings.TrimSpace(entity.Foo)
+	}
+
+	// Bulk create, codes taken in the database fail the batch with ErrCodeAlreadyExists
+	if err := uc.repo.BulkCreate(ctx, entities); err != nil {
+		return fmt.Errorf("failed to bulk create foos: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,180 @@
+package foo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository is an in-memory Repository used to exercise the usecase
+type fakeRepository struct {
+	items map[string]*Foo
+	seq   int
+}
+
+func newFakeRepository(codes ...string) *fakeRepository {
+	r := &fakeRepository{items: make(map[string]*Foo)}
+	for _, code := range codes {
+		_, _ = r.CreateFoo(context.Background(), &Foo{Code: code, Foo: "seed"})
+	}
+	return r
+}
+
+func (r *fakeRepository) WithTx(ctx context.Context) Repository { return r }
+
+// codeTaken emulates the unique constraint on code, which the real repository
+// translates into ErrCodeAlreadyExists
+func (r *fakeRepository) codeTaken(code, id string) bool {
+	for _, item := range r.items {
+		if item.Code == code && item.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRepository) CreateFoo(ctx context.Context, entity *Foo) (*Foo, error) {
+	if r.codeTaken(entity.Code, "") {
+		return nil, ErrCodeAlreadyExists
+	}
+	r.seq++
+	created := entity.Clone()
+	created.ID = fmt.Sprintf("id-%d", r.seq)
+	r.items[created.ID] = created
+	return created.Clone(), nil
+}
+
+func (r *fakeRepository) UpdateFoo(ctx context.Context, entity *Foo) error {
+	if _, ok := r.items[entity.ID]; !ok {
+		return ErrNotFound
+	}
+	if r.codeTaken(entity.Code, entity.ID) {
+		return ErrCodeAlreadyExists
+	}
+	r.items[entity.ID] = entity.Clone()
+	return nil
+}
+
+func (r *fakeRepository) DeleteFoo(ctx context.Context, entity *Foo) error {
+	delete(r.items, entity.ID)
+	return nil
+}
+
+func (r *fakeRepository) BulkCreate(ctx context.Context, entities []*Foo) error {
+	for _, entity := range entities {
+		created, err := r.CreateFoo(ctx, entity)
+		if err != nil {
+			return err
+		}
+		entity.ID = created.ID
+	}
+	return nil
+}
+
+func (r *fakeRepository) CountFoo(ctx context.Context, filter *Filter) (int64, error) {
+	list, err := r.GetFooList(ctx, filter)
+	return int64(len(list)), err
+}
+
+func (r *fakeRepository) GetFooList(ctx context.Context, filter *Filter) ([]*Foo, error) {
+	var out []*Foo
+	for _, item := range r.items {
+		if filter.Code == "" || item.Code == filter.Code {
+			out = append(out, item.Clone())
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepository) GetFooByID(ctx context.Context, id string) (*Foo, error) {
+	item, ok := r.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return item.Clone(), nil
+}
+
+func TestUsecase_Create(t *testing.T) {
+	t.Run("should normalize the code and foo", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc := NewUseCase(repo)
+
+		created, err := uc.Create(context.Background(), &Foo{Code: " exp-a ", Foo: " first "})
+
+		require.NoError(t, err)
+		assert.Equal(t, "EXP-A", created.Code)
+		assert.Equal(t, "first", created.Foo)
+		assert.Contains(t, repo.items, created.ID)
+	})
+
+	t.Run("should validate the foo", func(t *testing.T) {
+		repo := newFakeRepository()
+		uc := NewUseCase(repo)
+
+		_, err := uc.Create(context.Background(), &Foo{Code: "ab", Foo: "first"})
+
+		assert.Error(t, err)
+		assert.Empty(t, repo.items)
+	})
+
+	t.Run("should reject a taken code", func(t *testing.T) {
+		uc := NewUseCase(newFakeRepository("EXP-A"))
+
+		_, err := uc.Create(context.Background(), &Foo{Code: "exp-a", Foo: "again"})
+
+		assert.ErrorIs(t, err, ErrCodeAlreadyExists)
+	})
+}
+
+func TestUsecase_Update(t *testing.T) {
+	t.Run("should write the normalized foo", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+
+		updated, err := uc.Update(context.Background(), &Foo{ID: "id-1", Code: "exp-b", Foo: " renamed "})
+
+		require.NoError(t, err)
+		assert.Equal(t, "EXP-B", updated.Code)
+		assert.Equal(t, "renamed", repo.items["id-1"].Foo)
+	})
+
+	t.Run("should reject the code of another foo", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A", "EXP-B")
+		uc := NewUseCase(repo)
+
+		_, err := uc.Update(context.Background(), &Foo{ID: "id-1", Code: "EXP-B", Foo: "renamed"})
+
+		assert.ErrorIs(t, err, ErrCodeAlreadyExists)
+		assert.Equal(t, "EXP-A", repo.items["id-1"].Code)
+	})
+
+	t.Run("should not update a missing foo", func(t *testing.T) {
+		uc := NewUseCase(newFakeRepository())
+
+		_, err := uc.Update(context.Background(), &Foo{ID: "id-1", Code: "EXP-A", Foo: "renamed"})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestUsecase_Delete(t *testing.T) {
+	t.Run("should delete the foo", func(t *testing.T) {
+		repo := newFakeRepository("EXP-A")
+		uc := NewUseCase(repo)
+
+		require.NoError(t, uc.Delete(context.Background(), &Foo{ID: "id-1"}))
+
+		assert.Empty(t, repo.items)
+	})
+
+	t.Run("should not delete a missing foo", func(t *testing.T) {
+		uc := NewUseCase(newFakeRepository())
+
+		err := uc.Delete(context.Background(), &Foo{ID: "id-1"})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -9,6 +9,7 @@ type Bar struct {
 	Code      string     `gorm:"column:code"`
 	Bar       string     `gorm:"column:bar"`
 	IsActive  bool       `gorm:"column:is_active"`
+	Version   int64      `gorm:"column:version;default:1"`
 	CreatedBy string     `gorm:"column:created_by"`
 	UpdatedBy string     `gorm:"column:updated_by"`
 	DeletedBy *string    `gorm:"column:deleted_by"`
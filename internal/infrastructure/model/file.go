@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+type File struct {
+	ID           string     `gorm:"primaryKey;default:gen_random_uuid()"`
+	OwnerID      string     `gorm:"column:owner_id"`
+	TenantID     string     `gorm:"column:tenant_id"`
+	Disk         string     `gorm:"column:disk"`
+	Driver       string     `gorm:"column:driver"`
+	Path         string     `gorm:"column:path"`
+	OriginalName string     `gorm:"column:original_name"`
+	Size         int64      `gorm:"column:size"`
+	MimeType     string     `gorm:"column:mime_type"`
+	Checksum     string     `gorm:"column:checksum"`
+	Status       string     `gorm:"column:status;default:ready"`
+	Variants     string     `gorm:"column:variants;type:jsonb"`
+	StoredSize   int64      `gorm:"column:stored_size"`
+	CreatedBy    string     `gorm:"column:created_by"`
+	UpdatedBy    string     `gorm:"column:updated_by"`
+	DeletedBy    *string    `gorm:"column:deleted_by"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at"`
+	DeletedAt    *time.Time `gorm:"column:deleted_at"`
+}
+
+func (File) TableName() string {
+	return "files"
+}
+
+// FileVariant is an element of File.Variants
+type FileVariant struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	MimeType string `json:"mimeType"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+}
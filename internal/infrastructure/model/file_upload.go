@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+type FileUpload struct {
+	ID           string     `gorm:"primaryKey"`
+	OwnerID      string     `gorm:"column:owner_id"`
+	TenantID     string     `gorm:"column:tenant_id"`
+	Filename     string     `gorm:"column:filename"`
+	MimeType     string     `gorm:"column:mime_type"`
+	Size         int64      `gorm:"column:size"`
+	Offset       int64      `gorm:"column:upload_offset"`
+	Staged       string     `gorm:"column:staged;type:jsonb"`
+	HashState    []byte     `gorm:"column:hash_state"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at"`
+	ClaimedUntil *time.Time `gorm:"column:claimed_until"`
+	CreatedBy    string     `gorm:"column:created_by"`
+	UpdatedBy    string     `gorm:"column:updated_by"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at"`
+}
+
+func (FileUpload) TableName() string {
+	return "file_uploads"
+}
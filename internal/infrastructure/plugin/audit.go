@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"reflect"
+
+	auditctx "goilerplate/internal/infrastructure/context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	columnCreatedBy = "created_by"
+	columnUpdatedBy = "updated_by"
+	columnDeletedAt = "deleted_at"
+	columnDeletedBy = "deleted_by"
+
+	// softDeleteScopedKey marks a statement that already carries the soft-delete condition
+	softDeleteScopedKey = "audit:soft_delete_scoped"
+)
+
+// Audit is a GORM plugin that takes care of audit columns and soft deletes
+// for every model that declares them:
+//   - created_by / updated_by are filled from the request context (auditctx.GetUserID)
+//   - queries, updates and deletes only see rows where deleted_at IS NULL
+//   - Delete is converted into an UPDATE of deleted_at / deleted_by
+//
+// Use db.Unscoped() to bypass the soft-delete scope (e.g. trash listing, restore, purge).
+// created_at / updated_at are handled by GORM's autoCreateTime / autoUpdateTime using db.NowFunc.
+type Audit struct{}
+
+// NewAudit creates a new audit plugin
+func NewAudit() *Audit {
+	return &Audit{}
+}
+
+// Name implements gorm.Plugin
+func (p *Audit) Name() string {
+	return "goilerplate:audit"
+}
+
+// Initialize implements gorm.Plugin
+func (p *Audit) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("audit:before_create", p.beforeCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:before_update", p.beforeUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("audit:soft_delete", p.softDelete); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register("audit:query_scope", p.queryScope); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register("audit:row_scope", p.queryScope)
+}
+
+// beforeCreate fills created_by and updated_by when they are not set explicitly
+func (p *Audit) beforeCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SkipHooks {
+		return
+	}
+
+	userID := auditctx.GetUserID(stmt.Context)
+	fields := lookUpFields(stmt.Schema, columnCreatedBy, columnUpdatedBy)
+	if len(fields) == 0 {
+		return
+	}
+
+	fillIfZero := func(rv reflect.Value) {
+		for _, field := range fields {
+			if _, isZero := field.ValueOf(stmt.Context, rv); isZero {
+				_ = db.AddError(field.Set(stmt.Context, rv, userID))
+			}
+		}
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			rv := reflect.Indirect(stmt.ReflectValue.Index(i))
+			if rv.Kind() == reflect.Struct {
+				fillIfZero(rv)
+			}
+		}
+	case reflect.Struct:
+		fillIfZero(stmt.ReflectValue)
+	}
+}
+
+// beforeUpdate stamps updated_by and scopes the update to non-deleted rows
+func (p *Audit) beforeUpdate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+
+	if !stmt.SkipHooks {
+		if field := stmt.Schema.LookUpField(columnUpdatedBy); field != nil {
+			if _, ok := stmt.Dest.(map[string]interface{}); ok || stmt.ReflectValue.CanAddr() {
+				stmt.SetColumn(field.DBName, auditctx.GetUserID(stmt.Context), true)
+			}
+		}
+	}
+
+	p.applySoftDeleteScope(stmt)
+}
+
+// softDelete rewrites a DELETE into an UPDATE of deleted_at / deleted_by.
+// The statement SQL is built here, so gorm:delete only executes it.
+func (p *Audit) softDelete(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.Unscoped || stmt.SQL.Len() > 0 {
+		return
+	}
+
+	deletedAt := stmt.Schema.LookUpField(columnDeletedAt)
+	if deletedAt == nil {
+		return
+	}
+
+	now := db.NowFunc()
+	set := clause.Set{{Column: clause.Column{Name: deletedAt.DBName}, Value: now}}
+	if deletedBy := stmt.Schema.LookUpField(columnDeletedBy); deletedBy != nil {
+		set = append(set, clause.Assignment{Column: clause.Column{Name: deletedBy.DBName}, Value: auditctx.GetUserID(stmt.Context)})
+	}
+	stmt.AddClause(set)
+
+	// Restrict to the primary keys of the value being deleted, mirroring gorm:delete
+	_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+	column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+	if len(values) > 0 {
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+	}
+
+	if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
+		_, queryValues = schema.GetIdentityFieldValuesMap(stmt.Context, reflect.ValueOf(stmt.Model), stmt.Schema.PrimaryFields)
+		column, values = schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if len(values) > 0 {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+		}
+	}
+
+	p.applySoftDeleteScope(stmt)
+
+	stmt.AddClauseIfNotExists(clause.Update{})
+	stmt.Build(db.Callback().Update().Clauses...)
+}
+
+// queryScope hides soft-deleted rows from SELECT / COUNT / Row queries
+func (p *Audit) queryScope(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	p.applySoftDeleteScope(db.Statement)
+}
+
+// applySoftDeleteScope adds "<table>.deleted_at IS NULL" unless the statement is unscoped
+func (p *Audit) applySoftDeleteScope(stmt *gorm.Statement) {
+	if stmt.Unscoped {
+		return
+	}
+	if _, ok := stmt.Settings.Load(softDeleteScopedKey); ok {
+		return
+	}
+
+	deletedAt := stmt.Schema.LookUpField(columnDeletedAt)
+	if deletedAt == nil {
+		return
+	}
+
+	// Wrap existing OR conditions so the soft-delete condition applies to all of them
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
+			for _, expr := range where.Exprs {
+				if orCond, ok := expr.(clause.OrConditions); ok && len(orCond.Exprs) == 1 {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					c.Expression = where
+					stmt.Clauses["WHERE"] = c
+					break
+				}
+			}
+		}
+	}
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: deletedAt.DBName}, Value: nil},
+	}})
+	stmt.Settings.Store(softDeleteScopedKey, true)
+}
+
+func lookUpFields(s *schema.Schema, names ...string) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(names))
+	for _, name := range names {
+		if field := s.LookUpField(name); field != nil {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
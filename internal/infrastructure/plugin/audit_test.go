@@ -0,0 +1,163 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auditctx "goilerplate/internal/infrastructure/context"
+	"goilerplate/internal/infrastructure/model"
+	"goilerplate/internal/infrastructure/plugin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		NowFunc:                func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) },
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(plugin.NewAudit()))
+
+	return db
+}
+
+func TestAudit_Create(t *testing.T) {
+	db := newDryRunDB(t)
+
+	t.Run("should fill created_by and updated_by from context", func(t *testing.T) {
+		ctx := auditctx.WithAuditInfo(context.Background(), "user-1", "User One")
+		bar := &model.Bar{Code: "EXP1", Bar: "bar"}
+
+		require.NoError(t, db.WithContext(ctx).Create(bar).Error)
+
+		assert.Equal(t, "user-1", bar.CreatedBy)
+		assert.Equal(t, "user-1", bar.UpdatedBy)
+	})
+
+	t.Run("should fall back to system without a user in context", func(t *testing.T) {
+		bar := &model.Bar{Code: "EXP1", Bar: "bar"}
+
+		require.NoError(t, db.WithContext(context.Background()).Create(bar).Error)
+
+		assert.Equal(t, "system", bar.CreatedBy)
+	})
+
+	t.Run("should keep explicitly set values", func(t *testing.T) {
+		ctx := auditctx.WithAuditInfo(context.Background(), "user-1", "User One")
+		bar := &model.Bar{Code: "EXP1", Bar: "bar", CreatedBy: "importer"}
+
+		require.NoError(t, db.WithContext(ctx).Create(bar).Error)
+
+		assert.Equal(t, "importer", bar.CreatedBy)
+		assert.Equal(t, "user-1", bar.UpdatedBy)
+	})
+
+	t.Run("should fill every element of a batch", func(t *testing.T) {
+		ctx := auditctx.WithAuditInfo(context.Background(), "user-2", "User Two")
+		bars := []model.Bar{{Code: "EXP1"}, {Code: "EXP2"}}
+
+		require.NoError(t, db.WithContext(ctx).Create(&bars).Error)
+
+		for _, bar := range bars {
+			assert.Equal(t, "user-2", bar.CreatedBy)
+		}
+	})
+}
+
+func TestAudit_Update(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := auditctx.WithAuditInfo(context.Background(), "user-1", "User One")
+
+	t.Run("should stamp updated_by on map updates", func(t *testing.T) {
+		stmt := db.WithContext(ctx).Model(&model.Bar{ID: "id-1"}).Updates(map[string]interface{}{"bar": "new"}).Statement
+
+		assert.Contains(t, stmt.SQL.String(), `"updated_by"=`)
+		assert.Contains(t, stmt.Vars, "user-1")
+		assert.Contains(t, stmt.SQL.String(), `"bars"."deleted_at" IS NULL`)
+	})
+
+	t.Run("should stamp updated_by on save", func(t *testing.T) {
+		bar := &model.Bar{ID: "id-1", Code: "EXP1", Bar: "bar", CreatedBy: "someone"}
+
+		require.NoError(t, db.WithContext(ctx).Save(bar).Error)
+
+		assert.Equal(t, "user-1", bar.UpdatedBy)
+	})
+}
+
+func TestAudit_SoftDelete(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := auditctx.WithAuditInfo(context.Background(), "user-1", "User One")
+
+	t.Run("should convert delete into update", func(t *testing.T) {
+		stmt := db.WithContext(ctx).Delete(&model.Bar{ID: "id-1"}).Statement
+		sql := stmt.SQL.String()
+
+		assert.Contains(t, sql, `UPDATE "bars" SET "deleted_at"=`)
+		assert.Contains(t, sql, `"deleted_by"=`)
+		assert.Contains(t, sql, `"bars"."id" =`)
+		assert.Contains(t, sql, `"bars"."deleted_at" IS NULL`)
+		assert.Contains(t, stmt.Vars, "user-1")
+	})
+
+	t.Run("should hard delete when unscoped", func(t *testing.T) {
+		sql := db.WithContext(ctx).Unscoped().Delete(&model.Bar{ID: "id-1"}).Statement.SQL.String()
+
+		assert.Contains(t, sql, `DELETE FROM "bars"`)
+		assert.NotContains(t, sql, "deleted_at")
+	})
+
+	t.Run("should hard delete models without deleted_at", func(t *testing.T) {
+		sql := db.WithContext(ctx).Delete(&model.UserSession{ID: "id-1"}).Statement.SQL.String()
+
+		assert.Contains(t, sql, `DELETE FROM "user_sessions"`)
+	})
+}
+
+func TestAudit_QueryScope(t *testing.T) {
+	db := newDryRunDB(t)
+
+	t.Run("should hide soft-deleted rows", func(t *testing.T) {
+		var bars []model.Bar
+		sql := db.Where("code = ?", "EXP1").Find(&bars).Statement.SQL.String()
+
+		assert.Contains(t, sql, `"bars"."deleted_at" IS NULL`)
+	})
+
+	t.Run("should wrap OR conditions", func(t *testing.T) {
+		var bars []model.Bar
+		sql := db.Where("code = ?", "EXP1").Or("code = ?", "EXP2").Find(&bars).Statement.SQL.String()
+
+		assert.Contains(t, sql, `(code = $1 OR code = $2) AND "bars"."deleted_at" IS NULL`)
+	})
+
+	t.Run("should scope count queries", func(t *testing.T) {
+		var count int64
+		sql := db.Model(&model.Bar{}).Count(&count).Statement.SQL.String()
+
+		assert.Contains(t, sql, `"bars"."deleted_at" IS NULL`)
+	})
+
+	t.Run("should include soft-deleted rows when unscoped", func(t *testing.T) {
+		var bars []model.Bar
+		sql := db.Unscoped().Find(&bars).Statement.SQL.String()
+
+		assert.NotContains(t, sql, "deleted_at IS NULL")
+	})
+
+	t.Run("should not scope models without deleted_at", func(t *testing.T) {
+		var sessions []model.UserSession
+		sql := db.Find(&sessions).Statement.SQL.String()
+
+		assert.NotContains(t, sql, "deleted_at")
+	})
+}
@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"goilerplate/pkg/dberror"
+
+	"gorm.io/gorm"
+)
+
+// ErrorTranslator is a GORM plugin that translates driver errors of every statement with
+// dberror.Translate, so repositories return dberror.ErrConflict, ErrForeignKey,
+// ErrConstraint and ErrRetryable instead of raw pgx / MySQL errors.
+type ErrorTranslator struct{}
+
+// NewErrorTranslator creates a new error translator plugin
+func NewErrorTranslator() *ErrorTranslator {
+	return &ErrorTranslator{}
+}
+
+// Name implements gorm.Plugin
+func (p *ErrorTranslator) Name() string {
+	return "goilerplate:error_translator"
+}
+
+// Initialize implements gorm.Plugin
+func (p *ErrorTranslator) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().After("gorm:create").Register("error_translator:create", p.translate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("error_translator:update", p.translate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register("error_translator:delete", p.translate); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("error_translator:query", p.translate); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("error_translator:row", p.translate); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("gorm:raw").Register("error_translator:raw", p.translate)
+}
+
+func (p *ErrorTranslator) translate(db *gorm.DB) {
+	if db.Error != nil {
+		db.Error = dberror.Translate(db.Error)
+	}
+}
@@ -0,0 +1,28 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"goilerplate/internal/infrastructure/model"
+	"goilerplate/internal/infrastructure/plugin"
+	"goilerplate/pkg/dberror"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestErrorTranslator(t *testing.T) {
+	db := newDryRunDB(t)
+	require.NoError(t, db.Use(plugin.NewErrorTranslator()))
+
+	// Stand in for the driver, a dry run never reaches the database
+	require.NoError(t, db.Callback().Create().Before("error_translator:create").Register("test:unique_violation", func(tx *gorm.DB) {
+		_ = tx.AddError(&pgconn.PgError{Code: "23505", ConstraintName: "bars_code_key"})
+	}))
+
+	err := db.Create(&model.Bar{Code: "EXP1", Bar: "bar"}).Error
+
+	assert.ErrorIs(t, err, dberror.ErrConflict)
+}
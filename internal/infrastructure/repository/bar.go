@@ -2,85 +2,155 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"goilerplate/internal/domain/bar"
 	"goilerplate/internal/infrastructure/model"
 	"goilerplate/internal/infrastructure/transaction"
-	"goilerplate/pkg/constants"
+	"goilerplate/pkg/dberror"
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/listquery"
+	"goilerplate/pkg/pagination"
+	"goilerplate/pkg/search"
 	"goilerplate/pkg/utils"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// barListColumns are selected by list and get queries when the client selects no fields
+var barListColumns = []string{"id", "code", "bar"}
+
 type barRepo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search *search.Search
 }
 
-func NewBar(db *gorm.DB) bar.Repository {
+func NewBar(db *gorm.DB, searchCfg search.Config) bar.Repository {
 	return &barRepo{
-		db: db,
+		db:     db,
+		search: search.New(searchCfg, "code", "bar"),
 	}
 }
 
 func (r *barRepo) WithTx(ctx context.Context) bar.Repository {
 	tx := transaction.GetTxFromContext(ctx)
 	if tx != nil {
-		return &barRepo{db: tx}
+		return &barRepo{db: tx, search: r.search}
 	}
 	return r
 }
 
 func (r *barRepo) CreateBar(ctx context.Context, entity *bar.Bar) (*bar.Bar, error) {
-	now := utils.Now()
-	user := ctx.Value(constants.ContextKeyUserID).(string)
 	model := &model.Bar{
-		Code:      entity.Code,
-		Bar:       entity.Bar,
-		IsActive:  true,
-		CreatedAt: now,
-		UpdatedAt: now,
-		CreatedBy: user,
-		UpdatedBy: user,
+		Code:     entity.Code,
+		Bar:      entity.Bar,
+		IsActive: true,
+		Version:  1,
 	}
 
 	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		if errors.Is(err, dberror.ErrConflict) {
+			return nil, bar.ErrCodeAlreadyExists
+		}
 		return nil, utils.WrapErr(err)
 	}
 
 	return r.modelToEntity(model), nil
 }
 
+// UpdateBar updates code and bar only if the stored version is still the expected one
+// (entity.Version, or the loaded version when zero) and sets entity.Version to the new version
 func (r *barRepo) UpdateBar(ctx context.Context, entity *bar.Bar) error {
 	model, err := r.getBarByID(ctx, entity.ID)
 	if err != nil {
 		return err
 	}
 
-	model.Code = entity.Code
-	model.Bar = entity.Bar
-	model.UpdatedAt = utils.Now()
-	model.UpdatedBy = ctx.Value(constants.ContextKeyUserID).(string)
+	version := entity.Version
+	if version == 0 {
+		version = model.Version
+	}
 
-	if err = r.db.WithContext(ctx).Save(model).Error; err != nil {
-		return utils.WrapErr(err)
+	result := r.db.WithContext(ctx).
+		Model(model).
+		Where("version = ?", version).
+		Updates(map[string]interface{}{
+			"code":    entity.Code,
+			"bar":     entity.Bar,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		if errors.Is(result.Error, dberror.ErrConflict) {
+			return bar.ErrCodeAlreadyExists
+		}
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bar.ErrVersionMismatch
 	}
 
+	entity.Version = version + 1
+
 	return nil
 }
 
+// PatchBar writes only the given fields of entity, guarded by entity.Version like UpdateBar.
+// The audit plugin stamps updated_by / updated_at on the same statement.
+func (r *barRepo) PatchBar(ctx context.Context, entity *bar.Bar, fields []string) error {
+	updates := map[string]interface{}{
+		"version": gorm.Expr("version + 1"),
+	}
+	for _, field := range fields {
+		switch field {
+		case bar.FieldCode:
+			updates["code"] = entity.Code
+		case bar.FieldBar:
+			updates["bar"] = entity.Bar
+		default:
+			return utils.WrapErr(fmt.Errorf("unknown bar field %q", field))
+		}
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&model.Bar{}).
+		Where("id = ? AND version = ?", entity.ID, entity.Version).
+		Updates(updates)
+	if result.Error != nil {
+		if errors.Is(result.Error, dberror.ErrConflict) {
+			return bar.ErrCodeAlreadyExists
+		}
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bar.ErrVersionMismatch
+	}
+
+	entity.Version++
+
+	return nil
+}
+
+// DeleteBar soft deletes a bar, a non-zero entity.Version must match the stored version
 func (r *barRepo) DeleteBar(ctx context.Context, entity *bar.Bar) error {
 	model, err := r.getBarByID(ctx, entity.ID)
 	if err != nil {
 		return err
 	}
 
-	now := utils.Now()
-	user := ctx.Value(constants.ContextKeyUserID).(string)
-	model.DeletedAt = &now
-	model.DeletedBy = &user
+	query := r.db.WithContext(ctx)
+	if entity.Version > 0 {
+		query = query.Where("version = ?", entity.Version)
+	}
 
-	if err := r.db.WithContext(ctx).Save(model).Error; err != nil {
-		return utils.WrapErr(err)
+	result := query.Delete(model)
+	if result.Error != nil {
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bar.ErrVersionMismatch
 	}
 
 	return nil
@@ -96,12 +166,42 @@ func (r *barRepo) GetBarByID(ctx context.Context, id string) (*bar.Bar, error) {
 	return r.modelToEntity(model), nil
 }
 
+// GetCurrentBar is GetBarByID, decorators caching bars must not serve it
+func (r *barRepo) GetCurrentBar(ctx context.Context, id string) (*bar.Bar, error) {
+	return r.GetBarByID(ctx, id)
+}
+
+// GetBarView returns a bar with only the selected columns and the requested expansions,
+// the version is always selected for the ETag
+func (r *barRepo) GetBarView(ctx context.Context, id string, selection *fieldset.Selection) (*bar.Bar, error) {
+	var data model.Bar
+
+	err := r.db.WithContext(ctx).
+		Select(selection.Columns(barListColumns, "version")).
+		Where("id = ?", id).
+		First(&data).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, bar.ErrNotFound
+		}
+		return nil, utils.WrapErr(err)
+	}
+
+	entity := r.modelToEntity(&data)
+	if selection.Expands(bar.ExpandCreator) {
+		if err := r.loadCreators(ctx, []*bar.Bar{entity}); err != nil {
+			return nil, err
+		}
+	}
+
+	return entity, nil
+}
+
 func (r *barRepo) GetBarList(ctx context.Context, filter *bar.Filter) ([]*bar.Bar, error) {
 	var models []model.Bar
 
 	query := r.db.WithContext(ctx).
-		Select("id", "code", "bar").
-		Where("deleted_at IS NULL")
+		Select(filter.Selection.Columns(barListColumns))
 
 	r.applyBarFilters(query, filter, true) // true = apply pagination
 
@@ -115,15 +215,79 @@ func (r *barRepo) GetBarList(ctx context.Context, filter *bar.Filter) ([]*bar.Ba
 		entities[i] = r.modelToEntity(&model)
 	}
 
+	if filter.Selection.Expands(bar.ExpandCreator) {
+		if err := r.loadCreators(ctx, entities); err != nil {
+			return nil, err
+		}
+	}
+
 	return entities, nil
 }
 
+func (r *barRepo) GetBarListByCursor(ctx context.Context, filter *bar.Filter) ([]*bar.Bar, *pagination.CursorPage, error) {
+	query := r.db.WithContext(ctx).
+		Select(filter.Selection.Columns(barListColumns, filter.Query.KeysetColumns()...))
+
+	r.applyBarFilters(query, filter, false) // keyset pagination replaces sort and offset
+
+	models, page, err := listquery.FindPage[model.Bar](query, filter.Query, filter.Pagination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entities := make([]*bar.Bar, len(models))
+	for i, model := range models {
+		entities[i] = r.modelToEntity(&model)
+	}
+
+	if filter.Selection.Expands(bar.ExpandCreator) {
+		if err := r.loadCreators(ctx, entities); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return entities, page, nil
+}
+
+// StreamBars iterates the filtered and sorted bars row by row
+func (r *barRepo) StreamBars(ctx context.Context, filter *bar.Filter, fn func(*bar.Bar) error) error {
+	query := r.db.WithContext(ctx).
+		Model(&model.Bar{}).
+		Select("id", "code", "bar")
+
+	r.applyBarFilters(query, filter, false) // false = export everything
+	if filter != nil {
+		filter.Query.ApplySort(query)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return utils.WrapErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data model.Bar
+		if err := query.ScanRows(rows, &data); err != nil {
+			return utils.WrapErr(err)
+		}
+		if err := fn(r.modelToEntity(&data)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
 func (r *barRepo) CountBar(ctx context.Context, filter *bar.Filter) (int64, error) {
 	var count int64
 
 	query := r.db.WithContext(ctx).
-		Model(&model.Bar{}).
-		Where("deleted_at IS NULL")
+		Model(&model.Bar{})
 
 	r.applyBarFilters(query, filter, false) // false = don't apply pagination
 
@@ -139,42 +303,234 @@ func (r *barRepo) BulkCreate(ctx context.Context, entities []*bar.Bar) error {
 		return nil
 	}
 
-	now := utils.Now()
-	user := ctx.Value(constants.ContextKeyUserID).(string) // Use proper context key
-
 	models := make([]model.Bar, len(entities))
 	for i, entity := range entities {
 		models[i] = model.Bar{
-			Code:      entity.Code,
-			Bar:       entity.Bar,
-			IsActive:  true,
-			CreatedAt: now,
-			UpdatedAt: now,
-			CreatedBy: user,
-			UpdatedBy: user,
+			Code:     entity.Code,
+			Bar:      entity.Bar,
+			IsActive: true,
+			Version:  1,
 		}
 	}
 
 	if err := r.db.WithContext(ctx).Create(&models).
-		Select("code, bar, is_active, created_at, created_by, updated_at, updated_by").
+		Select("code, bar, is_active, version, created_at, created_by, updated_at, updated_by").
 		Error; err != nil {
+		if errors.Is(err, dberror.ErrConflict) {
+			return bar.ErrCodeAlreadyExists
+		}
+		return utils.WrapErr(err)
+	}
+
+	for i := range models {
+		entities[i].ID = models[i].ID
+		entities[i].Version = models[i].Version
+	}
+
+	return nil
+}
+
+// BulkUpdate updates code and bar of every entity in one transaction
+func (r *barRepo) BulkUpdate(ctx context.Context, entities []*bar.Bar) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, entity := range entities {
+			result := tx.Model(&model.Bar{ID: entity.ID}).Updates(map[string]interface{}{
+				"code":    entity.Code,
+				"bar":     entity.Bar,
+				"version": gorm.Expr("version + 1"),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return utils.ClientErr(404, "Bar not found")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var clientErr *utils.ClientError
+		if errors.As(err, &clientErr) {
+			return err
+		}
+		if errors.Is(err, dberror.ErrConflict) {
+			return bar.ErrCodeAlreadyExists
+		}
 		return utils.WrapErr(err)
 	}
 
 	return nil
 }
 
+// BulkDelete soft deletes the bars with the given ids in a single statement
+func (r *barRepo) BulkDelete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.Bar{}).Error; err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+func (r *barRepo) GetBarsByIDs(ctx context.Context, ids []string) ([]*bar.Bar, error) {
+	return r.getBarsWhere(ctx, "id IN ?", ids)
+}
+
+func (r *barRepo) GetBarsByCodes(ctx context.Context, codes []string) ([]*bar.Bar, error) {
+	return r.getBarsWhere(ctx, "code IN ?", codes)
+}
+
+func (r *barRepo) getBarsWhere(ctx context.Context, condition string, values []string) ([]*bar.Bar, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	var models []model.Bar
+	if err := r.db.WithContext(ctx).
+		Select("id", "code", "bar").
+		Where(condition, values).
+		Find(&models).Error; err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	entities := make([]*bar.Bar, len(models))
+	for i, model := range models {
+		entities[i] = r.modelToEntity(&model)
+	}
+
+	return entities, nil
+}
+
+// GetTrashList returns the soft deleted bars, most recently deleted first
+func (r *barRepo) GetTrashList(ctx context.Context, filter *bar.Filter) ([]*bar.Bar, error) {
+	var models []model.Bar
+
+	query := r.db.WithContext(ctx).
+		Unscoped().
+		Select("id", "code", "bar", "deleted_at", "deleted_by").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC")
+
+	r.applyBarFilters(query, filter, true) // true = apply pagination
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	entities := make([]*bar.Bar, len(models))
+	for i, model := range models {
+		entities[i] = r.modelToEntity(&model)
+	}
+
+	return entities, nil
+}
+
+func (r *barRepo) CountTrash(ctx context.Context, filter *bar.Filter) (int64, error) {
+	var count int64
+
+	query := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.Bar{}).
+		Where("deleted_at IS NOT NULL")
+
+	r.applyBarFilters(query, filter, false) // false = don't apply pagination
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, utils.WrapErr(err)
+	}
+
+	return count, nil
+}
+
+func (r *barRepo) GetDeletedBarByID(ctx context.Context, id string) (*bar.Bar, error) {
+	var data model.Bar
+
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&data).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, bar.ErrNotInTrash
+		}
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.modelToEntity(&data), nil
+}
+
+// RestoreBar clears deleted_at / deleted_by of a soft deleted bar
+func (r *barRepo) RestoreBar(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.Bar{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		if errors.Is(result.Error, dberror.ErrConflict) {
+			return bar.ErrCodeAlreadyExists
+		}
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bar.ErrNotInTrash
+	}
+
+	return nil
+}
+
+// HardDeleteBar permanently deletes a soft deleted bar
+func (r *barRepo) HardDeleteBar(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Delete(&model.Bar{})
+	if result.Error != nil {
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bar.ErrNotInTrash
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently deletes the bars soft deleted before the given time
+func (r *barRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at < ?", before).
+		Delete(&model.Bar{})
+	if result.Error != nil {
+		return 0, utils.WrapErr(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
 func (r *barRepo) getBarByID(ctx context.Context, id string) (*model.Bar, error) {
 
 	var data model.Bar
 
 	err := r.db.WithContext(ctx).
-		Where("id = ? and deleted_at IS NULL", id).
+		Where("id = ?", id).
 		First(&data).Error
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, utils.ClientErr(404, "Bar not found")
+			return nil, bar.ErrNotFound
 		}
 		return nil, utils.WrapErr(err)
 	}
@@ -182,29 +538,78 @@ func (r *barRepo) getBarByID(ctx context.Context, id string) (*model.Bar, error)
 	return &data, nil
 }
 
+// loadCreators sets Creator of every entity with a single users query. Bars created by
+// the system have no user, and deleted users are still shown.
+func (r *barRepo) loadCreators(ctx context.Context, entities []*bar.Bar) error {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, entity := range entities {
+		if _, err := uuid.Parse(entity.CreatedBy); err == nil && !seen[entity.CreatedBy] {
+			seen[entity.CreatedBy] = true
+			ids = append(ids, entity.CreatedBy)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var users []model.User
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Select("id", "name").
+		Where("id IN ?", ids).
+		Find(&users).Error
+	if err != nil {
+		return utils.WrapErr(err)
+	}
+
+	creators := make(map[string]*bar.Creator, len(users))
+	for _, user := range users {
+		creators[user.ID] = &bar.Creator{ID: user.ID, Name: user.Name}
+	}
+	for _, entity := range entities {
+		entity.Creator = creators[entity.CreatedBy]
+	}
+
+	return nil
+}
+
 func (r *barRepo) applyBarFilters(query *gorm.DB, filter *bar.Filter, applyPagination bool) {
 	if filter == nil {
 		return
 	}
 
-	if filter.Keyword != "" {
-		keyword := "%" + filter.Keyword + "%"
-		query.Where("code ILIKE ? OR bar ILIKE ?", keyword, keyword)
-	}
+	r.search.Apply(query, filter.Keyword)
 
 	if filter.Code != "" {
 		query.Where("code = ?", filter.Code)
 	}
 
+	filter.Query.ApplyFilters(query)
+
+	if applyPagination {
+		filter.Query.ApplySort(query)
+		if filter.Query.SortedByDefault() {
+			r.search.Rank(query, filter.Keyword) // relevance first, the default sort breaks ties
+		}
+	}
+
 	if applyPagination && filter.Pagination != nil {
 		query.Offset(filter.Pagination.GetOffset()).Limit(filter.Pagination.GetLimit())
 	}
 }
 
 func (r *barRepo) modelToEntity(model *model.Bar) *bar.Bar {
-	return &bar.Bar{
-		ID:   model.ID,
-		Code: model.Code,
-		Bar:  model.Bar,
+	entity := &bar.Bar{
+		ID:        model.ID,
+		Code:      model.Code,
+		Bar:       model.Bar,
+		Version:   model.Version,
+		CreatedBy: model.CreatedBy,
+		DeletedAt: model.DeletedAt,
+	}
+	if model.DeletedBy != nil {
+		entity.DeletedBy = *model.DeletedBy
 	}
+	return entity
 }
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"goilerplate/internal/domain/bar"
+	"goilerplate/internal/infrastructure/transaction"
+	"goilerplate/pkg/cache"
+	"goilerplate/pkg/fieldset"
+)
+
+// cachedBarRepo caches GetBarByID and GetBarView without expansions, and invalidates the
+// cached bars of every write once its transaction commits. Reads inside a transaction and
+// GetCurrentBar always go to the database, so version checks never see a stale row.
+type cachedBarRepo struct {
+	bar.Repository
+	cache *cache.Entity[bar.Bar]
+}
+
+// barCacheTTL bounds how long a bar cached by a load racing a write can be served
+const barCacheTTL = time.Minute
+
+// NewCachedBar decorates repo with a read-through cache of bars by id, the TTL defaults
+// to barCacheTTL
+func NewCachedBar(repo bar.Repository, store cache.Store, opts cache.EntityOptions) bar.Repository {
+	if opts.Prefix == "" {
+		opts.Prefix = "bar:"
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = barCacheTTL
+	}
+	opts.NotFound = bar.ErrNotFound
+
+	return &cachedBarRepo{
+		Repository: repo,
+		cache:      cache.NewEntity[bar.Bar](store, opts),
+	}
+}
+
+func (r *cachedBarRepo) WithTx(ctx context.Context) bar.Repository {
+	return &cachedBarRepo{Repository: r.Repository.WithTx(ctx), cache: r.cache}
+}
+
+func (r *cachedBarRepo) GetBarByID(ctx context.Context, id string) (*bar.Bar, error) {
+	if transaction.GetTxFromContext(ctx) != nil {
+		return r.Repository.GetBarByID(ctx, id)
+	}
+
+	return r.cache.Get(ctx, id, func(ctx context.Context) (*bar.Bar, error) {
+		return r.Repository.GetBarByID(ctx, id)
+	})
+}
+
+// GetBarView serves views without expansions from the cached bar, the caller projects the
+// selected fields. Expansions join other tables and are always read from the database.
+func (r *cachedBarRepo) GetBarView(ctx context.Context, id string, selection *fieldset.Selection) (*bar.Bar, error) {
+	if selection != nil && len(selection.Expand) > 0 {
+		return r.Repository.GetBarView(ctx, id, selection)
+	}
+
+	return r.GetBarByID(ctx, id)
+}
+
+// GetCurrentBar bypasses the cache, a read-through load racing a write may have cached
+// the previous version
+func (r *cachedBarRepo) GetCurrentBar(ctx context.Context, id string) (*bar.Bar, error) {
+	return r.Repository.GetCurrentBar(ctx, id)
+}
+
+func (r *cachedBarRepo) CreateBar(ctx context.Context, entity *bar.Bar) (*bar.Bar, error) {
+	created, err := r.Repository.CreateBar(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	// The id may have been cached as not found
+	r.invalidate(ctx, created.ID)
+
+	return created, nil
+}
+
+func (r *cachedBarRepo) UpdateBar(ctx context.Context, entity *bar.Bar) error {
+	if err := r.Repository.UpdateBar(ctx, entity); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, entity.ID)
+
+	return nil
+}
+
+func (r *cachedBarRepo) PatchBar(ctx context.Context, entity *bar.Bar, fields []string) error {
+	if err := r.Repository.PatchBar(ctx, entity, fields); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, entity.ID)
+
+	return nil
+}
+
+func (r *cachedBarRepo) DeleteBar(ctx context.Context, entity *bar.Bar) error {
+	if err := r.Repository.DeleteBar(ctx, entity); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, entity.ID)
+
+	return nil
+}
+
+func (r *cachedBarRepo) BulkCreate(ctx context.Context, entities []*bar.Bar) error {
+	if err := r.Repository.BulkCreate(ctx, entities); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, barIDs(entities)...)
+
+	return nil
+}
+
+func (r *cachedBarRepo) BulkUpdate(ctx context.Context, entities []*bar.Bar) error {
+	if err := r.Repository.BulkUpdate(ctx, entities); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, barIDs(entities)...)
+
+	return nil
+}
+
+func (r *cachedBarRepo) BulkDelete(ctx context.Context, ids []string) error {
+	if err := r.Repository.BulkDelete(ctx, ids); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, ids...)
+
+	return nil
+}
+
+func (r *cachedBarRepo) RestoreBar(ctx context.Context, id string) error {
+	if err := r.Repository.RestoreBar(ctx, id); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, id)
+
+	return nil
+}
+
+// invalidate removes ids from the cache after the transaction of ctx commits, so
+// concurrent reads cannot cache the old row again before the write is visible
+func (r *cachedBarRepo) invalidate(ctx context.Context, ids ...string) {
+	transaction.AfterCommit(ctx, func(ctx context.Context) {
+		r.cache.Invalidate(ctx, ids...)
+	})
+}
+
+func barIDs(entities []*bar.Bar) []string {
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+	return ids
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"goilerplate/internal/domain/bar"
+	"goilerplate/pkg/cache"
+	"goilerplate/pkg/fieldset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingBarRepo serves one stored bar and counts the reads reaching it
+type countingBarRepo struct {
+	bar.Repository
+	stored *bar.Bar
+	reads  int
+	onRead func() // runs after a read, before it is returned
+}
+
+func (r *countingBarRepo) GetBarByID(ctx context.Context, id string) (*bar.Bar, error) {
+	r.reads++
+	if r.stored == nil || r.stored.ID != id {
+		return nil, bar.ErrNotFound
+	}
+	clone := *r.stored
+	if r.onRead != nil {
+		r.onRead()
+	}
+	return &clone, nil
+}
+
+func (r *countingBarRepo) UpdateBar(ctx context.Context, entity *bar.Bar) error {
+	updated := *entity
+	updated.Version = r.stored.Version + 1
+	r.stored = &updated
+	return nil
+}
+
+func (r *countingBarRepo) GetCurrentBar(ctx context.Context, id string) (*bar.Bar, error) {
+	return r.GetBarByID(ctx, id)
+}
+
+func (r *countingBarRepo) GetBarView(ctx context.Context, id string, selection *fieldset.Selection) (*bar.Bar, error) {
+	return r.GetBarByID(ctx, id)
+}
+
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (s *memoryStore) IsEnabled() bool { return true }
+
+func (s *memoryStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key], nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.values, key)
+	}
+	return nil
+}
+
+func newCachedBarRepo(t *testing.T) (bar.Repository, *countingBarRepo) {
+	t.Helper()
+
+	inner := &countingBarRepo{stored: &bar.Bar{ID: "bar-1", Code: "A", Bar: "first", Version: 1}}
+	return NewCachedBar(inner, &memoryStore{values: map[string]string{}}, cache.EntityOptions{}), inner
+}
+
+func TestCachedBarRepo_GetBarByID(t *testing.T) {
+	ctx := context.Background()
+	repo, inner := newCachedBarRepo(t)
+	inner.onRead = func() {
+		inner.onRead = nil
+		require.NoError(t, repo.UpdateBar(ctx, &bar.Bar{ID: "bar-1", Code: "A", Bar: "second"}))
+	}
+
+	_, err := repo.GetBarByID(ctx, "bar-1")
+	require.NoError(t, err)
+
+	cached, err := repo.GetBarByID(ctx, "bar-1")
+	require.NoError(t, err)
+	assert.Equal(t, "second", cached.Bar, "the row read before the write is not cached")
+	assert.Equal(t, 2, inner.reads)
+}
+
+func TestCachedBarRepo_GetBarView(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should serve a second view from the cache", func(t *testing.T) {
+		repo, inner := newCachedBarRepo(t)
+		selection, err := fieldset.Parse("code", "", bar.FieldAllowlist)
+		require.NoError(t, err)
+
+		first, err := repo.GetBarView(ctx, "bar-1", nil)
+		require.NoError(t, err)
+		second, err := repo.GetBarView(ctx, "bar-1", selection)
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, inner.reads)
+	})
+
+	t.Run("should read expansions from the database", func(t *testing.T) {
+		repo, inner := newCachedBarRepo(t)
+		selection, err := fieldset.Parse("", bar.ExpandCreator, bar.FieldAllowlist)
+		require.NoError(t, err)
+
+		for range 2 {
+			_, err := repo.GetBarView(ctx, "bar-1", selection)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, inner.reads)
+	})
+}
+
+func TestCachedBarRepo_GetCurrentBar(t *testing.T) {
+	ctx := context.Background()
+	repo, inner := newCachedBarRepo(t)
+
+	_, err := repo.GetBarByID(ctx, "bar-1")
+	require.NoError(t, err)
+
+	// A write the cache has not seen, e.g. a load racing the invalidation cached the old row
+	inner.stored.Version = 2
+
+	cached, err := repo.GetBarByID(ctx, "bar-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cached.Version)
+
+	current, err := repo.GetCurrentBar(ctx, "bar-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), current.Version, "writes check the stored version")
+}
@@ -0,0 +1,450 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"goilerplate/internal/domain/file"
+	"goilerplate/internal/infrastructure/model"
+	"goilerplate/internal/infrastructure/transaction"
+	"goilerplate/pkg/search"
+	"goilerplate/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var fileListColumns = []string{"id", "owner_id", "tenant_id", "disk", "driver", "path", "original_name", "size", "mime_type", "checksum", "status", "variants", "stored_size", "created_at"}
+
+type fileRepo struct {
+	db     *gorm.DB
+	search *search.Search
+}
+
+func NewFile(db *gorm.DB) file.Repository {
+	return &fileRepo{
+		db:     db,
+		search: search.New(search.Config{}, "original_name"),
+	}
+}
+
+func (r *fileRepo) WithTx(ctx context.Context) file.Repository {
+	tx := transaction.GetTxFromContext(ctx)
+	if tx != nil {
+		return &fileRepo{db: tx, search: r.search}
+	}
+	return r
+}
+
+func (r *fileRepo) CreateFile(ctx context.Context, entity *file.File) (*file.File, error) {
+	variants, err := marshalVariants(entity.Variants)
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	model := &model.File{
+		OwnerID:      entity.OwnerID,
+		TenantID:     entity.TenantID,
+		Disk:         entity.Disk,
+		Driver:       entity.Driver,
+		Path:         entity.Path,
+		OriginalName: entity.OriginalName,
+		Size:         entity.Size,
+		MimeType:     entity.MimeType,
+		Checksum:     entity.Checksum,
+		Status:       entity.Status,
+		Variants:     variants,
+		StoredSize:   entity.StoredSize,
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.modelToEntity(model), nil
+}
+
+// MarkFileReady completes a presigned upload
+func (r *fileRepo) MarkFileReady(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.File{}).
+		Where("id = ? AND status = ?", id, file.StatusPending).
+		Update("status", file.StatusReady).Error
+	if err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+func (r *fileRepo) DeleteFile(ctx context.Context, entity *file.File) error {
+	if err := r.db.WithContext(ctx).Delete(&model.File{ID: entity.ID}).Error; err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+// GetFileByID returns a ready file of the owner, files of other users are not found
+func (r *fileRepo) GetFileByID(ctx context.Context, ownerID, id string) (*file.File, error) {
+	return r.getFile(ctx, ownerID, id, file.StatusReady)
+}
+
+// GetPendingFile returns a file of the owner whose presigned upload is not completed yet
+func (r *fileRepo) GetPendingFile(ctx context.Context, ownerID, id string) (*file.File, error) {
+	return r.getFile(ctx, ownerID, id, file.StatusPending)
+}
+
+func (r *fileRepo) getFile(ctx context.Context, ownerID, id, status string) (*file.File, error) {
+	var data model.File
+
+	err := r.db.WithContext(ctx).
+		Select(fileListColumns).
+		Where("id = ? AND owner_id = ? AND status = ?", id, ownerID, status).
+		First(&data).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, file.ErrNotFound
+		}
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.modelToEntity(&data), nil
+}
+
+// GetOwnerUsage counts pending files and unexpired resumable uploads, their declared size is reserved
+func (r *fileRepo) GetOwnerUsage(ctx context.Context, ownerID string) (*file.Usage, error) {
+	return r.getUsage(ctx, "owner_id", ownerID)
+}
+
+// GetTenantUsage counts pending files and unexpired resumable uploads, their declared size is reserved
+func (r *fileRepo) GetTenantUsage(ctx context.Context, tenantID string) (*file.Usage, error) {
+	return r.getUsage(ctx, "tenant_id", tenantID)
+}
+
+func (r *fileRepo) getUsage(ctx context.Context, column, id string) (*file.Usage, error) {
+	var stored struct {
+		Bytes int64
+		Files int64
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&model.File{}).
+		Select("COALESCE(SUM(stored_size), 0) AS bytes, COUNT(*) AS files").
+		Where(column+" = ?", id).
+		Scan(&stored).Error
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	var reserved int64
+	err = r.db.WithContext(ctx).
+		Model(&model.FileUpload{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where(column+" = ? AND expires_at > ?", id, utils.Now()).
+		Scan(&reserved).Error
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	return &file.Usage{Bytes: stored.Bytes + reserved, Files: stored.Files}, nil
+}
+
+func (r *fileRepo) GetStoredFiles(ctx context.Context, disk, afterID string, limit int) ([]*file.File, error) {
+	var models []model.File
+
+	query := r.db.WithContext(ctx).
+		Select(fileListColumns).
+		Where("disk = ?", disk)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+
+	if err := query.Order("id").Limit(limit).Find(&models).Error; err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	entities := make([]*file.File, len(models))
+	for i, model := range models {
+		entities[i] = r.modelToEntity(&model)
+	}
+
+	return entities, nil
+}
+
+func (r *fileRepo) UpdateStoredSize(ctx context.Context, id string, size int64) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.File{}).
+		Where("id = ?", id).
+		Update("stored_size", size).Error
+	if err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+func (r *fileRepo) GetFileList(ctx context.Context, filter *file.Filter) ([]*file.File, error) {
+	var models []model.File
+
+	query := r.db.WithContext(ctx).
+		Select(fileListColumns)
+
+	r.applyFileFilters(query, filter, true) // true = apply pagination
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	entities := make([]*file.File, len(models))
+	for i, model := range models {
+		entities[i] = r.modelToEntity(&model)
+	}
+
+	return entities, nil
+}
+
+func (r *fileRepo) CountFile(ctx context.Context, filter *file.Filter) (int64, error) {
+	var count int64
+
+	query := r.db.WithContext(ctx).
+		Model(&model.File{})
+
+	r.applyFileFilters(query, filter, false) // false = don't apply pagination
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, utils.WrapErr(err)
+	}
+
+	return count, nil
+}
+
+func (r *fileRepo) applyFileFilters(query *gorm.DB, filter *file.Filter, applyPagination bool) {
+	if filter == nil {
+		return
+	}
+
+	query.Where("owner_id = ? AND status = ?", filter.OwnerID, file.StatusReady)
+
+	r.search.Apply(query, filter.Keyword)
+
+	filter.Query.ApplyFilters(query)
+
+	if applyPagination {
+		filter.Query.ApplySort(query)
+	}
+
+	if applyPagination && filter.Pagination != nil {
+		query.Offset(filter.Pagination.GetOffset()).Limit(filter.Pagination.GetLimit())
+	}
+}
+
+func (r *fileRepo) CreateResumableUpload(ctx context.Context, entity *file.ResumableUpload) (*file.ResumableUpload, error) {
+	staged, err := json.Marshal(entity.Staged)
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	model := &model.FileUpload{
+		ID:        entity.ID,
+		OwnerID:   entity.OwnerID,
+		TenantID:  entity.TenantID,
+		Filename:  entity.Filename,
+		MimeType:  entity.MimeType,
+		Size:      entity.Staged.Size,
+		Offset:    entity.Staged.Offset,
+		Staged:    string(staged),
+		HashState: entity.HashState,
+		ExpiresAt: entity.ExpiresAt,
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.uploadModelToEntity(model)
+}
+
+// AdvanceResumableUpload is a compare-and-swap on the offset, so concurrent chunks
+// for the same offset cannot both be accepted
+func (r *fileRepo) AdvanceResumableUpload(ctx context.Context, entity *file.ResumableUpload, from int64) error {
+	staged, err := json.Marshal(entity.Staged)
+	if err != nil {
+		return utils.WrapErr(err)
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&model.FileUpload{}).
+		Where("id = ? AND upload_offset = ?", entity.ID, from).
+		Updates(map[string]any{
+			"upload_offset": entity.Staged.Offset,
+			"staged":        string(staged),
+			"hash_state":    entity.HashState,
+			"mime_type":     entity.MimeType,
+		})
+	if result.Error != nil {
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return file.ErrResumableOffsetMismatch
+	}
+
+	return nil
+}
+
+// ClaimResumableUpload is a compare-and-swap on the offset and the claim, so a chunk
+// is only written by the request holding the upload
+func (r *fileRepo) ClaimResumableUpload(ctx context.Context, id string, offset int64, until time.Time) error {
+	now := utils.Now()
+
+	result := r.db.WithContext(ctx).
+		Model(&model.FileUpload{}).
+		Where("id = ? AND upload_offset = ? AND (claimed_until IS NULL OR claimed_until < ?)", id, offset, now).
+		Update("claimed_until", until)
+	if result.Error != nil {
+		return utils.WrapErr(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return file.ErrResumableLocked
+	}
+
+	return nil
+}
+
+func (r *fileRepo) ReleaseResumableUpload(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.FileUpload{}).
+		Where("id = ?", id).
+		Update("claimed_until", nil).Error
+	if err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+func (r *fileRepo) DeleteResumableUpload(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&model.FileUpload{ID: id}).Error; err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+// GetResumableUpload returns an unexpired upload of the owner
+func (r *fileRepo) GetResumableUpload(ctx context.Context, ownerID, id string) (*file.ResumableUpload, error) {
+	var data model.FileUpload
+
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND owner_id = ? AND expires_at > ?", id, ownerID, utils.Now()).
+		First(&data).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, file.ErrResumableNotFound
+		}
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.uploadModelToEntity(&data)
+}
+
+func (r *fileRepo) GetExpiredResumableUploads(ctx context.Context, limit int) ([]*file.ResumableUpload, error) {
+	var data []model.FileUpload
+	now := utils.Now()
+
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)", now, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&data).Error
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	uploads := make([]*file.ResumableUpload, 0, len(data))
+	for i := range data {
+		upload, err := r.uploadModelToEntity(&data[i])
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, nil
+}
+
+func (r *fileRepo) uploadModelToEntity(model *model.FileUpload) (*file.ResumableUpload, error) {
+	entity := &file.ResumableUpload{
+		ID:        model.ID,
+		OwnerID:   model.OwnerID,
+		TenantID:  model.TenantID,
+		Filename:  model.Filename,
+		MimeType:  model.MimeType,
+		HashState: model.HashState,
+		ExpiresAt: model.ExpiresAt,
+		CreatedAt: model.CreatedAt,
+	}
+
+	if err := json.Unmarshal([]byte(model.Staged), &entity.Staged); err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	return entity, nil
+}
+
+func (r *fileRepo) modelToEntity(model *model.File) *file.File {
+	return &file.File{
+		ID:           model.ID,
+		OwnerID:      model.OwnerID,
+		TenantID:     model.TenantID,
+		Disk:         model.Disk,
+		Driver:       model.Driver,
+		Path:         model.Path,
+		OriginalName: model.OriginalName,
+		Size:         model.Size,
+		MimeType:     model.MimeType,
+		Checksum:     model.Checksum,
+		Status:       model.Status,
+		Variants:     unmarshalVariants(model.Variants),
+		StoredSize:   model.StoredSize,
+		CreatedAt:    model.CreatedAt,
+	}
+}
+
+// unmarshalVariants is lenient, variants are descriptive and a malformed column leaves them empty
+func unmarshalVariants(data string) []file.Variant {
+	var models []model.FileVariant
+	if data == "" || json.Unmarshal([]byte(data), &models) != nil {
+		return nil
+	}
+
+	variants := make([]file.Variant, len(models))
+	for i, variant := range models {
+		variants[i] = file.Variant{
+			Name:     variant.Name,
+			Path:     variant.Path,
+			MimeType: variant.MimeType,
+			Width:    variant.Width,
+			Height:   variant.Height,
+			Size:     variant.Size,
+		}
+	}
+	return variants
+}
+
+func marshalVariants(variants []file.Variant) (string, error) {
+	models := make([]model.FileVariant, len(variants))
+	for i, variant := range variants {
+		models[i] = model.FileVariant{
+			Name:     variant.Name,
+			Path:     variant.Path,
+			MimeType: variant.MimeType,
+			Width:    variant.Width,
+			Height:   variant.Height,
+			Size:     variant.Size,
+		}
+	}
+
+	data, err := json.Marshal(models)
+	return string(data), err
+}
@@ -2,54 +2,202 @@ package repository
 
 import (
 	"context"
+	"errors"
+
 	"goilerplate/internal/domain/foo"
+	"goilerplate/internal/infrastructure/model"
 	"goilerplate/internal/infrastructure/transaction"
+	"goilerplate/pkg/dberror"
+	"goilerplate/pkg/search"
+	"goilerplate/pkg/utils"
 
 	"gorm.io/gorm"
 )
 
 type fooRepo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search *search.Search
 }
 
-func NewFoo(db *gorm.DB) foo.Repository {
+func NewFoo(db *gorm.DB, searchCfg search.Config) foo.Repository {
 	return &fooRepo{
-		db: db,
+		db:     db,
+		search: search.New(searchCfg, "code", "foo"),
 	}
 }
 
 func (r *fooRepo) WithTx(ctx context.Context) foo.Repository {
 	tx := transaction.GetTxFromContext(ctx)
 	if tx != nil {
-		return &fooRepo{db: tx}
+		return &fooRepo{db: tx, search: r.search}
 	}
 	return r
 }
 
 func (r *fooRepo) CreateFoo(ctx context.Context, entity *foo.Foo) (*foo.Foo, error) {
-	panic("Implement me")
+	model := &model.Foo{
+		Code:     entity.Code,
+		Foo:      entity.Foo,
+		IsActive: true,
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		if errors.Is(err, dberror.ErrConflict) {
+			return nil, foo.ErrCodeAlreadyExists
+		}
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.modelToEntity(model), nil
 }
 
 func (r *fooRepo) UpdateFoo(ctx context.Context, entity *foo.Foo) error {
-	panic("Implement me")
+	model, err := r.getFooByID(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+
+	model.Code = entity.Code
+	model.Foo = entity.Foo
+
+	if err = r.db.WithContext(ctx).Save(model).Error; err != nil {
+		if errors.Is(err, dberror.ErrConflict) {
+			return foo.ErrCodeAlreadyExists
+		}
+		return utils.WrapErr(err)
+	}
+
+	return nil
 }
 
 func (r *fooRepo) DeleteFoo(ctx context.Context, entity *foo.Foo) error {
-	panic("Implement me")
+	model, err := r.getFooByID(ctx, entity.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Delete(model).Error; err != nil {
+		return utils.WrapErr(err)
+	}
+
+	return nil
 }
 
 func (r *fooRepo) GetFooByID(ctx context.Context, id string) (*foo.Foo, error) {
-	panic("Implement me")
+	model, err := r.getFooByID(ctx, id)
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	return r.modelToEntity(model), nil
 }
 
 func (r *fooRepo) GetFooList(ctx context.Context, filter *foo.Filter) ([]*foo.Foo, error) {
-	panic("Implement me")
+	var models []model.Foo
+
+	query := r.db.WithContext(ctx).
+		Select("id", "code", "foo")
+
+	r.applyFooFilters(query, filter, true) // true = apply pagination
+
+	err := query.Find(&models).Error
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	entities := make([]*foo.Foo, len(models))
+	for i, model := range models {
+		entities[i] = r.modelToEntity(&model)
+	}
+
+	return entities, nil
 }
 
 func (r *fooRepo) CountFoo(ctx context.Context, filter *foo.Filter) (int64, error) {
-	panic("Implement me")
+	var count int64
+
+	query := r.db.WithContext(ctx).
+		Model(&model.Foo{})
+
+	r.applyFooFilters(query, filter, false) // false = don't apply pagination
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, utils.WrapErr(err)
+	}
+
+	return count, nil
 }
 
 func (r *fooRepo) BulkCreate(ctx context.Context, entities []*foo.Foo) error {
-	panic("Implement me")
+	if len(entities) == 0 {
+		return nil
+	}
+
+	models := make([]model.Foo, len(entities))
+	for i, entity := range entities {
+		models[i] = model.Foo{
+			Code:     entity.Code,
+			Foo:      entity.Foo,
+			IsActive: true,
+		}
+	}
+
+	if err := r.db.WithContext(ctx).Create(&models).Error; err != nil {
+		if errors.Is(err, dberror.ErrConflict) {
+			return foo.ErrCodeAlreadyExists
+		}
+		return utils.WrapErr(err)
+	}
+
+	return nil
+}
+
+func (r *fooRepo) getFooByID(ctx context.Context, id string) (*model.Foo, error) {
+	var data model.Foo
+
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&data).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, foo.ErrNotFound
+		}
+		return nil, utils.WrapErr(err)
+	}
+
+	return &data, nil
+}
+
+func (r *fooRepo) applyFooFilters(query *gorm.DB, filter *foo.Filter, applyPagination bool) {
+	if filter == nil {
+		return
+	}
+
+	r.search.Apply(query, filter.Keyword)
+
+	if filter.Code != "" {
+		query.Where("code = ?", filter.Code)
+	}
+
+	filter.Query.ApplyFilters(query)
+
+	if applyPagination {
+		filter.Query.ApplySort(query)
+		if filter.Query.SortedByDefault() {
+			r.search.Rank(query, filter.Keyword) // relevance first, the default sort breaks ties
+		}
+	}
+
+	if applyPagination && filter.Pagination != nil {
+		query.Offset(filter.Pagination.GetOffset()).Limit(filter.Pagination.GetLimit())
+	}
+}
+
+func (r *fooRepo) modelToEntity(model *model.Foo) *foo.Foo {
+	return &foo.Foo{
+		ID:   model.ID,
+		Code: model.Code,
+		Foo:  model.Foo,
+	}
 }
@@ -3,6 +3,7 @@ package transaction
 import (
 	"context"
 	"goilerplate/internal/domain/transaction"
+	"goilerplate/pkg/dberror"
 
 	"gorm.io/gorm"
 )
@@ -10,6 +11,14 @@ import (
 // TxKey is the context key for storing transaction DB
 type TxKey struct{}
 
+// afterCommitKey is the context key for storing the after commit hooks of a transaction
+type afterCommitKey struct{}
+
+// afterCommit collects the hooks registered while a transaction runs
+type afterCommit struct {
+	hooks []func(ctx context.Context)
+}
+
 // gormTransaction implements the Transaction interface using GORM
 type gormTransaction struct {
 	db *gorm.DB
@@ -24,15 +33,38 @@ func NewGormTransaction(db *gorm.DB) transaction.Transaction {
 
 // Do executes a function within a database transaction
 // If the function returns an error, the transaction is rolled back
-// Otherwise, the transaction is committed
+// Otherwise, the transaction is committed and the hooks registered with AfterCommit run
 func (t *gormTransaction) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	hooks := &afterCommit{}
+
 	// Use GORM's built-in Transaction method
 	// This provides automatic begin, commit, and rollback
-	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Store transaction DB in context
 		txCtx := context.WithValue(ctx, TxKey{}, tx)
+		txCtx = context.WithValue(txCtx, afterCommitKey{}, hooks)
 		return fn(txCtx)
 	})
+	if err != nil {
+		// Serialization failures may only be reported on commit, which bypasses the GORM callbacks
+		return dberror.Translate(err)
+	}
+
+	for _, hook := range hooks.hooks {
+		hook(ctx)
+	}
+
+	return nil
+}
+
+// AfterCommit runs fn once the transaction of ctx is committed, or right away outside
+// of a transaction. Hooks of rolled back transactions never run.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok {
+		hooks.hooks = append(hooks.hooks, fn)
+		return
+	}
+	fn(ctx)
 }
 
 // GetTxFromContext retrieves transaction DB from context
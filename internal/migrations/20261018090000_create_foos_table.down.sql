@@ -0,0 +1,11 @@
+-- Rollback: create_foos_table
+-- Created at: 2026-10-18T09:00:00Z
+
+-- Drop indexes first
+DROP INDEX IF EXISTS idx_foos_active;
+DROP INDEX IF EXISTS idx_foos_deleted_at;
+DROP INDEX IF EXISTS idx_foos_is_active;
+DROP INDEX IF EXISTS idx_foos_code;
+
+-- Drop the table
+DROP TABLE IF EXISTS foos;
@@ -0,0 +1,35 @@
+-- Migration: create_foos_table
+-- Created at: 2026-10-18T09:00:00Z
+
+CREATE TABLE foos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(255) NOT NULL UNIQUE,
+    foo TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    deleted_by VARCHAR(255) DEFAULT NULL
+);
+
+-- Comments
+COMMENT ON TABLE foos IS 'Foos table for storing foo records';
+COMMENT ON COLUMN foos.code IS 'Unique code identifier';
+COMMENT ON COLUMN foos.foo IS 'Foo text content';
+COMMENT ON COLUMN foos.is_active IS 'Whether the record is active';
+COMMENT ON COLUMN foos.created_at IS 'Timestamp when record was created';
+COMMENT ON COLUMN foos.updated_at IS 'Timestamp when record was last updated';
+COMMENT ON COLUMN foos.deleted_at IS 'Timestamp when record was soft deleted';
+COMMENT ON COLUMN foos.created_by IS 'User who created this record';
+COMMENT ON COLUMN foos.updated_by IS 'User who last updated this record';
+COMMENT ON COLUMN foos.deleted_by IS 'User who deleted this record';
+
+-- Create indexes for better performance
+CREATE INDEX idx_foos_code ON foos(code);
+CREATE INDEX idx_foos_is_active ON foos(is_active);
+CREATE INDEX idx_foos_deleted_at ON foos(deleted_at);
+
+-- Composite index for active records
+CREATE INDEX idx_foos_active ON foos(code, is_active, deleted_at);
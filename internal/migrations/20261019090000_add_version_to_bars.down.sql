@@ -0,0 +1,4 @@
+-- Rollback: add_version_to_bars
+-- Created at: 2026-10-19T09:00:00Z
+
+ALTER TABLE bars DROP COLUMN IF EXISTS version;
@@ -0,0 +1,7 @@
+-- Migration: add_version_to_bars
+-- Created at: 2026-10-19T09:00:00Z
+
+ALTER TABLE bars ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- Comments
+COMMENT ON COLUMN bars.version IS 'Incremented on every update, used for optimistic concurrency (ETag / If-Match)';
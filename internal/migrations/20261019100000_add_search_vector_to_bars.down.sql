@@ -0,0 +1,5 @@
+-- Rollback: add_search_vector_to_bars
+-- Created at: 2026-10-19T10:00:00Z
+
+DROP INDEX IF EXISTS idx_bars_search_vector;
+ALTER TABLE bars DROP COLUMN IF EXISTS search_vector;
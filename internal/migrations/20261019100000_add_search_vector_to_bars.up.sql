@@ -0,0 +1,13 @@
+-- Migration: add_search_vector_to_bars
+-- Created at: 2026-10-19T10:00:00Z
+
+-- Only used by the fulltext search mode (search.bars.mode), the expression must match
+-- the searched columns and text search configuration of the bar repository
+ALTER TABLE bars ADD COLUMN search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', coalesce(code, '') || ' ' || coalesce(bar, ''))) STORED;
+
+-- Indexes
+CREATE INDEX idx_bars_search_vector ON bars USING GIN (search_vector);
+
+-- Comments
+COMMENT ON COLUMN bars.search_vector IS 'Full-text search document of code and bar';
@@ -0,0 +1,9 @@
+-- Rollback: create_files_table
+-- Created at: 2026-10-19T11:00:00Z
+
+-- Drop indexes first
+DROP INDEX IF EXISTS idx_files_checksum;
+DROP INDEX IF EXISTS idx_files_owner;
+
+-- Drop the table
+DROP TABLE IF EXISTS files;
@@ -0,0 +1,34 @@
+-- Migration: create_files_table
+-- Created at: 2026-10-19T11:00:00Z
+
+CREATE TABLE files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id VARCHAR(255) NOT NULL,
+    driver VARCHAR(32) NOT NULL,
+    path TEXT NOT NULL,
+    original_name TEXT NOT NULL,
+    size BIGINT NOT NULL DEFAULT 0,
+    mime_type VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    deleted_by VARCHAR(255) DEFAULT NULL
+);
+
+-- Comments
+COMMENT ON TABLE files IS 'Metadata of uploaded files, the content lives on the filesystem driver';
+COMMENT ON COLUMN files.owner_id IS 'User who uploaded the file';
+COMMENT ON COLUMN files.driver IS 'Filesystem driver holding the file (local, s3, drive)';
+COMMENT ON COLUMN files.path IS 'Path or object key of the file on the driver';
+COMMENT ON COLUMN files.original_name IS 'Filename sent by the client';
+COMMENT ON COLUMN files.size IS 'Size in bytes';
+COMMENT ON COLUMN files.mime_type IS 'MIME type of the content';
+COMMENT ON COLUMN files.checksum IS 'Hex encoded SHA-256 of the content';
+COMMENT ON COLUMN files.deleted_at IS 'Timestamp when the file was deleted from the driver';
+
+-- Create indexes for better performance
+CREATE INDEX idx_files_owner ON files(owner_id, created_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_files_checksum ON files(checksum);
@@ -0,0 +1,6 @@
+-- Rollback: add_status_to_files
+-- Created at: 2026-10-19T12:00:00Z
+
+DROP INDEX IF EXISTS idx_files_pending;
+
+ALTER TABLE files DROP COLUMN IF EXISTS status;
@@ -0,0 +1,10 @@
+-- Migration: add_status_to_files
+-- Created at: 2026-10-19T12:00:00Z
+
+ALTER TABLE files ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'ready';
+
+-- Comments
+COMMENT ON COLUMN files.status IS 'pending while a presigned upload has not been completed, ready afterwards';
+
+-- Create indexes for better performance
+CREATE INDEX idx_files_pending ON files(created_at) WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- Rollback: create_file_uploads_table
+-- Created at: 2026-10-19T13:00:00Z
+
+DROP TABLE IF EXISTS file_uploads;
@@ -0,0 +1,29 @@
+-- Migration: create_file_uploads_table
+-- Created at: 2026-10-19T13:00:00Z
+
+CREATE TABLE file_uploads (
+    id UUID PRIMARY KEY,
+    owner_id VARCHAR(255) NOT NULL,
+    filename TEXT NOT NULL,
+    mime_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    staged JSONB NOT NULL,
+    hash_state BYTEA NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(255) NOT NULL,
+    updated_by VARCHAR(255) NOT NULL
+);
+
+-- Comments
+COMMENT ON TABLE file_uploads IS 'Resumable (tus) uploads in progress, removed once finalized into files';
+COMMENT ON COLUMN file_uploads.upload_offset IS 'Bytes received so far';
+COMMENT ON COLUMN file_uploads.staged IS 'Staging state of the filesystem driver (path, multipart upload id and parts)';
+COMMENT ON COLUMN file_uploads.hash_state IS 'Serialized SHA-256 state of the received bytes';
+COMMENT ON COLUMN file_uploads.expires_at IS 'Uploads not finished by then are discarded';
+
+-- Create indexes for better performance
+CREATE INDEX idx_file_uploads_owner ON file_uploads(owner_id);
+CREATE INDEX idx_file_uploads_expires_at ON file_uploads(expires_at);
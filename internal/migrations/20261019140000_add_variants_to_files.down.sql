@@ -0,0 +1,4 @@
+-- Rollback: add_variants_to_files
+-- Created at: 2026-10-19T14:00:00Z
+
+ALTER TABLE files DROP COLUMN IF EXISTS variants;
@@ -0,0 +1,7 @@
+-- Migration: add_variants_to_files
+-- Created at: 2026-10-19T14:00:00Z
+
+ALTER TABLE files ADD COLUMN variants JSONB NOT NULL DEFAULT '[]';
+
+-- Comments
+COMMENT ON COLUMN files.variants IS 'Image variants stored next to the file (name, path, MIME type, dimensions and size)';
@@ -0,0 +1,9 @@
+-- Rollback: add_usage_to_files
+-- Created at: 2026-10-19T15:00:00Z
+
+DROP INDEX IF EXISTS idx_file_uploads_tenant;
+DROP INDEX IF EXISTS idx_files_tenant;
+
+ALTER TABLE file_uploads DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE files DROP COLUMN IF EXISTS stored_size;
+ALTER TABLE files DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,19 @@
+-- Migration: add_usage_to_files
+-- Created at: 2026-10-19T15:00:00Z
+
+ALTER TABLE files ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN stored_size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE file_uploads ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE files SET stored_size = size + COALESCE(
+    (SELECT SUM((variant->>'size')::BIGINT) FROM jsonb_array_elements(variants) AS variant), 0
+);
+
+-- Comments
+COMMENT ON COLUMN files.tenant_id IS 'Tenant (store) of the owner when the file was uploaded, empty without one';
+COMMENT ON COLUMN files.stored_size IS 'Bytes stored for the file and its variants, counted against storage quotas';
+COMMENT ON COLUMN file_uploads.tenant_id IS 'Tenant (store) of the owner, empty without one';
+
+-- Create indexes for better performance
+CREATE INDEX idx_files_tenant ON files(tenant_id) WHERE deleted_at IS NULL AND tenant_id <> '';
+CREATE INDEX idx_file_uploads_tenant ON file_uploads(tenant_id) WHERE tenant_id <> '';
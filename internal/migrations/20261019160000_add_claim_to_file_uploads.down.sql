@@ -0,0 +1,4 @@
+-- Rollback: add_claim_to_file_uploads
+-- Created at: 2026-10-19T16:00:00Z
+
+ALTER TABLE file_uploads DROP COLUMN IF EXISTS claimed_until;
@@ -0,0 +1,7 @@
+-- Migration: add_claim_to_file_uploads
+-- Created at: 2026-10-19T16:00:00Z
+
+ALTER TABLE file_uploads ADD COLUMN claimed_until TIMESTAMP NULL;
+
+-- Comments
+COMMENT ON COLUMN file_uploads.claimed_until IS 'A request is writing a chunk until then, others are rejected';
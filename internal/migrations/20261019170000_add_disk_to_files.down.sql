@@ -0,0 +1,6 @@
+-- Rollback: add_disk_to_files
+-- Created at: 2026-10-19T17:00:00Z
+
+DROP INDEX IF EXISTS idx_files_disk;
+
+ALTER TABLE files DROP COLUMN IF EXISTS disk;
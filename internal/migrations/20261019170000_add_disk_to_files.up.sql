@@ -0,0 +1,10 @@
+-- Migration: add_disk_to_files
+-- Created at: 2026-10-19T17:00:00Z
+
+ALTER TABLE files ADD COLUMN disk VARCHAR(255) NOT NULL DEFAULT 'default';
+
+-- Comments
+COMMENT ON COLUMN files.disk IS 'Name of the filesystem disk holding the file, existing files were stored on the default disk';
+
+-- Create indexes for better performance
+CREATE INDEX idx_files_disk ON files(disk, id);
@@ -0,0 +1,6 @@
+-- Rollback: unique_active_bar_codes
+-- Created at: 2026-10-19T18:00:00Z
+
+-- Fails while a live bar and a trashed bar share a code
+DROP INDEX IF EXISTS idx_bars_code_active;
+ALTER TABLE bars ADD CONSTRAINT bars_code_key UNIQUE (code);
@@ -0,0 +1,9 @@
+-- Migration: unique_active_bar_codes
+-- Created at: 2026-10-19T18:00:00Z
+
+-- Codes only have to be unique among live bars, so a code of a trashed bar can be
+-- reused. Restoring a bar whose code was taken in the meantime fails with a conflict.
+ALTER TABLE bars DROP CONSTRAINT IF EXISTS bars_code_key;
+
+-- Indexes
+CREATE UNIQUE INDEX idx_bars_code_active ON bars(code) WHERE deleted_at IS NULL;
@@ -15,10 +15,13 @@ import (
 
 // Handlers contains all HTTP handlers
 type Handlers struct {
-	Auth   *handler.Auth
-	Foo    *handler.Foo
-	Bar    *handler.Bar
-	Upload *handler.Upload
+	Auth    *handler.Auth
+	Foo     *handler.Foo
+	Bar     *handler.Bar
+	Upload  *handler.Upload
+	Storage *handler.Storage
+	Tus     *handler.Tus
+	// scaffold:handlers
 	// Future handlers will be added here:
 	// UserHandler    *handler.UserHandler
 	// OrderHandler   *handler.OrderHandler
@@ -43,10 +46,13 @@ func WireHandlers(app *bootstrap.App, useCases *UseCases, appServices *Applicati
 	deviceService := auth.NewDeviceService()
 
 	return &Handlers{
-		Auth:   handler.NewAuth(deviceService, app.Validator, appServices.RegisterSvc, useCases.AuthUC),
-		Upload: handler.NewUpload(app.Validator, infrastructure.FilesystemManager, app.Config.FileSystem.MaxFileSize),
-		Foo:    handler.NewFoo(app.Validator, useCases.FooUC),
-		Bar:    handler.NewBar(app.Validator, useCases.BarUC),
+		Auth:    handler.NewAuth(deviceService, app.Validator, appServices.RegisterSvc, useCases.AuthUC),
+		Upload:  handler.NewUpload(app.Validator, useCases.FileUC),
+		Storage: handler.NewStorage(infrastructure.FilesystemManager),
+		Tus:     handler.NewTus(useCases.FileUC, resumableMaxSize(app.Config.FileSystem)),
+		Foo:     handler.NewFoo(app.Validator, useCases.FooUC),
+		Bar:     handler.NewBar(app.Validator, useCases.BarUC, appServices.BarSvc, app.Config.Bulk, app.Config.Concurrency),
+		// scaffold:handler-constructors
 	}
 }
 
@@ -66,3 +72,14 @@ func WireMiddleware(cfg *config.Config, repos *Repositories, infrastructure *Inf
 		// Logger: middleware.NewLogger(),
 	}
 }
+
+// resumableMaxSize is the Tus-Max-Size announced to clients, the file usecase applies the same default
+func resumableMaxSize(cfg config.FileSystem) int64 {
+	if cfg.Policies.Resumable.MaxSize > 0 {
+		return cfg.Policies.Resumable.MaxSize
+	}
+	if cfg.Resumable.MaxSize > 0 {
+		return cfg.Resumable.MaxSize
+	}
+	return cfg.MaxFileSize
+}
@@ -1,6 +1,7 @@
 package wire
 
 import (
+	"goilerplate/internal/bootstrap"
 	grpcdelivery "goilerplate/internal/delivery/grpc"
 	grpchandler "goilerplate/internal/delivery/grpc/handler"
 )
@@ -9,15 +10,17 @@ type GrpcHandlers struct {
 	ServiceRegistry *grpcdelivery.ServiceRegistry
 }
 
-func WireGrpcHandlers(useCases *UseCases) *GrpcHandlers {
+func WireGrpcHandlers(app *bootstrap.App, useCases *UseCases) *GrpcHandlers {
 	hello := grpchandler.NewHello()
-	foo := grpchandler.NewFoo()
-	bar := grpchandler.NewBar(useCases.BarUC)
+	foo := grpchandler.NewFoo(useCases.FooUC)
+	bar := grpchandler.NewBar(useCases.BarUC, app.Config.Concurrency.RequireIfMatch)
+	// scaffold:grpc-handlers
 
 	registry := grpcdelivery.NewServiceRegistry(
 		hello,
 		foo,
 		bar,
+		// scaffold:grpc-services
 	)
 
 	return &GrpcHandlers{
@@ -25,10 +25,15 @@ type Infrastructure struct {
 func WireInfrastructure(app *bootstrap.App) *Infrastructure {
 	// Initialize filesystem manager from config
 	filesystemMgr, err := filesystem.NewManagerFromConfig(context.Background(), filesystem.Config{
-		Driver: filesystem.Driver(app.Config.FileSystem.Driver),
-		Local:  app.Config.FileSystem.Local,
-		S3:     app.Config.FileSystem.S3,
-		Drive:  app.Config.FileSystem.Drive,
+		Driver:     filesystem.Driver(app.Config.FileSystem.Driver),
+		Local:      app.Config.FileSystem.Local,
+		S3:         app.Config.FileSystem.S3,
+		Drive:      app.Config.FileSystem.Drive,
+		Images:     app.Config.FileSystem.Images,
+		Scan:       app.Config.FileSystem.Scan,
+		Encryption: app.Config.FileSystem.Encryption,
+		Mirror:     app.Config.FileSystem.Mirror,
+		Disks:      app.Config.FileSystem.Disks,
 	})
 	if err != nil {
 		panic("Failed to initialize filesystem manager: " + err.Error())
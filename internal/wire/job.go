@@ -0,0 +1,31 @@
+package wire
+
+import (
+	"goilerplate/internal/bootstrap"
+	"goilerplate/internal/delivery/job"
+)
+
+// Jobs contains the background jobs started with the server
+type Jobs struct {
+	Scheduler *job.Scheduler
+}
+
+// WireJobs creates the scheduler with every job enabled in the config
+func WireJobs(app *bootstrap.App, useCases *UseCases) *Jobs {
+	var jobs []job.Job
+
+	if app.Config.Trash.PurgeEnabled {
+		jobs = append(jobs, job.NewBarPurge(useCases.BarUC, app.Config.Trash))
+	}
+
+	// Expired uploads hold staged chunks and S3 multipart uploads until they are removed
+	jobs = append(jobs, job.NewFileUploadCleanup(useCases.FileUC, app.Config.FileSystem.Resumable))
+
+	if app.Config.FileSystem.Quota.ReconcileEnabled {
+		jobs = append(jobs, job.NewFileReconcile(useCases.FileUC, app.Config.FileSystem.Quota))
+	}
+
+	return &Jobs{
+		Scheduler: job.NewScheduler(jobs...),
+	}
+}
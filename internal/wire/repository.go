@@ -1,15 +1,19 @@
 package wire
 
 import (
+	"goilerplate/config"
 	"goilerplate/internal/bootstrap"
 	"goilerplate/internal/domain/auth"
 	"goilerplate/internal/domain/bar"
+	"goilerplate/internal/domain/file"
 	"goilerplate/internal/domain/foo"
 	"goilerplate/internal/domain/role"
 	"goilerplate/internal/domain/user"
 	"goilerplate/internal/domain/userrole"
 
+	"goilerplate/internal/infrastructure/cache"
 	"goilerplate/internal/infrastructure/repository"
+	entitycache "goilerplate/pkg/cache"
 )
 
 // Repositories contains all repository implementations
@@ -20,17 +24,31 @@ type Repositories struct {
 	UserRoleRepo userrole.Repository
 	FooRepo      foo.Repository
 	BarRepo      bar.Repository
+	FileRepo     file.Repository
+	// scaffold:repositories
 }
 
 // WireRepositories creates all repository implementations
 func WireRepositories(app *bootstrap.App) *Repositories {
 	db := app.DB.GDB
+	cacheStore := cache.NewRedisService(app.Redis)
 	return &Repositories{
 		AuthRepo:     repository.NewAuth(db),
 		RoleRepo:     repository.NewRole(db),
 		UserRepo:     repository.NewUser(db),
 		UserRoleRepo: repository.NewUserRole(db),
-		FooRepo:      repository.NewFoo(db),
-		BarRepo:      repository.NewBar(db),
+		FooRepo:      repository.NewFoo(db, app.Config.Search["foos"]),
+		BarRepo:      repository.NewCachedBar(repository.NewBar(db, app.Config.Search["bars"]), cacheStore, entityCacheOptions(app.Config.Cache["bars"])),
+		FileRepo:     repository.NewFile(db),
+		// scaffold:repository-constructors
+	}
+}
+
+// entityCacheOptions converts the cache config of a resource, zero values use the cache defaults
+func entityCacheOptions(cfg config.EntityCache) entitycache.EntityOptions {
+	return entitycache.EntityOptions{
+		TTL:             cfg.TTL,
+		NegativeTTL:     cfg.NegativeTTL,
+		InvalidateAgain: cfg.InvalidateAgain,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"goilerplate/internal/bootstrap"
 	"goilerplate/internal/domain/auth"
 	"goilerplate/internal/domain/bar"
+	"goilerplate/internal/domain/file"
 	"goilerplate/internal/domain/foo"
 	"goilerplate/pkg/jwt"
 )
@@ -13,6 +14,8 @@ type UseCases struct {
 	AuthUC auth.Usecase
 	FooUC  foo.Usecase
 	BarUC  bar.Usecase
+	FileUC file.Usecase
+	// scaffold:usecases
 	// Future use cases will be added here:
 	// UserUC    user.UseCase
 	// OrderUC   order.UseCase
@@ -38,6 +41,26 @@ func WireUseCases(app *bootstrap.App, repos *Repositories, infra *Infrastructure
 		AuthUC: auth.NewUseCase(repos.AuthRepo, jwtService, cacheService),
 		FooUC:  foo.NewUseCase(repos.FooRepo),
 		BarUC:  bar.NewUseCase(repos.BarRepo),
+		FileUC: file.NewUseCase(repos.FileRepo, infra.FilesystemManager, file.Options{
+			MaxFileSize: app.Config.FileSystem.MaxFileSize,
+			URLTTL:      app.Config.FileSystem.URLTTL,
+			UploadTTL:   app.Config.FileSystem.UploadTTL,
+
+			MaxResumableSize: app.Config.FileSystem.Resumable.MaxSize,
+			ResumableTTL:     app.Config.FileSystem.Resumable.TTL,
+			StagingPath:      app.Config.FileSystem.Resumable.StagingPath,
+
+			Policies: file.Policies{
+				Upload:    app.Config.FileSystem.Policies.Upload,
+				Multiple:  app.Config.FileSystem.Policies.Multiple,
+				Presign:   app.Config.FileSystem.Policies.Presign,
+				Resumable: app.Config.FileSystem.Policies.Resumable,
+			},
+
+			UserQuota:   app.Config.FileSystem.Quota.User,
+			TenantQuota: app.Config.FileSystem.Quota.Tenant,
+		}),
+		// scaffold:usecase-constructors
 		// Future use cases will be added here:
 		// UserUC:    user.NewUseCase(repos.UserRepo),
 		// OrderUC:   order.NewUseCase(repos.OrderRepo, repos.ProductRepo),
@@ -13,6 +13,7 @@ type ApplicationContainer struct {
 	Handlers            *Handlers
 	GrpcHandlers        *GrpcHandlers
 	Middleware          *Middleware
+	Jobs                *Jobs
 }
 
 // Init wires all dependencies following clean architecture layers
@@ -31,11 +32,14 @@ func Init(app *bootstrap.App) *ApplicationContainer {
 
 	// Layer 5: Handler Layer (Delivery/Presentation)
 	handlers := WireHandlers(app, useCases, applicationServices, infrastructure)
-	grpcHandlers := WireGrpcHandlers(useCases)
+	grpcHandlers := WireGrpcHandlers(app, useCases)
 
 	// Layer 5: Middleware Layer
 	middleware := WireMiddleware(app.Config, repositories, infrastructure)
 
+	// Layer 6: Background Jobs
+	jobs := WireJobs(app, useCases)
+
 	return &ApplicationContainer{
 		Infrastructure:      infrastructure,
 		Repositories:        repositories,
@@ -44,5 +48,6 @@ func Init(app *bootstrap.App) *ApplicationContainer {
 		Handlers:            handlers,
 		GrpcHandlers:        grpcHandlers,
 		Middleware:          middleware,
+		Jobs:                jobs,
 	}
 }
@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"goilerplate/pkg/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Defaults of EntityOptions
+const (
+	DefaultEntityTTL       = 5 * time.Minute
+	DefaultNegativeTTL     = 30 * time.Second
+	DefaultInvalidateAgain = time.Second
+)
+
+// generationStripes bounds the invalidation counters, keys sharing a stripe only cause extra loads
+const generationStripes = 64
+
+// notFoundMarker is stored for ids that do not exist, JSON never starts with it
+const notFoundMarker = "!"
+
+// Store is the key value store of Entity, implemented by the infrastructure RedisService.
+// Get returns an empty string on a miss.
+type Store interface {
+	IsEnabled() bool
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// EntityOptions configures an Entity cache
+type EntityOptions struct {
+	Prefix      string        // key prefix, e.g. "bar:"
+	TTL         time.Duration // lifetime of cached entities, defaults to DefaultEntityTTL
+	NegativeTTL time.Duration // lifetime of not found results, defaults to DefaultNegativeTTL, negative disables them
+	NotFound    error         // error of the loader for missing ids, matched with errors.Is and returned on negative hits
+
+	// InvalidateAgain repeats every invalidation after this delay, removing rows that loads of
+	// other instances read before the write and cached after it. Defaults to
+	// DefaultInvalidateAgain, negative disables it.
+	InvalidateAgain time.Duration
+}
+
+// Entity is a read-through cache of entities by id. Concurrent misses of the same id share
+// one load, and not found results are cached for NegativeTTL. A load overlapping an
+// invalidation of its id is read again before it is cached. Without an enabled store every
+// Get calls the loader and Invalidate does nothing.
+type Entity[T any] struct {
+	store       Store
+	opts        EntityOptions
+	group       singleflight.Group
+	generations [generationStripes]atomic.Uint64 // bumped by Invalidate, per stripe of keys
+}
+
+// NewEntity creates an entity cache, store may be nil
+func NewEntity[T any](store Store, opts EntityOptions) *Entity[T] {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultEntityTTL
+	}
+	if opts.NegativeTTL == 0 {
+		opts.NegativeTTL = DefaultNegativeTTL
+	}
+	if opts.InvalidateAgain == 0 {
+		opts.InvalidateAgain = DefaultInvalidateAgain
+	}
+	return &Entity[T]{store: store, opts: opts}
+}
+
+// IsEnabled returns whether entities are cached
+func (c *Entity[T]) IsEnabled() bool {
+	return c.store != nil && c.store.IsEnabled()
+}
+
+// Get returns the cached entity of id, or loads and caches it on a miss.
+// Redis failures are logged and fall back to the loader.
+func (c *Entity[T]) Get(ctx context.Context, id string, load func(ctx context.Context) (*T, error)) (*T, error) {
+	if !c.IsEnabled() {
+		return load(ctx)
+	}
+
+	key := c.opts.Prefix + id
+
+	cached, err := c.store.Get(ctx, key)
+	if err != nil {
+		logger.Warn(ctx, fmt.Sprintf("entity cache get %s: %v", key, err))
+	}
+	if cached == notFoundMarker && c.opts.NotFound != nil {
+		return nil, c.opts.NotFound
+	}
+	if cached != "" && cached != notFoundMarker {
+		var entity T
+		if err := json.Unmarshal([]byte(cached), &entity); err == nil {
+			return &entity, nil
+		}
+	}
+
+	// The load is shared by every caller of the key, so one canceled request does not fail the others
+	data, err, _ := c.group.Do(key, func() (interface{}, error) {
+		loadCtx := context.WithoutCancel(ctx)
+
+		// A load that overlapped an invalidation may have read the row before the write,
+		// it is read again and only cached when no invalidation overlapped the second read
+		var entity *T
+		var err error
+		var current bool
+		for range 2 {
+			generation := c.generation(key).Load()
+			entity, err = load(loadCtx)
+			if current = generation == c.generation(key).Load(); current {
+				break
+			}
+		}
+		if err != nil {
+			if current && c.opts.NotFound != nil && c.opts.NegativeTTL > 0 && errors.Is(err, c.opts.NotFound) {
+				c.set(loadCtx, key, notFoundMarker, c.opts.NegativeTTL)
+			}
+			return nil, err
+		}
+
+		data, err := json.Marshal(entity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal entity: %w", err)
+		}
+		if current {
+			c.set(loadCtx, key, string(data), c.opts.TTL)
+		}
+
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every caller gets its own copy, the shared result must not be mutated
+	var entity T
+	if err := json.Unmarshal(data.([]byte), &entity); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entity: %w", err)
+	}
+
+	return &entity, nil
+}
+
+// Invalidate removes the cached entities of ids, including not found results, and removes
+// them again after InvalidateAgain
+func (c *Entity[T]) Invalidate(ctx context.Context, ids ...string) {
+	if !c.IsEnabled() || len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.opts.Prefix + id
+		c.generation(keys[i]).Add(1)
+	}
+
+	c.delete(ctx, keys)
+
+	if c.opts.InvalidateAgain > 0 {
+		ctx := context.WithoutCancel(ctx)
+		time.AfterFunc(c.opts.InvalidateAgain, func() {
+			c.delete(ctx, keys)
+		})
+	}
+}
+
+func (c *Entity[T]) delete(ctx context.Context, keys []string) {
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		logger.Warn(ctx, fmt.Sprintf("entity cache invalidate %v: %v", keys, err))
+	}
+}
+
+// generation returns the invalidation counter of the stripe of key
+func (c *Entity[T]) generation(key string) *atomic.Uint64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &c.generations[h.Sum32()%generationStripes]
+}
+
+func (c *Entity[T]) set(ctx context.Context, key, value string, ttl time.Duration) {
+	if err := c.store.Set(ctx, key, value, ttl); err != nil {
+		logger.Warn(ctx, fmt.Sprintf("entity cache set %s: %v", key, err))
+	}
+}
@@ -0,0 +1,233 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goilerplate/pkg/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("not found")
+
+type item struct {
+	ID   string
+	Name string
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	enabled bool
+	values  map[string]string
+	ttls    map[string]time.Duration
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{enabled: true, values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (s *memoryStore) IsEnabled() bool { return s.enabled }
+
+func (s *memoryStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key], nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.ttls[key] = ttl
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.values, key)
+	}
+	return nil
+}
+
+func TestEntity_Get(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should load once and serve copies from the cache", func(t *testing.T) {
+		store := newMemoryStore()
+		c := cache.NewEntity[item](store, cache.EntityOptions{Prefix: "item:"})
+		var loads int
+		load := func(ctx context.Context) (*item, error) {
+			loads++
+			return &item{ID: "1", Name: "first"}, nil
+		}
+
+		first, err := c.Get(ctx, "1", load)
+		require.NoError(t, err)
+		first.Name = "mutated"
+
+		second, err := c.Get(ctx, "1", load)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, "first", second.Name)
+		assert.Equal(t, cache.DefaultEntityTTL, store.ttls["item:1"])
+	})
+
+	t.Run("should cache not found results", func(t *testing.T) {
+		store := newMemoryStore()
+		c := cache.NewEntity[item](store, cache.EntityOptions{Prefix: "item:", NotFound: errNotFound})
+		var loads int
+		load := func(ctx context.Context) (*item, error) {
+			loads++
+			return nil, errNotFound
+		}
+
+		_, err := c.Get(ctx, "missing", load)
+		assert.ErrorIs(t, err, errNotFound)
+		_, err = c.Get(ctx, "missing", load)
+		assert.ErrorIs(t, err, errNotFound)
+
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, cache.DefaultNegativeTTL, store.ttls["item:missing"])
+	})
+
+	t.Run("should not cache other errors", func(t *testing.T) {
+		store := newMemoryStore()
+		c := cache.NewEntity[item](store, cache.EntityOptions{NotFound: errNotFound})
+
+		_, err := c.Get(ctx, "1", func(ctx context.Context) (*item, error) {
+			return nil, errors.New("connection refused")
+		})
+
+		assert.Error(t, err)
+		assert.Empty(t, store.values)
+	})
+
+	t.Run("should share one load between concurrent misses", func(t *testing.T) {
+		c := cache.NewEntity[item](newMemoryStore(), cache.EntityOptions{})
+		var loads atomic.Int32
+		release := make(chan struct{})
+		load := func(ctx context.Context) (*item, error) {
+			loads.Add(1)
+			<-release
+			return &item{ID: "1"}, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := c.Get(ctx, "1", load)
+				assert.NoError(t, err)
+				assert.Equal(t, "1", got.ID)
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+	})
+
+	t.Run("should read a load overlapping an invalidation again", func(t *testing.T) {
+		store := newMemoryStore()
+		c := cache.NewEntity[item](store, cache.EntityOptions{Prefix: "item:", InvalidateAgain: -1})
+		var loads int
+		load := func(ctx context.Context) (*item, error) {
+			loads++
+			if loads == 1 {
+				// The write commits while the old row is on its way to the cache
+				c.Invalidate(ctx, "1")
+				return &item{ID: "1", Name: "old"}, nil
+			}
+			return &item{ID: "1", Name: "new"}, nil
+		}
+
+		got, err := c.Get(ctx, "1", load)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, loads)
+		assert.Equal(t, "new", got.Name)
+		assert.Contains(t, store.values["item:1"], "new")
+	})
+
+	t.Run("should not cache a load overlapping every read", func(t *testing.T) {
+		store := newMemoryStore()
+		c := cache.NewEntity[item](store, cache.EntityOptions{InvalidateAgain: -1})
+		load := func(ctx context.Context) (*item, error) {
+			c.Invalidate(ctx, "1")
+			return &item{ID: "1"}, nil
+		}
+
+		_, err := c.Get(ctx, "1", load)
+		require.NoError(t, err)
+
+		assert.Empty(t, store.values)
+	})
+
+	t.Run("should call the loader every time without an enabled store", func(t *testing.T) {
+		store := newMemoryStore()
+		store.enabled = false
+		for _, c := range []*cache.Entity[item]{
+			cache.NewEntity[item](nil, cache.EntityOptions{}),
+			cache.NewEntity[item](store, cache.EntityOptions{}),
+		} {
+			var loads int
+			load := func(ctx context.Context) (*item, error) {
+				loads++
+				return &item{ID: "1"}, nil
+			}
+
+			_, _ = c.Get(ctx, "1", load)
+			_, _ = c.Get(ctx, "1", load)
+			c.Invalidate(ctx, "1")
+
+			assert.False(t, c.IsEnabled())
+			assert.Equal(t, 2, loads)
+		}
+	})
+}
+
+func TestEntity_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	c := cache.NewEntity[item](store, cache.EntityOptions{Prefix: "item:", NotFound: errNotFound})
+
+	name := "first"
+	load := func(ctx context.Context) (*item, error) {
+		return &item{ID: "1", Name: name}, nil
+	}
+	_, err := c.Get(ctx, "1", load)
+	require.NoError(t, err)
+
+	name = "second"
+	c.Invalidate(ctx, "1")
+
+	got, err := c.Get(ctx, "1", load)
+	require.NoError(t, err)
+	assert.Equal(t, "second", got.Name)
+}
+
+func TestEntity_InvalidateAgain(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	c := cache.NewEntity[item](store, cache.EntityOptions{Prefix: "item:", InvalidateAgain: 10 * time.Millisecond})
+
+	c.Invalidate(ctx, "1")
+	// Another instance caches the row it read before the write
+	require.NoError(t, store.Set(ctx, "item:1", `{"ID":"1","Name":"old"}`, time.Minute))
+
+	assert.Eventually(t, func() bool {
+		value, _ := store.Get(ctx, "item:1")
+		return value == ""
+	}, time.Second, 5*time.Millisecond)
+}
@@ -16,4 +16,17 @@ const (
 const (
 	HeaderRequestID   = "X-Request-Id"
 	HeaderServiceName = "X-Service-Name"
+
+	// gRPC metadata carrying list sorting and filtering (see pkg/listquery)
+	HeaderSort   = "X-Sort"
+	HeaderFilter = "X-Filter"
+
+	// gRPC metadata carrying the opaque cursor of keyset paginated lists (see pkg/pagination)
+	HeaderCursor     = "X-Cursor"
+	HeaderNextCursor = "X-Next-Cursor"
+	HeaderPrevCursor = "X-Prev-Cursor"
+
+	// gRPC metadata carrying record versions for optimistic concurrency (see pkg/etag)
+	HeaderExpectedVersion = "X-Expected-Version"
+	HeaderVersion         = "X-Version"
 )
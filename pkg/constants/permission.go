@@ -14,11 +14,24 @@ const (
 
 // Bar Resource Permissions
 const (
-	PermissionBarList   = "bar.list"
-	PermissionBarGet    = "bar.get"
-	PermissionBarCreate = "bar.create"
-	PermissionBarUpdate = "bar.update"
-	PermissionBarDelete = "bar.delete"
+	PermissionBarList       = "bar.list"
+	PermissionBarGet        = "bar.get"
+	PermissionBarCreate     = "bar.create"
+	PermissionBarUpdate     = "bar.update"
+	PermissionBarDelete     = "bar.delete"
+	PermissionBarExport     = "bar.export"
+	PermissionBarImport     = "bar.import"
+	PermissionBarTrash      = "bar.trash"
+	PermissionBarRestore    = "bar.restore"
+	PermissionBarHardDelete = "bar.hard_delete"
+)
+
+// File Resource Permissions, users only reach their own files
+const (
+	PermissionFileUpload = "file.upload"
+	PermissionFileList   = "file.list"
+	PermissionFileGet    = "file.get"
+	PermissionFileDelete = "file.delete"
 )
 
 // Add more resource permissions here as needed
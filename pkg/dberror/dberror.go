@@ -0,0 +1,150 @@
+// Package dberror translates Postgres (pgx) and MySQL driver errors into typed errors,
+// so constraint violations and transient failures surface as client errors instead of 500s.
+package dberror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Kinds of translated errors, match them with errors.Is
+var (
+	ErrConflict   = errors.New("unique constraint violation")
+	ErrForeignKey = errors.New("foreign key constraint violation")
+	ErrConstraint = errors.New("not null or check constraint violation")
+	ErrRetryable  = errors.New("serialization failure or deadlock")
+)
+
+// Error is a translated database error
+type Error struct {
+	Kind       error  // one of the Err* kinds above
+	Constraint string // violated constraint, empty when the driver does not report it
+	Err        error  // original driver error
+}
+
+func (e *Error) Error() string {
+	if e.Constraint != "" {
+		return e.Kind.Error() + " (" + e.Constraint + "): " + e.Err.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap exposes both the kind and the driver error to errors.Is / errors.As
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Status returns the HTTP status code of the error kind
+func (e *Error) Status() int {
+	switch e.Kind {
+	case ErrConflict:
+		return http.StatusConflict
+	case ErrForeignKey, ErrConstraint:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+// Message returns a client safe message, driver details are never exposed
+func (e *Error) Message() string {
+	switch e.Kind {
+	case ErrConflict:
+		return "Resource already exists"
+	case ErrForeignKey:
+		return "Referenced resource does not exist or is still in use"
+	case ErrConstraint:
+		return "Missing or invalid value"
+	default:
+		return "Resource is busy, please retry"
+	}
+}
+
+// Postgres SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgNotNullViolation     = "23502"
+	pgCheckViolation       = "23514"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgLockNotAvailable     = "55P03"
+)
+
+// MySQL server error numbers, see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	myDuplicateEntry         = 1062
+	myDuplicateEntryWithKey  = 1586
+	myRowIsReferenced        = 1451
+	myNoReferencedRow        = 1452
+	myRowIsReferencedLegacy  = 1217
+	myNoReferencedRowLegacy  = 1216
+	myColumnCannotBeNull     = 1048
+	myFieldHasNoDefault      = 1364
+	myCheckConstraintViolate = 3819
+	myLockWaitTimeout        = 1205
+	myDeadlock               = 1213
+)
+
+// Translate returns an *Error for known driver errors and err unchanged otherwise.
+// Already translated errors are returned as is.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var translated *Error
+	if errors.As(err, &translated) {
+		return err
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if kind := pgKind(pgErr.Code); kind != nil {
+			return &Error{Kind: kind, Constraint: pgErr.ConstraintName, Err: err}
+		}
+		return err
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		if kind := mysqlKind(myErr.Number); kind != nil {
+			return &Error{Kind: kind, Err: err}
+		}
+	}
+
+	return err
+}
+
+func pgKind(code string) error {
+	switch code {
+	case pgUniqueViolation:
+		return ErrConflict
+	case pgForeignKeyViolation:
+		return ErrForeignKey
+	case pgNotNullViolation, pgCheckViolation:
+		return ErrConstraint
+	case pgSerializationFailure, pgDeadlockDetected, pgLockNotAvailable:
+		return ErrRetryable
+	default:
+		return nil
+	}
+}
+
+func mysqlKind(number uint16) error {
+	switch number {
+	case myDuplicateEntry, myDuplicateEntryWithKey:
+		return ErrConflict
+	case myRowIsReferenced, myNoReferencedRow, myRowIsReferencedLegacy, myNoReferencedRowLegacy:
+		return ErrForeignKey
+	case myColumnCannotBeNull, myFieldHasNoDefault, myCheckConstraintViolate:
+		return ErrConstraint
+	case myLockWaitTimeout, myDeadlock:
+		return ErrRetryable
+	default:
+		return nil
+	}
+}
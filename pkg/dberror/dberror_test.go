@@ -0,0 +1,68 @@
+package dberror_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"goilerplate/pkg/dberror"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		kind   error
+		status int
+	}{
+		"postgres unique violation":      {&pgconn.PgError{Code: "23505", ConstraintName: "bars_code_key"}, dberror.ErrConflict, http.StatusConflict},
+		"postgres foreign key violation": {&pgconn.PgError{Code: "23503"}, dberror.ErrForeignKey, http.StatusUnprocessableEntity},
+		"postgres not null violation":    {&pgconn.PgError{Code: "23502"}, dberror.ErrConstraint, http.StatusUnprocessableEntity},
+		"postgres serialization failure": {&pgconn.PgError{Code: "40001"}, dberror.ErrRetryable, http.StatusServiceUnavailable},
+		"postgres deadlock":              {&pgconn.PgError{Code: "40P01"}, dberror.ErrRetryable, http.StatusServiceUnavailable},
+		"mysql duplicate entry":          {&mysql.MySQLError{Number: 1062}, dberror.ErrConflict, http.StatusConflict},
+		"mysql missing parent row":       {&mysql.MySQLError{Number: 1452}, dberror.ErrForeignKey, http.StatusUnprocessableEntity},
+		"mysql column cannot be null":    {&mysql.MySQLError{Number: 1048}, dberror.ErrConstraint, http.StatusUnprocessableEntity},
+		"mysql deadlock":                 {&mysql.MySQLError{Number: 1213}, dberror.ErrRetryable, http.StatusServiceUnavailable},
+		"wrapped driver error":           {fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}), dberror.ErrConflict, http.StatusConflict},
+	}
+	for name, tt := range tests {
+		t.Run("should translate "+name, func(t *testing.T) {
+			err := dberror.Translate(tt.err)
+
+			var dbErr *dberror.Error
+			require.ErrorAs(t, err, &dbErr)
+			assert.ErrorIs(t, err, tt.kind)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.status, dbErr.Status())
+			assert.NotContains(t, dbErr.Message(), "23505")
+		})
+	}
+
+	t.Run("should keep the constraint name", func(t *testing.T) {
+		var dbErr *dberror.Error
+		require.ErrorAs(t, dberror.Translate(&pgconn.PgError{Code: "23505", ConstraintName: "bars_code_key"}), &dbErr)
+
+		assert.Equal(t, "bars_code_key", dbErr.Constraint)
+	})
+
+	t.Run("should return other errors unchanged", func(t *testing.T) {
+		plain := errors.New("connection refused")
+		syntax := &pgconn.PgError{Code: "42601"}
+
+		assert.Nil(t, dberror.Translate(nil))
+		assert.Same(t, plain, dberror.Translate(plain))
+		assert.Same(t, syntax, dberror.Translate(syntax))
+	})
+
+	t.Run("should not translate twice", func(t *testing.T) {
+		once := dberror.Translate(&pgconn.PgError{Code: "23505"})
+
+		assert.Same(t, once, dberror.Translate(once))
+	})
+}
@@ -0,0 +1,87 @@
+// Package etag maps record versions to ETag / If-Match headers and to gRPC metadata
+// for optimistic concurrency control.
+package etag
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	ErrRequired = utils.ClientErr(428, "If-Match header is required")
+	ErrMismatch = utils.ClientErr(412, "Resource was modified, fetch it again and retry")
+)
+
+// Format returns the strong ETag of a version, e.g. "3"
+func Format(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ParseIfMatch returns the version of an If-Match header value. An empty value or "*"
+// returns 0, meaning no check. Weak tags are accepted. A value that is not a single
+// version tag can never match and returns ErrMismatch.
+func ParseIfMatch(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, ErrMismatch
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrMismatch
+	}
+
+	return version, nil
+}
+
+// ExpectedVersion parses an If-Match header value, when required is set a missing
+// value returns ErrRequired
+func ExpectedVersion(value string, required bool) (int64, error) {
+	if required && strings.TrimSpace(value) == "" {
+		return 0, ErrRequired
+	}
+	return ParseIfMatch(value)
+}
+
+// ExpectedVersionFromMetadata reads the x-expected-version gRPC metadata, the gRPC
+// counterpart of If-Match. The protos have no version field.
+func ExpectedVersionFromMetadata(ctx context.Context, required bool) (int64, error) {
+	var value string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(constants.HeaderExpectedVersion); len(values) > 0 {
+			value = strings.TrimSpace(values[0])
+		}
+	}
+
+	if value == "" {
+		if required {
+			return 0, utils.ClientErr(428, "x-expected-version metadata is required")
+		}
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, utils.ClientErr(400, "x-expected-version must be a positive integer")
+	}
+
+	return version, nil
+}
+
+// SetVersionHeader sends the record version as the x-version gRPC response header
+func SetVersionHeader(ctx context.Context, version int64) error {
+	return grpc.SetHeader(ctx, metadata.Pairs(constants.HeaderVersion, strconv.FormatInt(version, 10)))
+}
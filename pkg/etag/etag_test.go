@@ -0,0 +1,85 @@
+package etag_test
+
+import (
+	"context"
+	"testing"
+
+	"goilerplate/pkg/etag"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for value, want := range map[string]int64{
+		``:       0,
+		`*`:      0,
+		`"3"`:    3,
+		`W/"12"`: 12,
+		` "7" `:  7,
+	} {
+		t.Run("should parse "+value, func(t *testing.T) {
+			version, err := etag.ParseIfMatch(value)
+
+			require.NoError(t, err)
+			assert.Equal(t, want, version)
+		})
+	}
+
+	for _, value := range []string{`3`, `"abc"`, `"0"`, `"1", "2"`} {
+		t.Run("should never match "+value, func(t *testing.T) {
+			_, err := etag.ParseIfMatch(value)
+			assert.ErrorIs(t, err, etag.ErrMismatch)
+		})
+	}
+
+	t.Run("should round trip", func(t *testing.T) {
+		version, err := etag.ParseIfMatch(etag.Format(42))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), version)
+	})
+}
+
+func TestExpectedVersion(t *testing.T) {
+	t.Run("should require the header", func(t *testing.T) {
+		_, err := etag.ExpectedVersion("", true)
+		assert.ErrorIs(t, err, etag.ErrRequired)
+	})
+
+	t.Run("should accept a wildcard when required", func(t *testing.T) {
+		version, err := etag.ExpectedVersion("*", true)
+
+		require.NoError(t, err)
+		assert.Zero(t, version)
+	})
+}
+
+func TestExpectedVersionFromMetadata(t *testing.T) {
+	withVersion := func(value string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-expected-version", value))
+	}
+
+	t.Run("should read the version", func(t *testing.T) {
+		version, err := etag.ExpectedVersionFromMetadata(withVersion("5"), true)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), version)
+	})
+
+	t.Run("should skip the check when optional", func(t *testing.T) {
+		version, err := etag.ExpectedVersionFromMetadata(context.Background(), false)
+
+		require.NoError(t, err)
+		assert.Zero(t, version)
+	})
+
+	t.Run("should reject a missing or invalid version", func(t *testing.T) {
+		_, err := etag.ExpectedVersionFromMetadata(context.Background(), true)
+		assert.Error(t, err)
+
+		_, err = etag.ExpectedVersionFromMetadata(withVersion("x"), false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,194 @@
+// Package fieldset parses sparse fieldsets (fields=id,code) and related resource
+// expansions (expand=creator) of list and get endpoints against a per-resource allowlist.
+package fieldset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"goilerplate/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits protecting the parser from oversized parameters
+const (
+	MaxFields  = 50
+	MaxExpands = 5
+)
+
+// Expansion describes a related resource clients may embed
+type Expansion struct {
+	Columns []string // columns of the resource the relation needs, e.g. created_by
+}
+
+// Allowlist maps public field names (the JSON names of the response) to their column for one resource
+type Allowlist struct {
+	Fields     map[string]string
+	Expansions map[string]Expansion
+	Always     []string // public fields returned even when not selected, e.g. id
+}
+
+// Selection is the parsed fields and expand parameters of a request
+type Selection struct {
+	Fields []string // selected public fields, empty = every field
+	Expand []string // requested expansions
+
+	columns []string
+}
+
+// Parse parses comma separated fields and expand values. Unknown names are rejected with a 400 client error.
+func Parse(fields, expand string, allowlist Allowlist) (*Selection, error) {
+	s := &Selection{}
+
+	names := split(fields)
+	if len(names) > MaxFields {
+		return nil, utils.ClientErr(400, fmt.Sprintf("too many fields, maximum is %d", MaxFields))
+	}
+	if len(names) > 0 {
+		for _, name := range allowlist.Always {
+			s.addField(name, allowlist.Fields[name])
+		}
+	}
+	for _, name := range names {
+		column, ok := allowlist.Fields[name]
+		if !ok {
+			return nil, utils.ClientErr(400, fmt.Sprintf("field '%s' is not allowed", name))
+		}
+		s.addField(name, column)
+	}
+
+	expands := split(expand)
+	if len(expands) > MaxExpands {
+		return nil, utils.ClientErr(400, fmt.Sprintf("too many expansions, maximum is %d", MaxExpands))
+	}
+	for _, name := range expands {
+		expansion, ok := allowlist.Expansions[name]
+		if !ok {
+			return nil, utils.ClientErr(400, fmt.Sprintf("expanding '%s' is not allowed", name))
+		}
+		if !s.Expands(name) {
+			s.Expand = append(s.Expand, name)
+			s.columns = append(s.columns, expansion.Columns...)
+		}
+	}
+
+	return s, nil
+}
+
+// ParseHTTP parses the fields and expand query parameters
+func ParseHTTP(ctx *fiber.Ctx, allowlist Allowlist) (*Selection, error) {
+	return Parse(ctx.Query("fields"), ctx.Query("expand"), allowlist)
+}
+
+// IsEmpty reports whether the client selected neither fields nor expansions
+func (s *Selection) IsEmpty() bool {
+	return s == nil || (len(s.Fields) == 0 && len(s.Expand) == 0)
+}
+
+// Expands reports whether the client asked to expand name
+func (s *Selection) Expands(name string) bool {
+	if s == nil {
+		return false
+	}
+	for _, expand := range s.Expand {
+		if expand == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Columns returns the columns to select: those of the selected fields, or defaults when the
+// client selected none, the columns needed by the requested expansions and required
+func (s *Selection) Columns(defaults []string, required ...string) []string {
+	var columns []string
+	seen := make(map[string]bool)
+	add := func(names ...string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				columns = append(columns, name)
+			}
+		}
+	}
+
+	if s == nil || len(s.Fields) == 0 {
+		add(defaults...)
+	}
+	if s != nil {
+		add(s.columns...)
+	}
+	add(required...)
+
+	return columns
+}
+
+// Project narrows a response DTO to the selected fields and expansions.
+// Without selected fields v is returned unchanged.
+func (s *Selection) Project(v interface{}) (interface{}, error) {
+	if s == nil || len(s.Fields) == 0 {
+		return v, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, utils.WrapErr(err)
+	}
+
+	for key := range item {
+		if !s.keeps(key) {
+			delete(item, key)
+		}
+	}
+
+	return item, nil
+}
+
+// ProjectList narrows every item of a list response, see Project
+func ProjectList[T any](s *Selection, items []T) ([]interface{}, error) {
+	out := make([]interface{}, len(items))
+	for i, item := range items {
+		projected, err := s.Project(item)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = projected
+	}
+	return out, nil
+}
+
+func (s *Selection) keeps(name string) bool {
+	for _, field := range s.Fields {
+		if field == name {
+			return true
+		}
+	}
+	return s.Expands(name)
+}
+
+func (s *Selection) addField(name, column string) {
+	for _, field := range s.Fields {
+		if field == name {
+			return
+		}
+	}
+	s.Fields = append(s.Fields, name)
+	s.columns = append(s.columns, column)
+}
+
+func split(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package fieldset_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"goilerplate/pkg/fieldset"
+	"goilerplate/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allowlist = fieldset.Allowlist{
+	Fields: map[string]string{
+		"id":   "id",
+		"code": "code",
+		"name": "bar",
+	},
+	Expansions: map[string]fieldset.Expansion{
+		"creator": {Columns: []string{"created_by"}},
+	},
+	Always: []string{"id"},
+}
+
+type creator struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type item struct {
+	ID      string   `json:"id"`
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Creator *creator `json:"creator,omitempty"`
+}
+
+func TestParse(t *testing.T) {
+	t.Run("should always include the always fields and dedupe names", func(t *testing.T) {
+		s, err := fieldset.Parse(" code, code,name ", "creator,creator", allowlist)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "code", "name"}, s.Fields)
+		assert.Equal(t, []string{"creator"}, s.Expand)
+		assert.True(t, s.Expands("creator"))
+	})
+
+	t.Run("should return an empty selection without parameters", func(t *testing.T) {
+		s, err := fieldset.Parse("", "", allowlist)
+
+		require.NoError(t, err)
+		assert.True(t, s.IsEmpty())
+	})
+
+	tests := []struct {
+		name   string
+		fields string
+		expand string
+	}{
+		{name: "unknown field", fields: "code,secret"},
+		{name: "column instead of field", fields: "bar"},
+		{name: "unknown expansion", expand: "owner"},
+		{name: "too many expansions", expand: "creator,creator,creator,creator,creator,creator"},
+	}
+	for _, tt := range tests {
+		t.Run("should reject "+tt.name, func(t *testing.T) {
+			_, err := fieldset.Parse(tt.fields, tt.expand, allowlist)
+
+			var clientErr *utils.ClientError
+			require.ErrorAs(t, err, &clientErr)
+			assert.Equal(t, 400, clientErr.Code)
+		})
+	}
+}
+
+func TestSelection_Columns(t *testing.T) {
+	defaults := []string{"id", "code", "bar"}
+
+	t.Run("should select the defaults without a selection", func(t *testing.T) {
+		var s *fieldset.Selection
+
+		assert.Equal(t, []string{"id", "code", "bar", "version"}, s.Columns(defaults, "version"))
+	})
+
+	t.Run("should select the columns of the fields and expansions", func(t *testing.T) {
+		s, err := fieldset.Parse("name", "creator", allowlist)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"id", "bar", "created_by", "created_at"}, s.Columns(defaults, "created_at", "id"))
+	})
+
+	t.Run("should add expansion columns to the defaults", func(t *testing.T) {
+		s, err := fieldset.Parse("", "creator", allowlist)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"id", "code", "bar", "created_by"}, s.Columns(defaults))
+	})
+}
+
+func TestSelection_Project(t *testing.T) {
+	v := item{ID: "1", Code: "EXP1", Name: "Example", Creator: &creator{ID: "u1", Name: "Jane"}}
+
+	t.Run("should return the value unchanged without fields", func(t *testing.T) {
+		s, err := fieldset.Parse("", "creator", allowlist)
+		require.NoError(t, err)
+
+		projected, err := s.Project(v)
+
+		require.NoError(t, err)
+		assert.Equal(t, v, projected)
+	})
+
+	t.Run("should keep the selected fields and expansions", func(t *testing.T) {
+		s, err := fieldset.Parse("code", "creator", allowlist)
+		require.NoError(t, err)
+
+		projected, err := fieldset.ProjectList(s, []item{v})
+
+		require.NoError(t, err)
+		require.Len(t, projected, 1)
+		assert.JSONEq(t, `{"id":"1","code":"EXP1","creator":{"id":"u1","name":"Jane"}}`, toJSON(t, projected[0]))
+	})
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return string(raw)
+}
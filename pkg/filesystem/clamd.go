@@ -0,0 +1,113 @@
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultClamdTimeout bounds a scan, including the transfer of the content, within the deadline of its context
+const DefaultClamdTimeout = 30 * time.Second
+
+// clamdChunkSize is the size of the chunks streamed to clamd, well below its StreamMaxLength
+const clamdChunkSize = 64 * 1024
+
+// ClamdScanner scans content with a clamd daemon through its INSTREAM command
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner connecting to clamd on every scan
+func NewClamdScanner(cfg ClamdConfig) *ClamdScanner {
+	network := cfg.Network
+	if network == "" {
+		network = "tcp"
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultClamdTimeout
+	}
+
+	return &ClamdScanner{
+		network: network,
+		address: cfg.Address,
+		timeout: timeout,
+	}
+}
+
+// Scan streams r to clamd as length prefixed chunks and parses its verdict
+func (c *ClamdScanner) Scan(ctx context.Context, r io.Reader) (*ScanResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The deadline bounds the transfer, closing the connection aborts it on cancellation
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set clamd deadline: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to send command to clamd: %w", err)
+	}
+
+	// Every chunk is prefixed with its length
+	chunk := make([]byte, 4+clamdChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, chunk[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk, uint32(n))
+			if _, err := conn.Write(chunk[:4+n]); err != nil {
+				// clamd closes the connection when the stream exceeds its limit, its reply says so
+				break
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+
+	// A zero length chunk ends the stream
+	_, _ = conn.Write([]byte{0, 0, 0, 0})
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamdReply parses "stream: OK", "stream: <threat> FOUND" and "<message> ERROR"
+func parseClamdReply(reply string) (*ScanResult, error) {
+	_, verdict, _ := strings.Cut(reply, ": ")
+
+	switch {
+	case strings.HasSuffix(reply, " ERROR"):
+		return nil, fmt.Errorf("clamd failed to scan: %s", reply)
+	case verdict == "OK":
+		return &ScanResult{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &ScanResult{Infected: true, Threat: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+}
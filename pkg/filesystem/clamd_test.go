@@ -0,0 +1,127 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eicar is the standard antivirus test signature
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// clamdStub serves INSTREAM scans like clamd on a local port, reply gets the streamed content
+func clamdStub(t *testing.T, reply func(content []byte) string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				reader := bufio.NewReader(conn)
+				if command, err := reader.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+					_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+
+				var content bytes.Buffer
+				size := make([]byte, 4)
+				for {
+					if _, err := io.ReadFull(reader, size); err != nil {
+						return
+					}
+					n := binary.BigEndian.Uint32(size)
+					if n == 0 {
+						break
+					}
+					if _, err := io.CopyN(&content, reader, int64(n)); err != nil {
+						return
+					}
+				}
+
+				_, _ = conn.Write([]byte(reply(content.Bytes()) + "\x00"))
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// eicarReply flags content carrying the EICAR signature
+func eicarReply(content []byte) string {
+	if bytes.Contains(content, []byte(eicar)) {
+		return "stream: Eicar-Test-Signature FOUND"
+	}
+	return "stream: OK"
+}
+
+func TestClamdScanner_Scan(t *testing.T) {
+	ctx := context.Background()
+	scanner := NewClamdScanner(ClamdConfig{Address: clamdStub(t, eicarReply)})
+
+	t.Run("should pass clean content", func(t *testing.T) {
+		result, err := scanner.Scan(ctx, strings.NewReader("hello world"))
+
+		require.NoError(t, err)
+		assert.False(t, result.Infected)
+	})
+
+	t.Run("should flag infected content spanning several chunks", func(t *testing.T) {
+		content := append(bytes.Repeat([]byte("a"), clamdChunkSize-10), eicar...)
+
+		result, err := scanner.Scan(ctx, bytes.NewReader(content))
+
+		require.NoError(t, err)
+		assert.True(t, result.Infected)
+		assert.Equal(t, "Eicar-Test-Signature", result.Threat)
+	})
+
+	t.Run("should fail when clamd reports an error", func(t *testing.T) {
+		failing := NewClamdScanner(ClamdConfig{Address: clamdStub(t, func([]byte) string {
+			return "INSTREAM size limit exceeded. ERROR"
+		})})
+
+		_, err := failing.Scan(ctx, strings.NewReader("hello world"))
+
+		assert.ErrorContains(t, err, "size limit exceeded")
+	})
+
+	t.Run("should fail when clamd is unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		_, err = NewClamdScanner(ClamdConfig{Address: address}).Scan(ctx, strings.NewReader("hello"))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNewScannerFromConfig(t *testing.T) {
+	scanner, err := NewScannerFromConfig(ScanConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, scanner)
+
+	_, err = NewScannerFromConfig(ScanConfig{Driver: "clamd"})
+	assert.Error(t, err, "clamd needs an address")
+
+	_, err = NewScannerFromConfig(ScanConfig{Driver: "sophos"})
+	assert.Error(t, err)
+}
@@ -1,26 +1,58 @@
 package filesystem
 
+import "errors"
+
+// DefaultDisk names the disk configured by the top level driver of Config
+const DefaultDisk = "default"
+
+// ErrUnknownDisk is returned for a disk name that is not configured
+var ErrUnknownDisk = errors.New("unknown disk")
+
 // Config holds filesystem configuration
 type Config struct {
-	Driver Driver
-	Local  LocalConfig
-	S3     S3Config
-	Drive  DriveConfig
+	Driver     Driver
+	Local      LocalConfig
+	S3         S3Config
+	Drive      DriveConfig
+	Images     ImageConfig
+	Scan       ScanConfig
+	Encryption EncryptionConfig      // encryption at rest of the default disk
+	Mirror     string                // disk receiving a copy of every write to the default disk
+	Disks      map[string]DiskConfig // named disks, they share the image variants and scanner
+}
+
+// DiskConfig configures a named disk
+type DiskConfig struct {
+	Driver     Driver           `mapstructure:"driver"` // local, s3, drive, memory
+	Local      LocalConfig      `mapstructure:"local"`
+	S3         S3Config         `mapstructure:"s3"`
+	Drive      DriveConfig      `mapstructure:"drive"`
+	Encryption EncryptionConfig `mapstructure:"encryption"` // encryption at rest, disabled when key_id is empty
+	Mirror     string           `mapstructure:"mirror"`     // disk receiving a copy of every write, e.g. during a migration
+}
+
+// config returns the driver configuration of the disk
+func (d DiskConfig) config() Config {
+	return Config{Driver: d.Driver, Local: d.Local, S3: d.S3, Drive: d.Drive, Encryption: d.Encryption}
 }
 
 // LocalConfig for local filesystem storage
 type LocalConfig struct {
-	BasePath string `mapstructure:"base_path"`
-	BaseURL  string `mapstructure:"base_url"`
+	BasePath   string `mapstructure:"base_path"`
+	BaseURL    string `mapstructure:"base_url"`
+	SigningKey string `mapstructure:"signing_key"` // HMAC key of signed URLs, empty = disabled
+	SignedURL  string `mapstructure:"signed_url"`  // route serving signed URLs, e.g. http://localhost:3000/storage/signed
 }
 
-// S3Config for AWS S3 storage
+// S3Config for AWS S3 and S3-compatible storage such as MinIO
 type S3Config struct {
-	AccessKeyID     string `mapstructure:"access_key_id"`
+	AccessKeyID     string `mapstructure:"access_key_id"` // static credentials, empty = default AWS credential chain
 	SecretAccessKey string `mapstructure:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"` // temporary credentials only
 	Region          string `mapstructure:"region"`
 	Bucket          string `mapstructure:"bucket"`
-	Endpoint        string `mapstructure:"endpoint"`
+	Endpoint        string `mapstructure:"endpoint"`       // custom endpoint, e.g. http://localhost:9000
+	UsePathStyle    bool   `mapstructure:"use_path_style"` // address the bucket in the path (MinIO) instead of the host
 }
 
 // DriveConfig for Google Drive storage
@@ -0,0 +1,230 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runConformance exercises the behavior every Storage driver shares. Files are written below
+// a unique prefix and addressed by the paths the driver returns, S3 and Drive choose their own.
+func runConformance(t *testing.T, storage Storage) {
+	ctx := context.Background()
+	prefix := "conformance-" + uuid.New().String()[:8]
+
+	upload := func(t *testing.T, folder, filename, content string) *UploadResult {
+		t.Helper()
+		result, err := storage.UploadFromReader(ctx, strings.NewReader(content), filename, UploadOptions{Path: folder})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = storage.Delete(ctx, result.Path) })
+		return result
+	}
+
+	read := func(t *testing.T, path string) string {
+		t.Helper()
+		reader, err := storage.Open(ctx, path)
+		require.NoError(t, err)
+		defer func() { _ = reader.Close() }()
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	first := upload(t, prefix, "first.txt", "hello")
+	nested := upload(t, prefix+"/nested", "second.txt", "hello world")
+	other := upload(t, prefix+"-other", "third.txt", "elsewhere")
+
+	t.Run("should open the content", func(t *testing.T) {
+		assert.Equal(t, "hello", read(t, first.Path))
+	})
+
+	t.Run("should stat a file", func(t *testing.T) {
+		info, err := storage.Stat(ctx, nested.Path)
+
+		require.NoError(t, err)
+		assert.Equal(t, nested.Path, info.Path)
+		assert.NotEmpty(t, info.Name)
+		assert.Equal(t, int64(11), info.Size)
+		assert.False(t, info.ModTime.IsZero())
+
+		size, err := storage.Size(ctx, nested.Path)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), size)
+	})
+
+	t.Run("should list the files below a prefix", func(t *testing.T) {
+		files, err := storage.List(ctx, prefix+"/")
+
+		require.NoError(t, err)
+		var paths []string
+		for _, file := range files {
+			paths = append(paths, file.Path)
+		}
+		assert.ElementsMatch(t, []string{first.Path, nested.Path}, paths)
+		assert.NotContains(t, paths, other.Path)
+	})
+
+	t.Run("should list nothing for an unknown prefix", func(t *testing.T) {
+		files, err := storage.List(ctx, prefix+"-missing/")
+
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("should copy a file and keep the source", func(t *testing.T) {
+		copied, err := storage.Copy(ctx, first.Path, prefix+"/copies/first.txt")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = storage.Delete(ctx, copied.Path) })
+
+		assert.Equal(t, int64(5), copied.Size)
+		assert.Equal(t, "hello", read(t, copied.Path))
+		assert.Equal(t, "hello", read(t, first.Path))
+	})
+
+	t.Run("should move a file", func(t *testing.T) {
+		source, err := storage.Copy(ctx, first.Path, prefix+"/moving/from.txt")
+		require.NoError(t, err)
+
+		moved, err := storage.Move(ctx, source.Path, prefix+"/moving/to.txt")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = storage.Delete(ctx, moved.Path) })
+
+		assert.Equal(t, "hello", read(t, moved.Path))
+		// Drive renames in place, the ID stays the same
+		if moved.Path != source.Path {
+			exists, err := storage.Exists(ctx, source.Path)
+			require.NoError(t, err)
+			assert.False(t, exists)
+		}
+	})
+
+	t.Run("should report missing files", func(t *testing.T) {
+		missing := prefix + "/missing.txt"
+
+		_, err := storage.Open(ctx, missing)
+		assert.ErrorIs(t, err, ErrFileNotFound)
+		_, err = storage.Stat(ctx, missing)
+		assert.ErrorIs(t, err, ErrFileNotFound)
+		_, err = storage.Copy(ctx, missing, prefix+"/copies/missing.txt")
+		assert.ErrorIs(t, err, ErrFileNotFound)
+		_, err = storage.Move(ctx, missing, prefix+"/copies/missing.txt")
+		assert.ErrorIs(t, err, ErrFileNotFound)
+
+		exists, err := storage.Exists(ctx, missing)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should delete a file", func(t *testing.T) {
+		require.NoError(t, storage.Delete(ctx, other.Path))
+
+		exists, err := storage.Exists(ctx, other.Path)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("should abort a canceled upload", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := storage.UploadFromReader(canceled, strings.NewReader("hello"), "canceled.txt", UploadOptions{Path: prefix + "/canceled"})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		files, err := storage.List(ctx, prefix+"/canceled/")
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+}
+
+func TestLocalStorage_Conformance(t *testing.T) {
+	runConformance(t, NewLocalStorage(t.TempDir(), "http://localhost/storage"))
+}
+
+func TestLocalStorage_StaysInBasePath(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	base := filepath.Join(root, "storage")
+	storage := NewLocalStorage(base, "http://localhost/storage")
+
+	result, err := storage.UploadFromReader(ctx, strings.NewReader("hello"), "../escape.txt", UploadOptions{Path: "../../files"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "escape.txt", result.Path)
+	assert.FileExists(t, filepath.Join(base, "escape.txt"))
+	assert.NoFileExists(t, filepath.Join(root, "escape.txt"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "outside.txt"), []byte("keep"), 0644))
+	exists, err := storage.Exists(ctx, "../outside.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Error(t, storage.Delete(ctx, "../outside.txt"))
+	assert.FileExists(t, filepath.Join(root, "outside.txt"))
+
+	url, err := storage.URL(ctx, "../files/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/storage/files/a.txt", url)
+}
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	runConformance(t, NewMemoryStorage())
+}
+
+// TestS3Storage_Conformance runs against a real bucket, set FILESYSTEM_TEST_S3_BUCKET to enable it.
+// With FILESYSTEM_TEST_S3_ENDPOINT (e.g. MinIO, see make test-s3) the bucket is created if missing.
+func TestS3Storage_Conformance(t *testing.T) {
+	bucket := os.Getenv("FILESYSTEM_TEST_S3_BUCKET")
+	if bucket == "" {
+		t.Skip("FILESYSTEM_TEST_S3_BUCKET is not set")
+	}
+
+	ctx := context.Background()
+	pathStyle, _ := strconv.ParseBool(os.Getenv("FILESYSTEM_TEST_S3_PATH_STYLE"))
+	storage, err := NewS3Storage(ctx, S3Config{
+		AccessKeyID:     os.Getenv("FILESYSTEM_TEST_S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("FILESYSTEM_TEST_S3_SECRET_ACCESS_KEY"),
+		Region:          os.Getenv("FILESYSTEM_TEST_S3_REGION"),
+		Bucket:          bucket,
+		Endpoint:        os.Getenv("FILESYSTEM_TEST_S3_ENDPOINT"),
+		UsePathStyle:    pathStyle,
+	})
+	require.NoError(t, err)
+
+	if storage.endpoint != "" {
+		_, err := storage.client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+		var owned *types.BucketAlreadyOwnedByYou
+		if err != nil && !errors.As(err, &owned) {
+			require.NoError(t, err)
+		}
+	}
+
+	runConformance(t, storage)
+}
+
+// TestDriveStorage_Conformance runs against a real folder, set FILESYSTEM_TEST_DRIVE_FOLDER_ID
+// and FILESYSTEM_TEST_DRIVE_CREDENTIALS_FILE to enable it
+func TestDriveStorage_Conformance(t *testing.T) {
+	folderID := os.Getenv("FILESYSTEM_TEST_DRIVE_FOLDER_ID")
+	if folderID == "" {
+		t.Skip("FILESYSTEM_TEST_DRIVE_FOLDER_ID is not set")
+	}
+
+	storage, err := NewDriveStorage(context.Background(), DriveConfig{
+		CredentialsFile: os.Getenv("FILESYSTEM_TEST_DRIVE_CREDENTIALS_FILE"),
+		FolderID:        folderID,
+	})
+	require.NoError(t, err)
+
+	runConformance(t, storage)
+}
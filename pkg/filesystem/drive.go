@@ -2,13 +2,20 @@ package filesystem
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -16,7 +23,6 @@ import (
 type DriveStorage struct {
 	service      *drive.Service
 	folderID     string
-	ctx          context.Context
 	tokenManager *TokenManager // For OAuth token management
 	useOAuth     bool          // Flag to indicate OAuth vs Service Account
 }
@@ -89,14 +95,13 @@ func NewDriveStorage(ctx context.Context, cfg DriveConfig) (*DriveStorage, error
 	return &DriveStorage{
 		service:      service,
 		folderID:     cfg.FolderID,
-		ctx:          ctx,
 		tokenManager: tokenManager,
 		useOAuth:     useOAuth,
 	}, nil
 }
 
 // Upload uploads file from multipart form
-func (d *DriveStorage) Upload(file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+func (d *DriveStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
 	if err := validateUpload(file.Size, file.Header.Get("Content-Type"), opts); err != nil {
 		return nil, err
 	}
@@ -117,7 +122,7 @@ func (d *DriveStorage) Upload(file *multipart.FileHeader, opts UploadOptions) (*
 	}
 
 	// Pass file metadata to avoid extra API calls
-	result, err := d.uploadWithMetadata(src, filename, file.Size, file.Header.Get("Content-Type"), opts)
+	result, err := d.uploadWithMetadata(ctx, src, filename, file.Size, file.Header.Get("Content-Type"), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -129,39 +134,41 @@ func (d *DriveStorage) Upload(file *multipart.FileHeader, opts UploadOptions) (*
 }
 
 // UploadFromReader uploads from io.Reader (without known size/mimeType)
-func (d *DriveStorage) UploadFromReader(reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
-	return d.uploadWithMetadata(reader, filename, 0, "", opts)
+func (d *DriveStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	return d.uploadWithMetadata(ctx, reader, filename, 0, "", opts)
 }
 
 // uploadWithMetadata performs the actual upload with known metadata to avoid extra API calls
-func (d *DriveStorage) uploadWithMetadata(reader io.Reader, filename string, fileSize int64, mimeType string, opts UploadOptions) (*UploadResult, error) {
-	// Detect MIME type if not provided
-	if mimeType == "" {
-		mimeType = detectMimeType(filename)
+func (d *DriveStorage) uploadWithMetadata(ctx context.Context, reader io.Reader, filename string, fileSize int64, mimeType string, opts UploadOptions) (*UploadResult, error) {
+	// Prefer the sniffed type, detect it from the extension if not provided
+	if opts.ContentType != "" || mimeType == "" {
+		mimeType = contentTypeOf(filename, opts)
 	}
 
+	// The folder is flat, opts.Path is kept as a prefix of the name so List can match it
 	fileMetadata := &drive.File{
-		Name:    filename,
+		Name:    path.Join(opts.Path, filename),
 		Parents: []string{d.folderID},
 	}
 
 	driveFile, err := d.service.Files.Create(fileMetadata).
 		Media(reader).
 		SupportsAllDrives(true).
-		Context(d.ctx).
+		Context(ctx).
 		Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload to drive: %w", err)
 	}
 
-	// Make public asynchronously (don't block upload response)
+	// Make public asynchronously (don't block upload response), outliving the request
 	if opts.Public {
+		ctx := context.WithoutCancel(ctx)
 		go func(fileID string) {
 			permission := &drive.Permission{
 				Type: "anyone",
 				Role: "reader",
 			}
-			_, err := d.service.Permissions.Create(fileID, permission).SupportsAllDrives(true).Context(d.ctx).Do()
+			_, err := d.service.Permissions.Create(fileID, permission).SupportsAllDrives(true).Context(ctx).Do()
 			if err != nil {
 				// Log warning but don't fail the upload
 				fmt.Printf("Warning: failed to set public permission for %s: %v\n", fileID, err)
@@ -190,27 +197,148 @@ func (d *DriveStorage) uploadWithMetadata(reader io.Reader, filename string, fil
 }
 
 // Delete deletes a file
-func (d *DriveStorage) Delete(fileID string) error {
-	if err := d.service.Files.Delete(fileID).SupportsAllDrives(true).Context(d.ctx).Do(); err != nil {
+func (d *DriveStorage) Delete(ctx context.Context, fileID string) error {
+	if err := d.service.Files.Delete(fileID).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
 		return fmt.Errorf("failed to delete from drive: %w", err)
 	}
 	return nil
 }
 
 // Exists checks if file exists
-func (d *DriveStorage) Exists(fileID string) (bool, error) {
-	_, err := d.service.Files.Get(fileID).SupportsAllDrives(true).Context(d.ctx).Do()
+func (d *DriveStorage) Exists(ctx context.Context, fileID string) (bool, error) {
+	_, err := d.service.Files.Get(fileID).SupportsAllDrives(true).Context(ctx).Do()
 	if err != nil {
-		return false, fmt.Errorf("failed to check file: %w", err)
+		if err = driveErr("failed to check file", err); err == ErrFileNotFound {
+			return false, nil
+		}
+		return false, err
 	}
 	return true, nil
 }
 
+// Open downloads the content of a file
+func (d *DriveStorage) Open(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	resp, err := d.service.Files.Get(fileID).SupportsAllDrives(true).Context(ctx).Download()
+	if err != nil {
+		return nil, driveErr("failed to open file", err)
+	}
+	return resp.Body, nil
+}
+
+// Stat describes a file
+func (d *DriveStorage) Stat(ctx context.Context, fileID string) (*FileInfo, error) {
+	file, err := d.service.Files.Get(fileID).
+		Fields(driveFileFields).
+		SupportsAllDrives(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, driveErr("failed to stat file", err)
+	}
+	return driveFileInfo(file), nil
+}
+
+// Size returns the size of a file in bytes
+func (d *DriveStorage) Size(ctx context.Context, fileID string) (int64, error) {
+	info, err := d.Stat(ctx, fileID)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// List returns the files of the folder whose name starts with prefix. Drive queries only
+// match name prefixes per word, so the folder is paged through and filtered here.
+func (d *DriveStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	files := []FileInfo{}
+	err := d.service.Files.List().
+		Q(fmt.Sprintf("'%s' in parents and trashed = false", d.folderID)).
+		Fields(googleapi.Field("nextPageToken, files("+driveFileFields+")")).
+		OrderBy("name").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Context(ctx).
+		Pages(ctx, func(page *drive.FileList) error {
+			for _, file := range page.Files {
+				if strings.HasPrefix(file.Name, prefix) {
+					files = append(files, *driveFileInfo(file))
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	// Drive orders by a collation of its own
+	slices.SortFunc(files, func(a, b FileInfo) int { return strings.Compare(a.Name, b.Name) })
+	return files, nil
+}
+
+// Copy copies a file into the folder as a new file named dst
+func (d *DriveStorage) Copy(ctx context.Context, fileID, dst string) (*FileInfo, error) {
+	file, err := d.service.Files.Copy(fileID, &drive.File{Name: dst, Parents: []string{d.folderID}}).
+		Fields(driveFileFields).
+		SupportsAllDrives(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, driveErr("failed to copy file", err)
+	}
+	return driveFileInfo(file), nil
+}
+
+// Move renames a file to dst, its ID stays the same
+func (d *DriveStorage) Move(ctx context.Context, fileID, dst string) (*FileInfo, error) {
+	file, err := d.service.Files.Update(fileID, &drive.File{Name: dst}).
+		Fields(driveFileFields).
+		SupportsAllDrives(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, driveErr("failed to move file", err)
+	}
+	return driveFileInfo(file), nil
+}
+
 // URL gets direct view URL for file
-func (d *DriveStorage) URL(fileID string) (string, error) {
+func (d *DriveStorage) URL(ctx context.Context, fileID string) (string, error) {
 	return fmt.Sprintf("https://drive.google.com/uc?export=view&id=%s", fileID), nil
 }
 
+// PresignUpload is not supported, Drive uploads go through the application
+func (d *DriveStorage) PresignUpload(ctx context.Context, fileID string, opts PresignOptions) (*PresignedUpload, error) {
+	return nil, ErrSigningNotSupported
+}
+
+// SignedURL is not supported, Drive access is controlled by file permissions
+func (d *DriveStorage) SignedURL(ctx context.Context, fileID string, ttl time.Duration) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// driveFileFields are the fields of a file FileInfo needs
+const driveFileFields = "id, name, size, mimeType, modifiedTime"
+
+func driveFileInfo(file *drive.File) *FileInfo {
+	modTime, _ := time.Parse(time.RFC3339, file.ModifiedTime)
+	return &FileInfo{
+		Path:     file.Id,
+		Name:     file.Name,
+		Size:     file.Size,
+		MimeType: file.MimeType,
+		ModTime:  modTime,
+	}
+}
+
+// driveErr maps missing files to ErrFileNotFound
+func driveErr(msg string, err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return ErrFileNotFound
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
 // GetDriver returns the driver name
 func (d *DriveStorage) GetDriver() Driver {
 	return DriverDrive
@@ -0,0 +1,447 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+)
+
+// Envelope encryption format, version 1. Every file starts with a fixed size header:
+//
+//	magic "GOEF" | version (1 byte) | master key ID (16 bytes, zero padded)
+//	| data key nonce (12 bytes) | data key sealed by the master key (32 + 16 bytes)
+//
+// followed by the content, sealed with AES-256-GCM in chunks of encryptionChunkSize bytes.
+// The nonce of a chunk is its counter, its last byte flags the final chunk, which is
+// shorter than encryptionChunkSize and possibly empty, so truncation is detected. Every
+// chunk authenticates the header.
+const (
+	encryptionMagic           = "GOEF"
+	encryptionVersion    byte = 1
+	encryptionChunkSize       = 64 * 1024
+	encryptionKeyIDSize       = 16
+	encryptionTagSize         = 16
+	encryptionHeaderSize      = len(encryptionMagic) + 1 + encryptionKeyIDSize + 12 + 32 + encryptionTagSize
+)
+
+var (
+	// ErrDecryption is returned for encrypted files that were altered, truncated or sealed
+	// by an unknown master key
+	ErrDecryption = errors.New("failed to decrypt file")
+	// ErrEncryptedURL is returned for the plain URLs of encrypted files, they would serve
+	// the ciphertext
+	ErrEncryptedURL = errors.New("encrypted files have no public URL")
+)
+
+// EncryptionConfig enables envelope encryption of a disk
+type EncryptionConfig struct {
+	KeyID string            `mapstructure:"key_id"` // master key of new files, empty = disabled
+	Keys  map[string]string `mapstructure:"keys"`   // master keys by ID (lower case, up to 16 bytes), base64 of 32 bytes
+}
+
+// Enabled reports whether files are encrypted
+func (c EncryptionConfig) Enabled() bool {
+	return c.KeyID != ""
+}
+
+// Keyring holds the master keys wrapping the data keys of files. New files use the current
+// key, retired keys stay to read the files they sealed.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring from cfg
+func NewKeyring(cfg EncryptionConfig) (*Keyring, error) {
+	keyring := &Keyring{current: cfg.KeyID, keys: make(map[string]cipher.AEAD, len(cfg.Keys))}
+	for id, encoded := range cfg.Keys {
+		if id == "" || len(id) > encryptionKeyIDSize {
+			return nil, fmt.Errorf("encryption key ID %q must be 1 to %d bytes", id, encryptionKeyIDSize)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %s must be 32 bytes encoded in base64", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys[id] = aead
+	}
+
+	if _, ok := keyring.keys[cfg.KeyID]; !ok {
+		return nil, fmt.Errorf("encryption key %s is not configured", cfg.KeyID)
+	}
+	return keyring, nil
+}
+
+// seal returns the header of a new file and the cipher of its content
+func (k *Keyring) seal() ([]byte, cipher.AEAD, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	header := make([]byte, 0, encryptionHeaderSize)
+	header = append(header, encryptionMagic...)
+	header = append(header, encryptionVersion)
+	header = append(header, keyIDField(k.current)...)
+
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header = append(header, nonce...)
+	// The key ID and version are bound to the sealed data key
+	header = k.keys[k.current].Seal(header, nonce, dataKey, header[:len(encryptionMagic)+1+encryptionKeyIDSize])
+
+	aead, err := newAEAD(dataKey)
+	return header, aead, err
+}
+
+// open returns the cipher of the content sealed under header
+func (k *Keyring) open(header []byte) (cipher.AEAD, error) {
+	prefix := len(encryptionMagic) + 1 + encryptionKeyIDSize
+	if string(header[:len(encryptionMagic)]) != encryptionMagic {
+		return nil, fmt.Errorf("%w: not an encrypted file", ErrDecryption)
+	}
+	if version := header[len(encryptionMagic)]; version != encryptionVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrDecryption, version)
+	}
+
+	id := string(bytes.TrimRight(header[len(encryptionMagic)+1:prefix], "\x00"))
+	master, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %s", ErrDecryption, id)
+	}
+
+	dataKey, err := master.Open(nil, header[prefix:prefix+12], header[prefix+12:], header[:prefix])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryption, err)
+	}
+	return newAEAD(dataKey)
+}
+
+// encryptedStorage seals the content written to storage and opens it on read. Sizes are
+// reported in plaintext bytes. Copies and moves keep the ciphertext as is.
+type encryptedStorage struct {
+	storage Storage
+	keyring *Keyring
+}
+
+// Encrypt wraps storage so its files are encrypted at rest with keys of keyring
+func Encrypt(storage Storage, keyring *Keyring) Storage {
+	return &encryptedStorage{storage: storage, keyring: keyring}
+}
+
+// Unwrap returns the storage holding the ciphertext
+func (s *encryptedStorage) Unwrap() Storage {
+	return s.storage
+}
+
+func (s *encryptedStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+	if err := validateUpload(file.Size, file.Header.Get("Content-Type"), opts); err != nil {
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	filename := opts.Filename
+	if filename == "" {
+		filename = generateFilename(file.Filename)
+	}
+
+	result, err := s.UploadFromReader(ctx, src, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.OriginalName = file.Filename
+	return result, nil
+}
+
+func (s *encryptedStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	header, aead, err := s.keyring.seal()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypting := &encryptReader{source: reader, aead: aead, header: header, pending: header}
+	result, err := s.storage.UploadFromReader(ctx, encrypting, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Size = encrypting.size
+	result.URL = ""
+	return result, nil
+}
+
+func (s *encryptedStorage) Delete(ctx context.Context, path string) error {
+	return s.storage.Delete(ctx, path)
+}
+
+func (s *encryptedStorage) Exists(ctx context.Context, path string) (bool, error) {
+	return s.storage.Exists(ctx, path)
+}
+
+// Open decrypts the file while it is read, a tampered chunk fails the read with ErrDecryption
+func (s *encryptedStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	reader, err := s.storage.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, encryptionHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		_ = reader.Close()
+		return nil, fmt.Errorf("%w: missing header", ErrDecryption)
+	}
+	aead, err := s.keyring.open(header)
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+
+	return &decryptReader{source: reader, aead: aead, header: header}, nil
+}
+
+func (s *encryptedStorage) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	info, err := s.storage.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return plaintextInfo(info)
+}
+
+func (s *encryptedStorage) Size(ctx context.Context, path string) (int64, error) {
+	info, err := s.Stat(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+func (s *encryptedStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	files, err := s.storage.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i := range files {
+		info, err := plaintextInfo(&files[i])
+		if err != nil {
+			return nil, err
+		}
+		files[i] = *info
+	}
+	return files, nil
+}
+
+func (s *encryptedStorage) Copy(ctx context.Context, src, dst string) (*FileInfo, error) {
+	info, err := s.storage.Copy(ctx, src, dst)
+	if err != nil {
+		return nil, err
+	}
+	return plaintextInfo(info)
+}
+
+func (s *encryptedStorage) Move(ctx context.Context, src, dst string) (*FileInfo, error) {
+	info, err := s.storage.Move(ctx, src, dst)
+	if err != nil {
+		return nil, err
+	}
+	return plaintextInfo(info)
+}
+
+// URL is not supported, the storage would serve the ciphertext
+func (s *encryptedStorage) URL(ctx context.Context, path string) (string, error) {
+	return "", ErrEncryptedURL
+}
+
+// PresignUpload is only supported by drivers whose signed requests the application
+// serves itself, through the manager and so this storage
+func (s *encryptedStorage) PresignUpload(ctx context.Context, path string, opts PresignOptions) (*PresignedUpload, error) {
+	if _, ok := unwrapAs[SignedRequestVerifier](s.storage); !ok {
+		return nil, ErrSigningNotSupported
+	}
+	return s.storage.PresignUpload(ctx, path, opts)
+}
+
+// SignedURL is only supported by drivers whose signed requests the application serves itself
+func (s *encryptedStorage) SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	if _, ok := unwrapAs[SignedRequestVerifier](s.storage); !ok {
+		return "", ErrSigningNotSupported
+	}
+	return s.storage.SignedURL(ctx, path, ttl)
+}
+
+func (s *encryptedStorage) GetDriver() Driver {
+	return s.storage.GetDriver()
+}
+
+// encryptReader streams the header, then the sealed chunks of source
+type encryptReader struct {
+	source  io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	pending []byte // sealed bytes not read yet
+	chunk   []byte
+	counter uint64
+	size    int64 // plaintext bytes read from source
+	done    bool
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.sealChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// sealChunk reads the next chunk of source, a short one is the last
+func (r *encryptReader) sealChunk() error {
+	if r.chunk == nil {
+		r.chunk = make([]byte, encryptionChunkSize, encryptionChunkSize+encryptionTagSize)
+	}
+
+	n, err := io.ReadFull(r.source, r.chunk[:encryptionChunkSize])
+	last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if err != nil && !last {
+		return err
+	}
+
+	r.size += int64(n)
+	r.pending = r.aead.Seal(r.chunk[:0], chunkNonce(r.counter, last), r.chunk[:n], r.header)
+	r.counter++
+	r.done = last
+	return nil
+}
+
+// decryptReader opens the sealed chunks of source
+type decryptReader struct {
+	source  io.ReadCloser
+	aead    cipher.AEAD
+	header  []byte
+	pending []byte // opened bytes not read yet
+	chunk   []byte
+	counter uint64
+	done    bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// openChunk reads the next sealed chunk, a short one is the last
+func (r *decryptReader) openChunk() error {
+	if r.chunk == nil {
+		r.chunk = make([]byte, encryptionChunkSize+encryptionTagSize)
+	}
+
+	n, err := io.ReadFull(r.source, r.chunk)
+	last := errors.Is(err, io.ErrUnexpectedEOF)
+	if errors.Is(err, io.EOF) {
+		// Every file ends with a short chunk
+		return fmt.Errorf("%w: truncated", ErrDecryption)
+	}
+	if err != nil && !last {
+		return err
+	}
+
+	opened, err := r.aead.Open(r.chunk[:0], chunkNonce(r.counter, last), r.chunk[:n], r.header)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDecryption, err)
+	}
+
+	r.pending = opened
+	r.counter++
+	r.done = last
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.source.Close()
+}
+
+// plaintextInfo converts info of a sealed file to the plaintext size
+func plaintextInfo(info *FileInfo) (*FileInfo, error) {
+	size, err := plaintextSize(info.Size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, info.Path)
+	}
+
+	plain := *info
+	plain.Size = size
+	return &plain, nil
+}
+
+// plaintextSize returns the plaintext bytes of a sealed file of size bytes: the header,
+// full chunks, then a short final chunk, each with its tag
+func plaintextSize(size int64) (int64, error) {
+	sealed := size - int64(encryptionHeaderSize) - encryptionTagSize
+	if sealed < 0 {
+		return 0, fmt.Errorf("%w: missing header", ErrDecryption)
+	}
+
+	full := sealed / (encryptionChunkSize + encryptionTagSize)
+	rest := sealed % (encryptionChunkSize + encryptionTagSize)
+	if rest >= encryptionChunkSize {
+		return 0, fmt.Errorf("%w: truncated", ErrDecryption)
+	}
+	return full*encryptionChunkSize + rest, nil
+}
+
+// chunkNonce returns the nonce of chunk number counter
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce, counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func keyIDField(id string) []byte {
+	field := make([]byte, encryptionKeyIDSize)
+	copy(field, id)
+	return field
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
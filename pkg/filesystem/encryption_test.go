@@ -0,0 +1,185 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) string {
+	t.Helper()
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func newTestKeyring(t *testing.T, current string, keys map[string]string) *Keyring {
+	t.Helper()
+
+	keyring, err := NewKeyring(EncryptionConfig{KeyID: current, Keys: keys})
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestNewKeyring(t *testing.T) {
+	key := newTestKey(t)
+	tests := []struct {
+		name string
+		cfg  EncryptionConfig
+	}{
+		{name: "without the current key", cfg: EncryptionConfig{KeyID: "k2", Keys: map[string]string{"k1": key}}},
+		{name: "with a short key", cfg: EncryptionConfig{KeyID: "k1", Keys: map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}}},
+		{name: "with a long key ID", cfg: EncryptionConfig{KeyID: "k1", Keys: map[string]string{"k1": key, "a-very-long-key-id": key}}},
+	}
+
+	for _, tt := range tests {
+		t.Run("should reject a keyring "+tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keys := map[string]string{"k1": newTestKey(t)}
+	storage := Encrypt(NewLocalStorage(dir, "http://localhost/storage"), newTestKeyring(t, "k1", keys))
+
+	read := func(t *testing.T, storage Storage, path string) ([]byte, error) {
+		t.Helper()
+		reader, err := storage.Open(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = reader.Close() }()
+		return io.ReadAll(reader)
+	}
+
+	for _, size := range []int{0, 10, encryptionChunkSize, 2*encryptionChunkSize + 7} {
+		content := bytes.Repeat([]byte("secret "), size/7+1)[:size]
+
+		result, err := storage.UploadFromReader(ctx, bytes.NewReader(content), "secret.txt", UploadOptions{Path: "files"})
+		require.NoError(t, err)
+
+		t.Run(fmt.Sprintf("should store ciphertext and read back %d bytes", size), func(t *testing.T) {
+			stored, err := os.ReadFile(filepath.Join(dir, result.Path))
+			require.NoError(t, err)
+			if size > 0 {
+				assert.NotContains(t, string(stored), "secret")
+			}
+
+			plain, err := read(t, storage, result.Path)
+			require.NoError(t, err)
+			assert.Equal(t, content, plain)
+		})
+
+		t.Run(fmt.Sprintf("should report the plaintext size of %d bytes", size), func(t *testing.T) {
+			assert.Equal(t, int64(size), result.Size)
+
+			info, err := storage.Stat(ctx, result.Path)
+			require.NoError(t, err)
+			assert.Equal(t, int64(size), info.Size)
+		})
+	}
+
+	t.Run("should detect tampering", func(t *testing.T) {
+		result, err := storage.UploadFromReader(ctx, bytes.NewReader([]byte("hello world")), "tampered.txt", UploadOptions{Path: "files"})
+		require.NoError(t, err)
+		path := filepath.Join(dir, result.Path)
+		stored, err := os.ReadFile(path)
+		require.NoError(t, err)
+		stored[len(stored)-1] ^= 1
+		require.NoError(t, os.WriteFile(path, stored, 0644))
+
+		_, err = read(t, storage, result.Path)
+
+		assert.ErrorIs(t, err, ErrDecryption)
+	})
+
+	t.Run("should detect truncation at a chunk boundary", func(t *testing.T) {
+		result, err := storage.UploadFromReader(ctx, bytes.NewReader(make([]byte, encryptionChunkSize+10)), "truncated.bin", UploadOptions{Path: "files"})
+		require.NoError(t, err)
+		path := filepath.Join(dir, result.Path)
+		require.NoError(t, os.Truncate(path, int64(encryptionHeaderSize+encryptionChunkSize+encryptionTagSize)))
+
+		_, err = read(t, storage, result.Path)
+
+		assert.ErrorIs(t, err, ErrDecryption)
+	})
+
+	t.Run("should read files sealed by retired keys", func(t *testing.T) {
+		old, err := storage.UploadFromReader(ctx, bytes.NewReader([]byte("old")), "old.txt", UploadOptions{Path: "files"})
+		require.NoError(t, err)
+
+		keys := map[string]string{"k1": keys["k1"], "k2": newTestKey(t)}
+		rotated := Encrypt(NewLocalStorage(dir, ""), newTestKeyring(t, "k2", keys))
+
+		plain, err := read(t, rotated, old.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(plain))
+
+		_, err = read(t, Encrypt(NewLocalStorage(dir, ""), newTestKeyring(t, "k2", map[string]string{"k2": keys["k2"]})), old.Path)
+		assert.ErrorIs(t, err, ErrDecryption)
+	})
+
+	t.Run("should not serve the ciphertext through plain URLs", func(t *testing.T) {
+		result, err := storage.UploadFromReader(ctx, bytes.NewReader([]byte("hello")), "public.txt", UploadOptions{Path: "files", Public: true})
+		require.NoError(t, err)
+		assert.Empty(t, result.URL)
+
+		_, err = storage.URL(ctx, result.Path)
+		assert.ErrorIs(t, err, ErrEncryptedURL)
+		_, err = Encrypt(NewMemoryStorage(), newTestKeyring(t, "k1", keys)).SignedURL(ctx, result.Path, 0)
+		assert.ErrorIs(t, err, ErrSigningNotSupported)
+	})
+}
+
+func TestEncryptedStorage_Conformance(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", map[string]string{"k1": newTestKey(t)})
+	runConformance(t, Encrypt(NewMemoryStorage(), keyring))
+}
+
+func TestNewManagerFromConfig_Encryption(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	manager, err := NewManagerFromConfig(ctx, Config{
+		Driver:     DriverLocal,
+		Local:      LocalConfig{BasePath: dir},
+		Encryption: EncryptionConfig{KeyID: "k1", Keys: map[string]string{"k1": newTestKey(t)}},
+	})
+	require.NoError(t, err)
+
+	result, err := manager.UploadFromReader(ctx, bytes.NewReader(pngOf(t, 4, 4)), "photo.png", UploadOptions{Path: "images"})
+	require.NoError(t, err)
+
+	t.Run("should sniff and store the plaintext type", func(t *testing.T) {
+		assert.Equal(t, "image/png", result.MimeType)
+
+		stored, err := os.ReadFile(filepath.Join(dir, result.Path))
+		require.NoError(t, err)
+		assert.Equal(t, encryptionMagic, string(stored[:len(encryptionMagic)]))
+	})
+
+	t.Run("should decrypt on read", func(t *testing.T) {
+		reader, err := manager.Open(ctx, result.Path)
+		require.NoError(t, err)
+		defer func() { _ = reader.Close() }()
+
+		plain, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, pngOf(t, 4, 4), plain)
+	})
+}
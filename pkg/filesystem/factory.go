@@ -27,6 +27,8 @@ func (f *DefaultStorageFactory) Create(ctx context.Context, driver Driver, cfg C
 		return f.createS3(ctx, cfg.S3)
 	case DriverDrive:
 		return f.createDrive(ctx, cfg.Drive)
+	case DriverMemory:
+		return NewMemoryStorage(), nil
 	default:
 		return nil, &UnsupportedDriverError{Driver: string(driver)}
 	}
@@ -36,7 +38,11 @@ func (f *DefaultStorageFactory) createLocal(cfg LocalConfig) (Storage, error) {
 	if cfg.BasePath == "" {
 		return nil, fmt.Errorf("local storage requires base_path")
 	}
-	return NewLocalStorage(cfg.BasePath, cfg.BaseURL), nil
+	storage := NewLocalStorage(cfg.BasePath, cfg.BaseURL)
+	if cfg.SigningKey != "" {
+		storage.WithSigner(NewURLSigner(cfg.SigningKey, cfg.SignedURL))
+	}
+	return storage, nil
 }
 
 func (f *DefaultStorageFactory) createS3(ctx context.Context, cfg S3Config) (Storage, error) {
@@ -0,0 +1,254 @@
+package filesystem
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	// Decoders of the supported source formats
+	_ "image/gif"
+	_ "image/png"
+
+	"goilerplate/pkg/utils"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageFit controls how an image is resized into the box of a variant
+type ImageFit string
+
+const (
+	ImageFitContain ImageFit = "fit"  // scaled down to fit inside the box, keeping the aspect ratio
+	ImageFitCover   ImageFit = "fill" // scaled and center cropped to fill the box exactly
+)
+
+// ImageFormat is the encoding of a variant
+type ImageFormat string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatWebP ImageFormat = "webp" // lossless, Quality does not apply
+)
+
+// Defaults of the image pipeline
+const (
+	DefaultImageQuality   = 85
+	DefaultImageMaxPixels = 40_000_000
+)
+
+var (
+	ErrInvalidImage  = utils.ClientErr(400, "Invalid image")
+	ErrImageTooLarge = utils.ClientErr(400, "Image dimensions are too large")
+)
+
+// ImageConfig configures the variants generated for uploaded images
+type ImageConfig struct {
+	Variants  []ImageVariant `mapstructure:"variants"`
+	MaxPixels int            `mapstructure:"max_pixels"` // larger images are rejected before decoding, defaults to DefaultImageMaxPixels
+}
+
+// ImageVariant describes a resized copy of uploaded images. A zero Width or Height
+// leaves that side unconstrained with ImageFitContain, ImageFitCover needs both.
+type ImageVariant struct {
+	Name    string      `mapstructure:"name"`
+	Width   int         `mapstructure:"width"`
+	Height  int         `mapstructure:"height"`
+	Fit     ImageFit    `mapstructure:"fit"`     // defaults to ImageFitContain
+	Format  ImageFormat `mapstructure:"format"`  // defaults to ImageFormatJPEG
+	Quality int         `mapstructure:"quality"` // JPEG quality 1-100, defaults to DefaultImageQuality
+}
+
+// VariantResult is a variant stored next to the original upload
+type VariantResult struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	MimeType string `json:"mimeType"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+}
+
+// ProcessedImage is an encoded variant waiting to be stored
+type ProcessedImage struct {
+	Variant ImageVariant
+	Data    []byte
+	Width   int
+	Height  int
+}
+
+// MimeType returns the MIME type of the encoded variant
+func (p *ProcessedImage) MimeType() string {
+	if p.Variant.Format == ImageFormatWebP {
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
+
+// Extension returns the file extension of the encoded variant
+func (p *ProcessedImage) Extension() string {
+	if p.Variant.Format == ImageFormatWebP {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
+// ImagePipeline generates the variants of uploaded images in pure Go
+type ImagePipeline struct {
+	variants  []ImageVariant
+	maxPixels int
+}
+
+// NewImagePipeline validates cfg and fills in the defaults of its variants
+func NewImagePipeline(cfg ImageConfig) (*ImagePipeline, error) {
+	variants := make([]ImageVariant, len(cfg.Variants))
+	for i, variant := range cfg.Variants {
+		if variant.Fit == "" {
+			variant.Fit = ImageFitContain
+		}
+		if variant.Format == "" {
+			variant.Format = ImageFormatJPEG
+		}
+		if variant.Quality <= 0 {
+			variant.Quality = DefaultImageQuality
+		}
+
+		switch {
+		case variant.Name == "":
+			return nil, fmt.Errorf("image variant %d has no name", i)
+		case variant.Width < 0 || variant.Height < 0 || variant.Width+variant.Height == 0:
+			return nil, fmt.Errorf("image variant %s needs a width or a height", variant.Name)
+		case variant.Fit == ImageFitCover && (variant.Width == 0 || variant.Height == 0):
+			return nil, fmt.Errorf("image variant %s needs a width and a height to fill", variant.Name)
+		case variant.Fit != ImageFitContain && variant.Fit != ImageFitCover:
+			return nil, fmt.Errorf("image variant %s has unsupported fit %q", variant.Name, variant.Fit)
+		case variant.Format != ImageFormatJPEG && variant.Format != ImageFormatWebP:
+			return nil, fmt.Errorf("image variant %s has unsupported format %q", variant.Name, variant.Format)
+		}
+		variants[i] = variant
+	}
+
+	maxPixels := cfg.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = DefaultImageMaxPixels
+	}
+
+	return &ImagePipeline{variants: variants, maxPixels: maxPixels}, nil
+}
+
+// IsImage reports whether the sniffed content of head is an image the pipeline decodes
+func IsImage(head []byte) bool {
+	switch http.DetectContentType(head) {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Process decodes the image in src and encodes every variant. Content that is not an
+// image yields no variants, images that cannot be decoded fail with ErrInvalidImage.
+func (p *ImagePipeline) Process(src io.Reader) ([]*ProcessedImage, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	if len(p.variants) == 0 || !IsImage(data[:min(len(data), 512)]) {
+		return nil, nil
+	}
+
+	// Check the dimensions before decoding, so a small file cannot expand into gigabytes
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > p.maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	processed := make([]*ProcessedImage, 0, len(p.variants))
+	for _, variant := range p.variants {
+		resized := resize(source, variant)
+
+		var buf bytes.Buffer
+		if err := encode(&buf, resized, variant); err != nil {
+			return nil, fmt.Errorf("failed to encode image variant %s: %w", variant.Name, err)
+		}
+
+		processed = append(processed, &ProcessedImage{
+			Variant: variant,
+			Data:    buf.Bytes(),
+			Width:   resized.Bounds().Dx(),
+			Height:  resized.Bounds().Dy(),
+		})
+	}
+
+	return processed, nil
+}
+
+// resize scales source into the box of variant, images are never scaled up to fit
+func resize(source image.Image, variant ImageVariant) image.Image {
+	bounds := source.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	if variant.Fit == ImageFitCover {
+		// Crop the largest centered rectangle with the aspect ratio of the box
+		cropWidth, cropHeight := srcWidth, srcWidth*variant.Height/variant.Width
+		if cropHeight > srcHeight {
+			cropWidth, cropHeight = srcHeight*variant.Width/variant.Height, srcHeight
+		}
+		x := bounds.Min.X + (srcWidth-cropWidth)/2
+		y := bounds.Min.Y + (srcHeight-cropHeight)/2
+
+		dst := image.NewRGBA(image.Rect(0, 0, variant.Width, variant.Height))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), source, image.Rect(x, y, x+cropWidth, y+cropHeight), draw.Src, nil)
+		return dst
+	}
+
+	scale := 1.0
+	if variant.Width > 0 {
+		scale = min(scale, float64(variant.Width)/float64(srcWidth))
+	}
+	if variant.Height > 0 {
+		scale = min(scale, float64(variant.Height)/float64(srcHeight))
+	}
+
+	width := max(int(float64(srcWidth)*scale+0.5), 1)
+	height := max(int(float64(srcHeight)*scale+0.5), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), source, bounds, draw.Src, nil)
+	return dst
+}
+
+func encode(w io.Writer, img image.Image, variant ImageVariant) error {
+	if variant.Format == ImageFormatWebP {
+		return nativewebp.Encode(w, img, nil)
+	}
+
+	// JPEG has no alpha channel, transparent areas become white
+	flattened := image.NewRGBA(img.Bounds())
+	draw.Draw(flattened, flattened.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	return jpeg.Encode(w, flattened, &jpeg.Options{Quality: variant.Quality})
+}
+
+// variantFilename returns the filename of a variant stored next to original
+func variantFilename(original string, processed *ProcessedImage) string {
+	base := filepath.Base(original)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "_" + processed.Variant.Name + processed.Extension()
+}
@@ -0,0 +1,173 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// pngOf encodes a width x height image with a transparent corner
+func pngOf(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	img.Set(0, 0, color.NRGBA{})
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newTestPipeline(t *testing.T, variants ...ImageVariant) *ImagePipeline {
+	t.Helper()
+
+	pipeline, err := NewImagePipeline(ImageConfig{Variants: variants})
+	require.NoError(t, err)
+	return pipeline
+}
+
+func TestNewImagePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant ImageVariant
+	}{
+		{name: "without name", variant: ImageVariant{Width: 10}},
+		{name: "without box", variant: ImageVariant{Name: "thumb"}},
+		{name: "fill without height", variant: ImageVariant{Name: "thumb", Width: 10, Fit: ImageFitCover}},
+		{name: "unknown fit", variant: ImageVariant{Name: "thumb", Width: 10, Fit: "stretch"}},
+		{name: "unknown format", variant: ImageVariant{Name: "thumb", Width: 10, Format: "avif"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("should reject a variant "+tt.name, func(t *testing.T) {
+			_, err := NewImagePipeline(ImageConfig{Variants: []ImageVariant{tt.variant}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImagePipeline_Process(t *testing.T) {
+	source := pngOf(t, 400, 200)
+
+	t.Run("should fit inside the box keeping the aspect ratio", func(t *testing.T) {
+		pipeline := newTestPipeline(t, ImageVariant{Name: "medium", Width: 100, Height: 100})
+
+		processed, err := pipeline.Process(bytes.NewReader(source))
+
+		require.NoError(t, err)
+		require.Len(t, processed, 1)
+		assert.Equal(t, 100, processed[0].Width)
+		assert.Equal(t, 50, processed[0].Height)
+		assert.Equal(t, "image/jpeg", processed[0].MimeType())
+
+		decoded, format, err := image.Decode(bytes.NewReader(processed[0].Data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, image.Rect(0, 0, 100, 50), decoded.Bounds())
+	})
+
+	t.Run("should fill the box exactly", func(t *testing.T) {
+		pipeline := newTestPipeline(t, ImageVariant{Name: "thumb", Width: 60, Height: 60, Fit: ImageFitCover, Format: ImageFormatWebP})
+
+		processed, err := pipeline.Process(bytes.NewReader(source))
+
+		require.NoError(t, err)
+		require.Len(t, processed, 1)
+		assert.Equal(t, ".webp", processed[0].Extension())
+
+		config, err := webp.DecodeConfig(bytes.NewReader(processed[0].Data))
+		require.NoError(t, err)
+		assert.Equal(t, 60, config.Width)
+		assert.Equal(t, 60, config.Height)
+	})
+
+	t.Run("should never scale up", func(t *testing.T) {
+		pipeline := newTestPipeline(t, ImageVariant{Name: "large", Width: 1000})
+
+		processed, err := pipeline.Process(bytes.NewReader(source))
+
+		require.NoError(t, err)
+		assert.Equal(t, 400, processed[0].Width)
+		assert.Equal(t, 200, processed[0].Height)
+	})
+
+	t.Run("should skip content that is not an image", func(t *testing.T) {
+		pipeline := newTestPipeline(t, ImageVariant{Name: "thumb", Width: 10})
+
+		processed, err := pipeline.Process(bytes.NewReader([]byte("hello world")))
+
+		require.NoError(t, err)
+		assert.Empty(t, processed)
+	})
+
+	t.Run("should reject a corrupt image", func(t *testing.T) {
+		pipeline := newTestPipeline(t, ImageVariant{Name: "thumb", Width: 10})
+
+		_, err := pipeline.Process(bytes.NewReader(source[:100]))
+
+		assert.ErrorIs(t, err, ErrInvalidImage)
+	})
+
+	t.Run("should reject images over the pixel limit before decoding", func(t *testing.T) {
+		pipeline, err := NewImagePipeline(ImageConfig{Variants: []ImageVariant{{Name: "thumb", Width: 10}}, MaxPixels: 1000})
+		require.NoError(t, err)
+
+		_, err = pipeline.Process(bytes.NewReader(source))
+
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+	})
+}
+
+func TestManager_UploadFromReader_Variants(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	manager := NewManager(NewLocalStorage(dir, "http://localhost/storage")).
+		WithImagePipeline(newTestPipeline(t,
+			ImageVariant{Name: "thumb", Width: 50, Height: 50, Fit: ImageFitCover, Format: ImageFormatWebP},
+			ImageVariant{Name: "medium", Width: 200},
+		))
+
+	t.Run("should store the variants next to the original", func(t *testing.T) {
+		result, err := manager.UploadFromReader(ctx, bytes.NewReader(pngOf(t, 400, 200)), "avatar.png", UploadOptions{Path: "images"})
+
+		require.NoError(t, err)
+		require.Len(t, result.Variants, 2)
+		assert.Equal(t, "images/avatar_thumb.webp", result.Variants[0].Path)
+		assert.Equal(t, "http://localhost/storage/images/avatar_thumb.webp", result.Variants[0].URL)
+		assert.Equal(t, "images/avatar_medium.jpg", result.Variants[1].Path)
+		assert.Equal(t, 100, result.Variants[1].Height)
+
+		for _, path := range []string{result.Path, result.Variants[0].Path, result.Variants[1].Path} {
+			assert.FileExists(t, filepath.Join(dir, path))
+		}
+	})
+
+	t.Run("should store other files as is", func(t *testing.T) {
+		result, err := manager.UploadFromReader(ctx, bytes.NewReader([]byte("hello")), "notes.txt", UploadOptions{Path: "docs"})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.Variants)
+	})
+
+	t.Run("should keep nothing of a corrupt image", func(t *testing.T) {
+		_, err := manager.UploadFromReader(ctx, bytes.NewReader(pngOf(t, 40, 40)[:60]), "broken.png", UploadOptions{Path: "broken"})
+
+		assert.ErrorIs(t, err, ErrInvalidImage)
+		entries, _ := os.ReadDir(filepath.Join(dir, "broken"))
+		assert.Empty(t, entries)
+	})
+}
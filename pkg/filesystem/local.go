@@ -1,18 +1,26 @@
 package filesystem
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // LocalStorage implements Storage for local filesystem
 type LocalStorage struct {
 	basePath string
 	baseURL  string
+	signer   *URLSigner // nil = signed URLs are not configured
 }
 
 // NewLocalStorage creates a new local storage instance
@@ -23,8 +31,14 @@ func NewLocalStorage(basePath, baseURL string) *LocalStorage {
 	}
 }
 
+// WithSigner enables signed URLs, served by the route of the signer's base URL
+func (l *LocalStorage) WithSigner(signer *URLSigner) *LocalStorage {
+	l.signer = signer
+	return l
+}
+
 // Upload uploads file from multipart form
-func (l *LocalStorage) Upload(file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+func (l *LocalStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
 	// Validate
 	if err := validateUpload(file.Size, file.Header.Get("Content-Type"), opts); err != nil {
 		return nil, err
@@ -45,7 +59,7 @@ func (l *LocalStorage) Upload(file *multipart.FileHeader, opts UploadOptions) (*
 		filename = generateFilename(originalName)
 	}
 
-	result, err := l.UploadFromReader(src, filename, opts)
+	result, err := l.UploadFromReader(ctx, src, filename, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -57,28 +71,36 @@ func (l *LocalStorage) Upload(file *multipart.FileHeader, opts UploadOptions) (*
 }
 
 // UploadFromReader uploads from io.Reader
-func (l *LocalStorage) UploadFromReader(reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
-	fullPath := filepath.Join(l.basePath, opts.Path)
-	if err := os.MkdirAll(fullPath, 0755); err != nil {
+func (l *LocalStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	relativePath, err := l.key(filepath.Join(opts.Path, filename))
+	if err != nil {
+		return nil, err
+	}
+	destPath, err := l.resolve(relativePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	destPath := filepath.Join(fullPath, filename)
 	dst, err := os.Create(destPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file: %w", err)
 	}
 	defer func() { _ = dst.Close() }()
 
-	size, err := io.Copy(dst, reader)
+	size, err := io.Copy(dst, contextReader{ctx: ctx, reader: reader})
 	if err != nil {
+		// Never leave a partial file behind a canceled upload
+		_ = dst.Close()
+		_ = os.Remove(destPath)
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	relativePath := filepath.Join(opts.Path, filename)
 	url := ""
 	if l.baseURL != "" {
-		url = strings.TrimSuffix(l.baseURL, "/") + "/" + strings.TrimPrefix(relativePath, "/")
+		url = strings.TrimSuffix(l.baseURL, "/") + "/" + relativePath
 	}
 
 	return &UploadResult{
@@ -86,15 +108,18 @@ func (l *LocalStorage) UploadFromReader(reader io.Reader, filename string, opts
 		Filename:     filename,
 		Path:         relativePath,
 		Size:         size,
-		MimeType:     detectMimeType(filename),
+		MimeType:     contentTypeOf(filename, opts),
 		URL:          url,
 		Driver:       DriverLocal,
 	}, nil
 }
 
 // Delete deletes a file
-func (l *LocalStorage) Delete(path string) error {
-	fullPath := filepath.Join(l.basePath, path)
+func (l *LocalStorage) Delete(ctx context.Context, path string) error {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		return err
+	}
 	if err := os.Remove(fullPath); err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
@@ -102,9 +127,12 @@ func (l *LocalStorage) Delete(path string) error {
 }
 
 // Exists checks if file exists
-func (l *LocalStorage) Exists(path string) (bool, error) {
-	fullPath := filepath.Join(l.basePath, path)
-	_, err := os.Stat(fullPath)
+func (l *LocalStorage) Exists(ctx context.Context, path string) (bool, error) {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(fullPath)
 	if os.IsNotExist(err) {
 		return false, nil
 	}
@@ -115,11 +143,250 @@ func (l *LocalStorage) Exists(path string) (bool, error) {
 }
 
 // URL gets public URL for file
-func (l *LocalStorage) URL(path string) (string, error) {
+func (l *LocalStorage) URL(ctx context.Context, path string) (string, error) {
 	if l.baseURL == "" {
 		return "", fmt.Errorf("base URL not configured")
 	}
-	return strings.TrimSuffix(l.baseURL, "/") + "/" + strings.TrimPrefix(path, "/"), nil
+	key, err := l.key(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(l.baseURL, "/") + "/" + key, nil
+}
+
+// PresignUpload returns a signed PUT request to the application, the size, content type
+// and checksum are signed along and checked when the content arrives
+func (l *LocalStorage) PresignUpload(ctx context.Context, path string, opts PresignOptions) (*PresignedUpload, error) {
+	if l.signer == nil {
+		return nil, ErrSigningNotSupported
+	}
+	path, err := l.key(path)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set(SignedParamSize, strconv.FormatInt(opts.Size, 10))
+	params.Set(SignedParamContentType, opts.ContentType)
+	params.Set(SignedParamChecksum, opts.Checksum)
+
+	signedURL, expiresAt := l.signer.Sign(http.MethodPut, path, opts.TTL, params)
+
+	return &PresignedUpload{
+		URL:       signedURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": opts.ContentType},
+		Path:      path,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// SignedURL returns a signed GET request to the application
+func (l *LocalStorage) SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	if l.signer == nil {
+		return "", ErrSigningNotSupported
+	}
+	path, err := l.key(path)
+	if err != nil {
+		return "", err
+	}
+
+	signedURL, _ := l.signer.Sign(http.MethodGet, path, ttl, nil)
+	return signedURL, nil
+}
+
+// VerifySignedRequest implements SignedRequestVerifier
+func (l *LocalStorage) VerifySignedRequest(method, path string, query url.Values) error {
+	if l.signer == nil {
+		return ErrSigningNotSupported
+	}
+	return l.signer.Verify(method, path, query)
+}
+
+// Open opens a stored file
+func (l *LocalStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, localErr("failed to open file", err)
+	}
+	return file, nil
+}
+
+// Stat describes a stored file, its MIME type is detected from the extension
+func (l *LocalStorage) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	fullPath, err := l.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, localErr("failed to stat file", err)
+	}
+	if info.IsDir() {
+		return nil, ErrFileNotFound
+	}
+
+	key, _ := l.key(path)
+	return localFileInfo(key, info), nil
+}
+
+// Size returns the size of a stored file in bytes
+func (l *LocalStorage) Size(ctx context.Context, path string) (int64, error) {
+	info, err := l.Stat(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// List walks the deepest folder of prefix and keeps the files starting with prefix
+func (l *LocalStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	prefix = strings.TrimPrefix(filepath.ToSlash(prefix), "/")
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	if dir != "" && !filepath.IsLocal(dir) {
+		return nil, fmt.Errorf("invalid prefix %q", prefix)
+	}
+
+	root := filepath.Join(l.basePath, filepath.FromSlash(dir))
+	files := []FileInfo{}
+
+	err := filepath.WalkDir(root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && fullPath == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		relative, err := filepath.Rel(l.basePath, fullPath)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		if !strings.HasPrefix(relative, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, *localFileInfo(relative, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return files, nil
+}
+
+// Copy copies src to dst, creating the folders of dst
+func (l *LocalStorage) Copy(ctx context.Context, src, dst string) (*FileInfo, error) {
+	reader, err := l.Open(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	dstPath, err := l.resolve(dst)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Written next to dst first, so a failed copy never leaves a partial dst
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".copy-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, reader: reader}); err != nil {
+		_ = tmp.Close()
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dstPath); err != nil {
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	return l.Stat(ctx, dst)
+}
+
+// Move renames src to dst, creating the folders of dst
+func (l *LocalStorage) Move(ctx context.Context, src, dst string) (*FileInfo, error) {
+	srcPath, err := l.resolve(src)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err := l.resolve(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(srcPath); err != nil {
+		return nil, localErr("failed to move file", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return nil, fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return l.Stat(ctx, dst)
+}
+
+// resolve returns the full path of path below the base path, every method taking a path
+// goes through it or key
+func (l *LocalStorage) resolve(path string) (string, error) {
+	key, err := l.key(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.basePath, filepath.FromSlash(key)), nil
+}
+
+// key returns path relative to the base path with forward slashes, ".." cannot leave
+// the base path
+func (l *LocalStorage) key(path string) (string, error) {
+	cleaned := filepath.ToSlash(filepath.Clean("/" + path))
+	if cleaned == "/" {
+		return "", fmt.Errorf("invalid path %q", path)
+	}
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+// localErr maps missing files to ErrFileNotFound
+func localErr(msg string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrFileNotFound
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func localFileInfo(path string, info fs.FileInfo) *FileInfo {
+	return &FileInfo{
+		Path:     path,
+		Name:     info.Name(),
+		Size:     info.Size(),
+		MimeType: DetectMimeType(info.Name()),
+		ModTime:  info.ModTime(),
+	}
 }
 
 // GetDriver returns the driver name
@@ -1,62 +1,562 @@
 package filesystem
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"goilerplate/pkg/utils"
 )
 
+// ErrTypeMismatch rejects stored content whose sniffed type is not the declared one
+var ErrTypeMismatch = utils.ClientErr(http.StatusBadRequest, "File content does not match its declared type")
+
 // Manager manages file storage operations with dependency injection
 type Manager struct {
+	name    string
 	storage Storage
 	factory StorageFactory
+	images  *ImagePipeline
+	disks   map[string]*Manager // shared by the managers of every disk
+	mirror  bool                // receives the writes of another disk
+
+	scanner        Scanner
+	scanAction     ScanAction
+	quarantinePath string
 }
 
-// NewManager creates a manager with injected dependencies
+// NewManager creates a manager with injected dependencies, every storage operation is traced
 func NewManager(storage Storage) *Manager {
-	return &Manager{
-		storage: storage,
+	manager := &Manager{
+		name:    DefaultDisk,
+		storage: Instrument(storage),
 		factory: NewStorageFactory(),
 	}
+	manager.disks = map[string]*Manager{DefaultDisk: manager}
+	return manager
 }
 
-// NewManagerFromConfig creates a manager from configuration
+// NewManagerFromConfig creates a manager of the default disk from configuration, the named
+// disks are reachable through Disk
 func NewManagerFromConfig(ctx context.Context, cfg Config) (*Manager, error) {
 	factory := NewStorageFactory()
+
+	// Every disk is created first, so any of them can mirror another
+	storages := make(map[string]Storage, len(cfg.Disks))
+	for name, disk := range cfg.Disks {
+		if name == DefaultDisk {
+			return nil, fmt.Errorf("disk name %s is reserved", DefaultDisk)
+		}
+		storage, err := createDisk(ctx, factory, disk.config())
+		if err != nil {
+			return nil, fmt.Errorf("disk %s: %w", name, err)
+		}
+		storages[name] = storage
+	}
+
+	storage, err := createDisk(ctx, factory, cfg)
+	if err != nil {
+		return nil, err
+	}
+	storage, err = mirrorOf(storage, DefaultDisk, cfg.Mirror, storages)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := NewManager(storage)
+	manager.factory = factory
+
+	if len(cfg.Images.Variants) > 0 {
+		images, err := NewImagePipeline(cfg.Images)
+		if err != nil {
+			return nil, err
+		}
+		manager.WithImagePipeline(images)
+	}
+
+	scanner, err := NewScannerFromConfig(cfg.Scan)
+	if err != nil {
+		return nil, err
+	}
+	if scanner != nil {
+		if cfg.Scan.Action != "" && cfg.Scan.Action != ScanActionReject && cfg.Scan.Action != ScanActionQuarantine {
+			return nil, fmt.Errorf("unsupported scan action: %s", cfg.Scan.Action)
+		}
+		manager.WithScanner(scanner, cfg.Scan.Action, cfg.Scan.QuarantinePath)
+	}
+
+	for name, disk := range cfg.Disks {
+		storage, err := mirrorOf(storages[name], name, disk.Mirror, storages)
+		if err != nil {
+			return nil, err
+		}
+		manager.WithDisk(name, manager.withStorage(storage))
+	}
+
+	if cfg.Mirror != "" {
+		manager.disks[cfg.Mirror].mirror = true
+	}
+	for _, disk := range cfg.Disks {
+		if disk.Mirror != "" {
+			manager.disks[disk.Mirror].mirror = true
+		}
+	}
+
+	return manager, nil
+}
+
+// createDisk creates the storage of a disk, encrypted if configured
+func createDisk(ctx context.Context, factory StorageFactory, cfg Config) (Storage, error) {
 	storage, err := factory.Create(ctx, cfg.Driver, cfg)
 	if err != nil {
 		return nil, err
 	}
+	if !cfg.Encryption.Enabled() {
+		return storage, nil
+	}
+
+	keyring, err := NewKeyring(cfg.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(storage, keyring), nil
+}
+
+// mirrorOf wraps the storage of disk name with the mirror disk named mirror, if any
+func mirrorOf(storage Storage, name, mirror string, storages map[string]Storage) (Storage, error) {
+	if mirror == "" {
+		return storage, nil
+	}
+	if mirror == name {
+		return nil, fmt.Errorf("disk %s cannot mirror itself", name)
+	}
+
+	target, ok := storages[mirror]
+	if !ok {
+		return nil, fmt.Errorf("disk %s: %w: %s", name, ErrUnknownDisk, mirror)
+	}
+	// Drive names files by ID, it cannot keep the paths of the primary disk
+	if target.GetDriver() == DriverDrive {
+		return nil, fmt.Errorf("disk %s: drive disks cannot be mirrors", name)
+	}
+
+	return Mirror(storage, target, mirror), nil
+}
+
+// WithDisk registers disk under name, reachable through Disk from every disk
+func (m *Manager) WithDisk(name string, disk *Manager) *Manager {
+	disk.name = name
+	disk.disks = m.disks
+	m.disks[name] = disk
+	return m
+}
+
+// Disk returns the manager of the disk named name, an empty name is the default disk
+func (m *Manager) Disk(name string) (*Manager, error) {
+	if name == "" {
+		name = DefaultDisk
+	}
+
+	disk, ok := m.disks[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDisk, name)
+	}
+	return disk, nil
+}
+
+// Disks returns every disk ordered by name
+func (m *Manager) Disks() []*Manager {
+	disks := make([]*Manager, 0, len(m.disks))
+	for _, disk := range m.disks {
+		disks = append(disks, disk)
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].name < disks[j].name })
+	return disks
+}
+
+// Name returns the name the disk is reachable with through Disk
+func (m *Manager) Name() string {
+	return m.name
+}
 
-	return &Manager{
-		storage: storage,
-		factory: factory,
-	}, nil
+// IsMirror reports whether the disk receives the writes of another disk, its files
+// belong to that disk
+func (m *Manager) IsMirror() bool {
+	return m.mirror
 }
 
-// Upload uploads a file
-func (m *Manager) Upload(file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
-	return m.storage.Upload(file, opts)
+// withStorage returns a manager of storage sharing the image pipeline and scanner of m
+func (m *Manager) withStorage(storage Storage) *Manager {
+	disk := *m
+	disk.storage = Instrument(storage)
+	return &disk
 }
 
-// UploadFromReader uploads from reader
-func (m *Manager) UploadFromReader(reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
-	return m.storage.UploadFromReader(reader, filename, opts)
+// WithImagePipeline generates the variants of uploaded images with images
+func (m *Manager) WithImagePipeline(images *ImagePipeline) *Manager {
+	m.images = images
+	return m
+}
+
+// WithScanner scans every upload before it is stored. Flagged content is rejected,
+// with ScanActionQuarantine it is stored under quarantinePath first.
+func (m *Manager) WithScanner(scanner Scanner, action ScanAction, quarantinePath string) *Manager {
+	if quarantinePath == "" {
+		quarantinePath = DefaultQuarantinePath
+	}
+
+	m.scanner = scanner
+	m.scanAction = action
+	m.quarantinePath = quarantinePath
+	return m
+}
+
+// Upload uploads a file, see upload for the checks it passes first
+func (m *Manager) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	return m.upload(ctx, src, file.Size, file.Filename, opts, func(opts UploadOptions) (*UploadResult, error) {
+		return m.storage.Upload(ctx, file, opts)
+	})
+}
+
+// UploadFromReader uploads from reader, see upload for the checks it passes first.
+// Readers that cannot seek are buffered in memory when the content is read more than once.
+func (m *Manager) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	content, ok := reader.(io.ReadSeeker)
+	if !ok && !m.rereads(opts) {
+		return m.uploadStream(ctx, reader, filename, opts)
+	}
+
+	if !ok {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		content = bytes.NewReader(data)
+	}
+
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return m.upload(ctx, content, size, filename, opts, func(opts UploadOptions) (*UploadResult, error) {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		return m.storage.UploadFromReader(ctx, content, filename, opts)
+	})
+}
+
+// rereads reports whether an upload with opts reads its content more than once
+func (m *Manager) rereads(opts UploadOptions) bool {
+	return opts.MaxSize > 0 || m.scanner != nil || (m.images != nil && !opts.SkipVariants)
+}
+
+// upload checks content before store commits it to the driver: the type sniffed from its
+// magic bytes and its size against opts, then the scanner. Images get their variants afterwards.
+func (m *Manager) upload(ctx context.Context, content io.ReadSeeker, size int64, filename string, opts UploadOptions, store func(UploadOptions) (*UploadResult, error)) (*UploadResult, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var err error
+	if _, opts.ContentType, err = Sniff(content, filename); err != nil {
+		return nil, err
+	}
+
+	if err := validateUpload(size, opts.ContentType, opts); err != nil {
+		return nil, err
+	}
+
+	if m.scanner != nil {
+		if err := m.scan(ctx, content, filename, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := store(opts)
+	if err != nil || m.images == nil || opts.SkipVariants {
+		return result, err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		m.deleteUpload(ctx, result)
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return m.storeVariants(ctx, content, result, opts)
+}
+
+// uploadStream stores reader in a single pass, only its type is checked
+func (m *Manager) uploadStream(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	head, contentType, err := Sniff(reader, filename)
+	if err != nil {
+		return nil, err
+	}
+	opts.ContentType = contentType
+
+	if err := validateUpload(0, contentType, opts); err != nil {
+		return nil, err
+	}
+
+	return m.storage.UploadFromReader(ctx, io.MultiReader(bytes.NewReader(head), reader), filename, opts)
+}
+
+// scan rejects content the scanner flagged, after quarantining it when configured.
+// Content that cannot be scanned is rejected as well.
+func (m *Manager) scan(ctx context.Context, content io.ReadSeeker, filename string, opts UploadOptions) error {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	result, err := m.scanner.Scan(ctx, content)
+	if err != nil {
+		return fmt.Errorf("failed to scan file: %w", err)
+	}
+	if !result.Infected {
+		return nil
+	}
+
+	if m.scanAction == ScanActionQuarantine {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("%w: %s, failed to quarantine: %v", ErrFileInfected, result.Threat, err)
+		}
+		if _, err := m.storage.UploadFromReader(ctx, content, generateFilename(filename), UploadOptions{
+			Path:        m.quarantinePath,
+			ContentType: opts.ContentType,
+		}); err != nil {
+			return fmt.Errorf("%w: %s, failed to quarantine: %v", ErrFileInfected, result.Threat, err)
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrFileInfected, result.Threat)
+}
+
+// Inspect checks content that reached the driver without passing the manager, e.g. through a
+// presigned URL, and returns its sniffed type. The type must be opts.ContentType when set and
+// allowed by opts, then the scanner runs. Rejected content is deleted, flagged content is moved
+// under the quarantine path instead with ScanActionQuarantine. Content that could not be
+// checked is kept, so the check can be retried.
+func (m *Manager) Inspect(ctx context.Context, path string, opts UploadOptions) (string, error) {
+	contentType, err := m.sniffStored(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	declared, _, _ := strings.Cut(opts.ContentType, ";")
+	if declared = strings.TrimSpace(declared); declared != "" && declared != contentType {
+		return "", m.discard(ctx, path, fmt.Errorf("%w: %s is not %s", ErrTypeMismatch, contentType, declared))
+	}
+
+	opts.ContentType = contentType
+	if err := validateUpload(0, contentType, opts); err != nil {
+		return "", m.discard(ctx, path, err)
+	}
+
+	if m.scanner == nil {
+		return contentType, nil
+	}
+
+	result, err := m.scanStored(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if !result.Infected {
+		return contentType, nil
+	}
+
+	infected := fmt.Errorf("%w: %s", ErrFileInfected, result.Threat)
+	if m.scanAction == ScanActionQuarantine {
+		if _, err := m.storage.Move(ctx, path, m.quarantinePath+"/"+generateFilename(path)); err != nil {
+			return "", m.discard(ctx, path, fmt.Errorf("%w: %s, failed to quarantine: %v", ErrFileInfected, result.Threat, err))
+		}
+		return "", infected
+	}
+
+	return "", m.discard(ctx, path, infected)
+}
+
+// sniffStored returns the type of the magic bytes of a stored file
+func (m *Manager) sniffStored(ctx context.Context, path string) (string, error) {
+	reader, err := m.storage.Open(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	_, contentType, err := Sniff(reader, path)
+	return contentType, err
+}
+
+// scanStored streams a stored file through the scanner
+func (m *Manager) scanStored(ctx context.Context, path string) (*ScanResult, error) {
+	reader, err := m.storage.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	result, err := m.scanner.Scan(ctx, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan file: %w", err)
+	}
+	return result, nil
+}
+
+// discard deletes rejected content and returns the rejection, with the failure to delete it
+func (m *Manager) discard(ctx context.Context, path string, rejection error) error {
+	if err := m.storage.Delete(context.WithoutCancel(ctx), path); err != nil && !errors.Is(err, ErrFileNotFound) {
+		return fmt.Errorf("%w, failed to remove it: %v", rejection, err)
+	}
+	return rejection
+}
+
+// storeVariants stores the image variants of result next to it. Nothing is kept when
+// the image is invalid or a variant cannot be stored, the original included.
+func (m *Manager) storeVariants(ctx context.Context, src io.Reader, result *UploadResult, opts UploadOptions) (*UploadResult, error) {
+	processed, err := m.images.Process(src)
+	if err != nil {
+		m.deleteUpload(ctx, result)
+		return nil, err
+	}
+
+	for _, image := range processed {
+		stored, err := m.storage.UploadFromReader(ctx, bytes.NewReader(image.Data), variantFilename(result.Path, image), UploadOptions{
+			Path:   filepath.Dir(result.Path),
+			Public: opts.Public,
+		})
+		if err != nil {
+			m.deleteUpload(ctx, result)
+			return nil, fmt.Errorf("failed to store image variant %s: %w", image.Variant.Name, err)
+		}
+
+		result.Variants = append(result.Variants, VariantResult{
+			Name:     image.Variant.Name,
+			Path:     stored.Path,
+			URL:      stored.URL,
+			MimeType: image.MimeType(),
+			Width:    image.Width,
+			Height:   image.Height,
+			Size:     int64(len(image.Data)),
+		})
+	}
+
+	return result, nil
+}
+
+// deleteUpload removes an upload and the variants stored so far, on a best effort basis.
+// It also runs when the upload failed because ctx was canceled.
+func (m *Manager) deleteUpload(ctx context.Context, result *UploadResult) {
+	ctx = context.WithoutCancel(ctx)
+	for _, variant := range result.Variants {
+		_ = m.storage.Delete(ctx, variant.Path)
+	}
+	_ = m.storage.Delete(ctx, result.Path)
 }
 
 // Delete deletes a file
-func (m *Manager) Delete(path string) error {
-	return m.storage.Delete(path)
+func (m *Manager) Delete(ctx context.Context, path string) error {
+	return m.storage.Delete(ctx, path)
 }
 
 // Exists checks if file exists
-func (m *Manager) Exists(path string) (bool, error) {
-	return m.storage.Exists(path)
+func (m *Manager) Exists(ctx context.Context, path string) (bool, error) {
+	return m.storage.Exists(ctx, path)
 }
 
 // URL gets public URL
-func (m *Manager) URL(path string) (string, error) {
-	return m.storage.URL(path)
+func (m *Manager) URL(ctx context.Context, path string) (string, error) {
+	return m.storage.URL(ctx, path)
+}
+
+// PresignUpload presigns a direct upload to path
+func (m *Manager) PresignUpload(ctx context.Context, path string, opts PresignOptions) (*PresignedUpload, error) {
+	return m.storage.PresignUpload(ctx, path, opts)
+}
+
+// SignedURL gets a download URL valid for ttl
+func (m *Manager) SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	return m.storage.SignedURL(ctx, path, ttl)
+}
+
+// VerifySignedRequest verifies a signed request served by the application
+func (m *Manager) VerifySignedRequest(method, path string, query url.Values) error {
+	verifier, ok := unwrapAs[SignedRequestVerifier](m.storage)
+	if !ok {
+		return ErrSigningNotSupported
+	}
+	return verifier.VerifySignedRequest(method, path, query)
+}
+
+// Open opens a stored file
+func (m *Manager) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return m.storage.Open(ctx, path)
+}
+
+// Stat describes a stored file
+func (m *Manager) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	return m.storage.Stat(ctx, path)
+}
+
+// Size returns the size of a stored file in bytes
+func (m *Manager) Size(ctx context.Context, path string) (int64, error) {
+	return m.storage.Size(ctx, path)
+}
+
+// List returns the stored files whose path starts with prefix
+func (m *Manager) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	return m.storage.List(ctx, prefix)
+}
+
+// Copy copies a stored file to dst
+func (m *Manager) Copy(ctx context.Context, src, dst string) (*FileInfo, error) {
+	return m.storage.Copy(ctx, src, dst)
+}
+
+// Move moves a stored file to dst
+func (m *Manager) Move(ctx context.Context, src, dst string) (*FileInfo, error) {
+	return m.storage.Move(ctx, src, dst)
+}
+
+// Stager returns the stager of resumable uploads, chunks are staged under dir.
+// S3 stages them as native multipart parts, other drivers receive the assembled file
+// through the manager, so images get their variants. Mirrored and encrypted disks always
+// stage locally, native parts would bypass them, and so do disks with a scanner.
+func (m *Manager) Stager(dir string) Stager {
+	if s3Storage, ok := multipartTarget(m.storage); ok && m.scanner == nil {
+		return newMultipartStager(dir, s3Storage)
+	}
+	return newLocalStager(dir, m)
+}
+
+// multipartTarget returns the S3 driver of storage when nothing but the instrumentation
+// wraps it, other decorators must see every write
+func multipartTarget(storage Storage) (*S3Storage, bool) {
+	for {
+		switch s := storage.(type) {
+		case *S3Storage:
+			return s, true
+		case *instrumentedStorage:
+			storage = s.storage
+		default:
+			return nil, false
+		}
+	}
 }
 
 // GetDriver returns current driver
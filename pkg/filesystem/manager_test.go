@@ -0,0 +1,264 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_UploadFromReader_Checks(t *testing.T) {
+	ctx := context.Background()
+	clamd := NewClamdScanner(ClamdConfig{Address: clamdStub(t, eicarReply)})
+
+	t.Run("should record the sniffed type", func(t *testing.T) {
+		manager := NewManager(NewLocalStorage(t.TempDir(), ""))
+
+		result, err := manager.UploadFromReader(ctx, bytes.NewReader(pngOf(t, 4, 4)), "photo.txt", UploadOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", result.MimeType)
+	})
+
+	t.Run("should reject a type outside the allowlist by its content", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, ""))
+
+		_, err := manager.UploadFromReader(ctx, strings.NewReader("<?php echo 1;"), "photo.png", UploadOptions{
+			Path:             "images",
+			AllowedMimeTypes: []string{"image/*"},
+		})
+
+		assert.ErrorContains(t, err, "text/plain is not allowed")
+		assert.NoDirExists(t, filepath.Join(dir, "images"))
+	})
+
+	t.Run("should reject content over the size limit", func(t *testing.T) {
+		manager := NewManager(NewLocalStorage(t.TempDir(), ""))
+
+		_, err := manager.UploadFromReader(ctx, strings.NewReader(strings.Repeat("a", 100)), "big.txt", UploadOptions{MaxSize: 10})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should store content that passed the scanner", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, "")).WithScanner(clamd, ScanActionReject, "")
+
+		result, err := manager.UploadFromReader(ctx, strings.NewReader("hello world"), "hello.txt", UploadOptions{Path: "files"})
+
+		require.NoError(t, err)
+		stored, err := os.ReadFile(filepath.Join(dir, result.Path))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(stored))
+	})
+
+	t.Run("should reject infected content", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, "")).WithScanner(clamd, ScanActionReject, "")
+
+		_, err := manager.UploadFromReader(ctx, strings.NewReader(eicar), "eicar.txt", UploadOptions{Path: "files"})
+
+		assert.ErrorIs(t, err, ErrFileInfected)
+		assert.ErrorContains(t, err, "Eicar-Test-Signature")
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should quarantine infected content", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, "")).WithScanner(clamd, ScanActionQuarantine, "")
+
+		_, err := manager.UploadFromReader(ctx, strings.NewReader(eicar), "eicar.txt", UploadOptions{Path: "files"})
+
+		assert.ErrorIs(t, err, ErrFileInfected)
+		assert.NoDirExists(t, filepath.Join(dir, "files"))
+		quarantined, err := os.ReadDir(filepath.Join(dir, DefaultQuarantinePath))
+		require.NoError(t, err)
+		require.Len(t, quarantined, 1)
+		assert.True(t, strings.HasSuffix(quarantined[0].Name(), ".txt"))
+	})
+}
+
+func TestManager_Inspect(t *testing.T) {
+	ctx := context.Background()
+	clamd := NewClamdScanner(ClamdConfig{Address: clamdStub(t, eicarReply)})
+
+	store := func(t *testing.T, dir, path, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+
+	t.Run("should return the type of clean content", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, "")).WithScanner(clamd, ScanActionReject, "")
+		store(t, dir, "files/hello.txt", "hello world")
+
+		contentType, err := manager.Inspect(ctx, "files/hello.txt", UploadOptions{ContentType: "text/plain; charset=utf-8"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", contentType)
+		assert.FileExists(t, filepath.Join(dir, "files/hello.txt"))
+	})
+
+	t.Run("should delete content of another type than declared", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, ""))
+		store(t, dir, "files/photo.png", "<?php echo 1;")
+
+		_, err := manager.Inspect(ctx, "files/photo.png", UploadOptions{ContentType: "image/png"})
+
+		assert.ErrorIs(t, err, ErrTypeMismatch)
+		assert.NoFileExists(t, filepath.Join(dir, "files/photo.png"))
+	})
+
+	t.Run("should delete content outside the allowlist", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, ""))
+		store(t, dir, "files/hello.txt", "hello world")
+
+		_, err := manager.Inspect(ctx, "files/hello.txt", UploadOptions{AllowedMimeTypes: []string{"image/*"}})
+
+		assert.ErrorContains(t, err, "text/plain is not allowed")
+		assert.NoFileExists(t, filepath.Join(dir, "files/hello.txt"))
+	})
+
+	t.Run("should quarantine infected content", func(t *testing.T) {
+		dir := t.TempDir()
+		manager := NewManager(NewLocalStorage(dir, "")).WithScanner(clamd, ScanActionQuarantine, "")
+		store(t, dir, "files/eicar.txt", eicar)
+
+		_, err := manager.Inspect(ctx, "files/eicar.txt", UploadOptions{})
+
+		assert.ErrorIs(t, err, ErrFileInfected)
+		assert.NoFileExists(t, filepath.Join(dir, "files/eicar.txt"))
+		quarantined, err := os.ReadDir(filepath.Join(dir, DefaultQuarantinePath))
+		require.NoError(t, err)
+		assert.Len(t, quarantined, 1)
+	})
+
+	t.Run("should keep content the scanner could not check", func(t *testing.T) {
+		dir := t.TempDir()
+		unreachable := NewClamdScanner(ClamdConfig{Address: "127.0.0.1:1", Timeout: time.Second})
+		manager := NewManager(NewLocalStorage(dir, "")).WithScanner(unreachable, ScanActionReject, "")
+		store(t, dir, "files/hello.txt", "hello world")
+
+		_, err := manager.Inspect(ctx, "files/hello.txt", UploadOptions{})
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrFileInfected)
+		assert.FileExists(t, filepath.Join(dir, "files/hello.txt"))
+	})
+}
+
+func TestManager_Stager(t *testing.T) {
+	s3Storage, err := NewS3Storage(context.Background(), S3Config{Region: "us-east-1", Bucket: "assets", AccessKeyID: "key", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+
+	t.Run("should stage S3 uploads as multipart parts", func(t *testing.T) {
+		assert.IsType(t, &multipartStager{}, NewManager(s3Storage).Stager(t.TempDir()))
+	})
+
+	t.Run("should stage locally when uploads are scanned", func(t *testing.T) {
+		clamd := NewClamdScanner(ClamdConfig{Address: clamdStub(t, eicarReply)})
+		manager := NewManager(s3Storage).WithScanner(clamd, ScanActionReject, "")
+
+		assert.IsType(t, &localStager{}, manager.Stager(t.TempDir()))
+	})
+}
+
+func TestNewManagerFromConfig_Disks(t *testing.T) {
+	ctx := context.Background()
+	defaultDir, tempDir, backupDir := t.TempDir(), t.TempDir(), t.TempDir()
+
+	manager, err := NewManagerFromConfig(ctx, Config{
+		Driver: DriverLocal,
+		Local:  LocalConfig{BasePath: defaultDir},
+		Mirror: "backup",
+		Disks: map[string]DiskConfig{
+			"temp":   {Driver: DriverLocal, Local: LocalConfig{BasePath: tempDir}},
+			"backup": {Driver: DriverLocal, Local: LocalConfig{BasePath: backupDir}},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("should store on the named disk", func(t *testing.T) {
+		temp, err := manager.Disk("temp")
+		require.NoError(t, err)
+
+		result, err := temp.UploadFromReader(ctx, strings.NewReader("hello"), "hello.txt", UploadOptions{Path: "files"})
+
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(tempDir, result.Path))
+		assert.NoFileExists(t, filepath.Join(defaultDir, result.Path))
+	})
+
+	t.Run("should reach every disk from any disk", func(t *testing.T) {
+		temp, err := manager.Disk("temp")
+		require.NoError(t, err)
+
+		disk, err := temp.Disk("")
+		require.NoError(t, err)
+		assert.Same(t, manager, disk)
+
+		_, err = manager.Disk("missing")
+		assert.ErrorIs(t, err, ErrUnknownDisk)
+	})
+
+	t.Run("should replicate writes to the mirror", func(t *testing.T) {
+		result, err := manager.UploadFromReader(ctx, strings.NewReader("hello"), "hello.txt", UploadOptions{Path: "files"})
+		require.NoError(t, err)
+
+		mirrored, err := os.ReadFile(filepath.Join(backupDir, result.Path))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(mirrored))
+
+		moved, err := manager.Move(ctx, result.Path, "archive/hello.txt")
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(backupDir, moved.Path))
+		assert.NoFileExists(t, filepath.Join(backupDir, result.Path))
+
+		require.NoError(t, manager.Delete(ctx, moved.Path))
+		assert.NoFileExists(t, filepath.Join(backupDir, moved.Path))
+	})
+
+	t.Run("should keep writing when the mirror misses a file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(defaultDir, "before.txt"), []byte("hello"), 0644))
+
+		assert.NoError(t, manager.Delete(ctx, "before.txt"))
+	})
+
+	t.Run("should stage mirrored uploads locally", func(t *testing.T) {
+		assert.IsType(t, &localStager{}, manager.Stager(t.TempDir()))
+	})
+
+	t.Run("should list every disk by name", func(t *testing.T) {
+		var names []string
+		for _, disk := range manager.Disks() {
+			names = append(names, disk.Name())
+		}
+		assert.Equal(t, []string{"backup", DefaultDisk, "temp"}, names)
+
+		backup, err := manager.Disk("backup")
+		require.NoError(t, err)
+		assert.True(t, backup.IsMirror())
+		assert.False(t, manager.IsMirror())
+	})
+
+	t.Run("should reject an unknown mirror", func(t *testing.T) {
+		_, err := NewManagerFromConfig(ctx, Config{
+			Driver: DriverLocal,
+			Local:  LocalConfig{BasePath: defaultDir},
+			Mirror: "missing",
+		})
+
+		assert.ErrorIs(t, err, ErrUnknownDisk)
+	})
+}
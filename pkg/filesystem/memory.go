@@ -0,0 +1,229 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"goilerplate/pkg/utils"
+)
+
+// MemoryStorage implements Storage in memory, for tests and local development.
+// Files are lost when the process exits.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data     []byte
+	mimeType string
+	modTime  time.Time
+}
+
+// NewMemoryStorage creates an empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string]memoryFile)}
+}
+
+// Upload uploads file from multipart form
+func (m *MemoryStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+	if err := validateUpload(file.Size, file.Header.Get("Content-Type"), opts); err != nil {
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	filename := opts.Filename
+	if filename == "" {
+		filename = generateFilename(file.Filename)
+	}
+
+	result, err := m.UploadFromReader(ctx, src, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.OriginalName = file.Filename
+	return result, nil
+}
+
+// UploadFromReader stores the content of reader as filename below opts.Path
+func (m *MemoryStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	data, err := io.ReadAll(contextReader{ctx: ctx, reader: reader})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	key := memoryKey(path.Join(opts.Path, filename))
+	mimeType := contentTypeOf(filename, opts)
+
+	m.mu.Lock()
+	m.files[key] = memoryFile{data: data, mimeType: mimeType, modTime: utils.Now()}
+	m.mu.Unlock()
+
+	return &UploadResult{
+		OriginalName: filename,
+		Filename:     filename,
+		Path:         key,
+		Size:         int64(len(data)),
+		MimeType:     mimeType,
+		URL:          memoryURL(key),
+		Driver:       DriverMemory,
+	}, nil
+}
+
+// Delete deletes a file
+func (m *MemoryStorage) Delete(ctx context.Context, path string) error {
+	key := memoryKey(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.files[key]; !ok {
+		return ErrFileNotFound
+	}
+	delete(m.files, key)
+	return nil
+}
+
+// Exists checks if file exists
+func (m *MemoryStorage) Exists(ctx context.Context, path string) (bool, error) {
+	_, ok := m.get(path)
+	return ok, nil
+}
+
+// Open streams a file, later writes do not affect the returned reader
+func (m *MemoryStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	file, ok := m.get(path)
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	return io.NopCloser(bytes.NewReader(file.data)), nil
+}
+
+// Stat describes a file
+func (m *MemoryStorage) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	key := memoryKey(path)
+	file, ok := m.get(key)
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	return file.info(key), nil
+}
+
+// Size returns the size of a file in bytes
+func (m *MemoryStorage) Size(ctx context.Context, path string) (int64, error) {
+	info, err := m.Stat(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// List returns the files whose path starts with prefix, sorted by path
+func (m *MemoryStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	m.mu.RLock()
+	files := []FileInfo{}
+	for key, file := range m.files {
+		if strings.HasPrefix(key, prefix) {
+			files = append(files, *file.info(key))
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Copy copies src to dst
+func (m *MemoryStorage) Copy(ctx context.Context, src, dst string) (*FileInfo, error) {
+	return m.transfer(src, dst, false)
+}
+
+// Move moves src to dst
+func (m *MemoryStorage) Move(ctx context.Context, src, dst string) (*FileInfo, error) {
+	return m.transfer(src, dst, true)
+}
+
+// URL gets the URL of a file, memory files are only reachable through the storage
+func (m *MemoryStorage) URL(ctx context.Context, path string) (string, error) {
+	return memoryURL(memoryKey(path)), nil
+}
+
+// PresignUpload is not supported, nothing serves memory files over HTTP
+func (m *MemoryStorage) PresignUpload(ctx context.Context, path string, opts PresignOptions) (*PresignedUpload, error) {
+	return nil, ErrSigningNotSupported
+}
+
+// SignedURL is not supported, nothing serves memory files over HTTP
+func (m *MemoryStorage) SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+// GetDriver returns the driver name
+func (m *MemoryStorage) GetDriver() Driver {
+	return DriverMemory
+}
+
+func (m *MemoryStorage) get(path string) (memoryFile, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[memoryKey(path)]
+	return file, ok
+}
+
+// transfer copies src to dst, removing src when move is set
+func (m *MemoryStorage) transfer(src, dst string, move bool) (*FileInfo, error) {
+	src, dst = memoryKey(src), memoryKey(dst)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, ok := m.files[src]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+
+	// Content is never modified in place, so the copy shares it
+	if move {
+		delete(m.files, src)
+	} else {
+		file.modTime = utils.Now()
+	}
+	m.files[dst] = file
+
+	return file.info(dst), nil
+}
+
+func (f memoryFile) info(key string) *FileInfo {
+	return &FileInfo{
+		Path:     key,
+		Name:     path.Base(key),
+		Size:     int64(len(f.data)),
+		MimeType: f.mimeType,
+		ModTime:  f.modTime,
+	}
+}
+
+// memoryKey normalizes path the way the local driver resolves it
+func memoryKey(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+func memoryURL(key string) string {
+	return "memory://" + key
+}
@@ -0,0 +1,124 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"path"
+
+	"goilerplate/pkg/logger"
+)
+
+// mirrorStorage replicates the writes of storage to mirror, at the same paths, while every
+// read is served by storage. It backs migrations between providers: point the mirror at
+// the new disk, copy the existing files over, then switch the disks.
+//
+// The primary storage stays authoritative, a failed replication is logged and never fails
+// the write. Presigned uploads go straight to the primary storage and are not replicated.
+type mirrorStorage struct {
+	Storage
+	mirror     Storage
+	mirrorName string
+}
+
+// Mirror wraps storage so its writes are replicated to the mirror disk named name
+func Mirror(storage, mirror Storage, name string) Storage {
+	return &mirrorStorage{Storage: storage, mirror: mirror, mirrorName: name}
+}
+
+// Unwrap returns the primary storage
+func (s *mirrorStorage) Unwrap() Storage {
+	return s.Storage
+}
+
+func (s *mirrorStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+	result, err := s.Storage.Upload(ctx, file, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.replicate(ctx, "upload", result.Path, func(ctx context.Context) error {
+		return s.put(ctx, result, opts.Public)
+	})
+	return result, nil
+}
+
+func (s *mirrorStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	result, err := s.Storage.UploadFromReader(ctx, reader, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.replicate(ctx, "upload", result.Path, func(ctx context.Context) error {
+		return s.put(ctx, result, opts.Public)
+	})
+	return result, nil
+}
+
+func (s *mirrorStorage) Delete(ctx context.Context, path string) error {
+	if err := s.Storage.Delete(ctx, path); err != nil {
+		return err
+	}
+
+	s.replicate(ctx, "delete", path, func(ctx context.Context) error {
+		return s.mirror.Delete(ctx, path)
+	})
+	return nil
+}
+
+func (s *mirrorStorage) Copy(ctx context.Context, src, dst string) (*FileInfo, error) {
+	info, err := s.Storage.Copy(ctx, src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	s.replicate(ctx, "copy", dst, func(ctx context.Context) error {
+		_, err := s.mirror.Copy(ctx, src, dst)
+		return err
+	})
+	return info, nil
+}
+
+func (s *mirrorStorage) Move(ctx context.Context, src, dst string) (*FileInfo, error) {
+	info, err := s.Storage.Move(ctx, src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	s.replicate(ctx, "move", dst, func(ctx context.Context) error {
+		_, err := s.mirror.Move(ctx, src, dst)
+		return err
+	})
+	return info, nil
+}
+
+// put streams the stored upload from the primary storage into the mirror at the same path
+func (s *mirrorStorage) put(ctx context.Context, result *UploadResult, public bool) error {
+	reader, err := s.Storage.Open(ctx, result.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reader.Close() }()
+
+	filename := path.Base(result.Path)
+	_, err = s.mirror.UploadFromReader(ctx, reader, filename, UploadOptions{
+		Path:        path.Dir(result.Path),
+		Filename:    filename,
+		Public:      public,
+		ContentType: result.MimeType,
+	})
+	return err
+}
+
+// replicate runs write against the mirror once the primary write succeeded, even if the
+// request was canceled in between
+func (s *mirrorStorage) replicate(ctx context.Context, operation, path string, write func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	// Files written before the mirror was added may be missing there
+	if err := write(ctx); err != nil && !errors.Is(err, ErrFileNotFound) && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn(ctx, fmt.Sprintf("failed to replicate %s of %s to mirror disk %s: %v", operation, path, s.mirrorName, err))
+	}
+}
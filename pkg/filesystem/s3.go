@@ -2,11 +2,18 @@ package filesystem
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -15,23 +22,27 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
-// S3Storage implements Storage for AWS S3
+// S3Storage implements Storage for AWS S3 and S3-compatible services
 type S3Storage struct {
-	client   *s3.Client
-	bucket   string
-	region   string
-	endpoint string
-	ctx      context.Context
+	client    *s3.Client
+	presigner *s3.PresignClient
+	bucket    string
+	region    string
+	endpoint  string
+	pathStyle bool
 }
 
 // NewS3Storage creates a new S3 storage instance
 func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
-	awsCfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(cfg.Region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			cfg.AccessKeyID, cfg.SecretAccessKey, "",
-		)),
-	)
+	options := []func(*config.LoadOptions) error{config.WithRegion(cfg.Region)}
+	// Without static keys the default chain applies: environment, shared config, IAM roles
+	if cfg.AccessKeyID != "" {
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken,
+		)))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -39,22 +50,23 @@ func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
 		if cfg.Endpoint != "" {
 			o.BaseEndpoint = aws.String(cfg.Endpoint)
-			// For custom endpoints that include bucket name, don't use path style
-			o.UsePathStyle = false
 		}
+		// MinIO and most S3-compatible services address buckets by path
+		o.UsePathStyle = cfg.UsePathStyle
 	})
 
 	return &S3Storage{
-		client:   client,
-		bucket:   cfg.Bucket,
-		region:   cfg.Region,
-		endpoint: cfg.Endpoint,
-		ctx:      ctx,
+		client:    client,
+		presigner: s3.NewPresignClient(client),
+		bucket:    cfg.Bucket,
+		region:    cfg.Region,
+		endpoint:  strings.TrimSuffix(cfg.Endpoint, "/"),
+		pathStyle: cfg.UsePathStyle,
 	}, nil
 }
 
 // Upload uploads file from multipart form
-func (s *S3Storage) Upload(file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
+func (s *S3Storage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error) {
 	if err := validateUpload(file.Size, file.Header.Get("Content-Type"), opts); err != nil {
 		return nil, err
 	}
@@ -70,34 +82,37 @@ func (s *S3Storage) Upload(file *multipart.FileHeader, opts UploadOptions) (*Upl
 		filename = file.Filename
 	}
 
-	return s.UploadFromReader(src, filename, opts)
+	return s.UploadFromReader(ctx, src, filename, opts)
 }
 
 // UploadFromReader uploads from io.Reader
-func (s *S3Storage) UploadFromReader(reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
-	key := filepath.Join(opts.Path, generateFilename(filename))
+func (s *S3Storage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error) {
+	name := opts.Filename
+	if name == "" {
+		name = generateFilename(filename)
+	}
+	key := filepath.Join(opts.Path, name)
 	key = strings.ReplaceAll(key, "\\", "/")
 
-	bucket := ""
 	input := &s3.PutObjectInput{
-		Bucket:      &bucket,
+		Bucket:      s.bucketParam(),
 		Key:         aws.String(key),
 		Body:        reader,
-		ContentType: aws.String(detectMimeType(filename)),
+		ContentType: aws.String(contentTypeOf(filename, opts)),
 	}
 
 	if opts.Public {
 		input.ACL = types.ObjectCannedACLPublicRead
 	}
 
-	_, err := s.client.PutObject(s.ctx, input)
+	_, err := s.client.PutObject(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload to S3: %w", err)
 	}
 
 	// Get file size
-	head, _ := s.client.HeadObject(s.ctx, &s3.HeadObjectInput{
-		Bucket: &bucket,
+	head, _ := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: s.bucketParam(),
 		Key:    aws.String(key),
 	})
 
@@ -108,13 +123,7 @@ func (s *S3Storage) UploadFromReader(reader io.Reader, filename string, opts Upl
 
 	url := ""
 	if opts.Public {
-		if s.endpoint != "" {
-			// Custom endpoint (MinIO, etc) - already includes bucket in domain
-			url = fmt.Sprintf("%s/%s", s.endpoint, key)
-		} else {
-			// AWS S3 standard endpoint
-			url = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
-		}
+		url = s.publicURL(key)
 	}
 
 	return &UploadResult{
@@ -122,18 +131,17 @@ func (s *S3Storage) UploadFromReader(reader io.Reader, filename string, opts Upl
 		Filename:     filename,
 		Path:         key,
 		Size:         size,
-		MimeType:     detectMimeType(filename),
+		MimeType:     contentTypeOf(filename, opts),
 		URL:          url,
 		Driver:       DriverS3,
 	}, nil
 }
 
 // Delete deletes a file
-func (s *S3Storage) Delete(key string) error {
-	// For custom endpoints that already include bucket name, use empty bucket
-
-	_, err := s.client.DeleteObject(s.ctx, &s3.DeleteObjectInput{
-		Key: aws.String(key),
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: s.bucketParam(),
+		Key:    aws.String(key),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete from S3: %w", err)
@@ -142,13 +150,13 @@ func (s *S3Storage) Delete(key string) error {
 }
 
 // Exists checks if file exists
-func (s *S3Storage) Exists(key string) (bool, error) {
-	// For custom endpoints that already include bucket name, use empty bucket
-	_, err := s.client.HeadObject(s.ctx, &s3.HeadObjectInput{
-		Key: aws.String(key),
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: s.bucketParam(),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "404") {
+		if isS3NotFound(err) {
 			return false, nil
 		}
 		return false, fmt.Errorf("failed to check file: %w", err)
@@ -156,17 +164,211 @@ func (s *S3Storage) Exists(key string) (bool, error) {
 	return true, nil
 }
 
+// Open streams an object
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: s.bucketParam(),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, s3Err("failed to open file", err)
+	}
+	return output.Body, nil
+}
+
+// Stat describes an object from its HEAD
+func (s *S3Storage) Stat(ctx context.Context, key string) (*FileInfo, error) {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: s.bucketParam(),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, s3Err("failed to stat file", err)
+	}
+
+	return &FileInfo{
+		Path:     key,
+		Name:     path.Base(key),
+		Size:     aws.ToInt64(head.ContentLength),
+		MimeType: aws.ToString(head.ContentType),
+		ModTime:  aws.ToTime(head.LastModified),
+	}, nil
+}
+
+// Size returns the size of an object in bytes
+func (s *S3Storage) Size(ctx context.Context, key string) (int64, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// List pages through the objects starting with prefix. S3 lists keys in order and does
+// not return content types, they are detected from the extension.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	files := []FileInfo{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: s.bucketParam(),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			files = append(files, FileInfo{
+				Path:     key,
+				Name:     path.Base(key),
+				Size:     aws.ToInt64(object.Size),
+				MimeType: DetectMimeType(key),
+				ModTime:  aws.ToTime(object.LastModified),
+			})
+		}
+	}
+
+	return files, nil
+}
+
+// Copy copies an object server side, objects over 5GB need a multipart copy and fail
+func (s *S3Storage) Copy(ctx context.Context, src, dst string) (*FileInfo, error) {
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     s.bucketParam(),
+		Key:        aws.String(dst),
+		CopySource: aws.String(s.bucket + "/" + escapeKey(src)),
+	})
+	if err != nil {
+		return nil, s3Err("failed to copy file", err)
+	}
+
+	return s.Stat(ctx, dst)
+}
+
+// Move copies the object and deletes the source, S3 has no rename
+func (s *S3Storage) Move(ctx context.Context, src, dst string) (*FileInfo, error) {
+	info, err := s.Copy(ctx, src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Delete(ctx, src); err != nil {
+		return nil, fmt.Errorf("failed to remove moved file: %w", err)
+	}
+
+	return info, nil
+}
+
 // URL gets public URL for file
-func (s *S3Storage) URL(key string) (string, error) {
-	if s.endpoint != "" {
-		// Custom endpoint (MinIO, etc) - already includes bucket in domain
-		return fmt.Sprintf("%s/%s", s.endpoint, key), nil
+func (s *S3Storage) URL(ctx context.Context, key string) (string, error) {
+	return s.publicURL(key), nil
+}
+
+// PresignUpload presigns a PutObject request. Content type, length and SHA-256 checksum
+// are signed headers, so S3 rejects uploads that do not match them.
+func (s *S3Storage) PresignUpload(ctx context.Context, key string, opts PresignOptions) (*PresignedUpload, error) {
+	checksum, err := hex.DecodeString(opts.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum: %w", err)
+	}
+
+	request, err := s.presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:         s.bucketParam(),
+		Key:            aws.String(key),
+		ContentType:    aws.String(opts.ContentType),
+		ContentLength:  aws.Int64(opts.Size),
+		ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(checksum)),
+	}, s3.WithPresignExpires(opts.TTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign S3 upload: %w", err)
+	}
+
+	return &PresignedUpload{
+		URL:       request.URL,
+		Method:    request.Method,
+		Headers:   signedHeaders(request.SignedHeader),
+		Path:      key,
+		ExpiresAt: time.Now().Add(opts.TTL),
+	}, nil
+}
+
+// SignedURL presigns a GetObject request
+func (s *S3Storage) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	request, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: s.bucketParam(),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 download: %w", err)
 	}
-	// AWS S3 standard endpoint
-	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key), nil
+	return request.URL, nil
 }
 
 // GetDriver returns the driver name
 func (s *S3Storage) GetDriver() Driver {
 	return DriverS3
 }
+
+// bucketParam is the Bucket of requests. It is left empty for custom endpoints addressed
+// by host, their endpoint already includes the bucket name.
+func (s *S3Storage) bucketParam() *string {
+	if s.endpoint != "" && !s.pathStyle {
+		return aws.String("")
+	}
+	return aws.String(s.bucket)
+}
+
+// publicURL returns the unsigned URL of key
+func (s *S3Storage) publicURL(key string) string {
+	switch {
+	case s.endpoint != "" && s.pathStyle:
+		return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key)
+	case s.endpoint != "":
+		// Custom endpoint (MinIO, etc) - already includes bucket in domain
+		return fmt.Sprintf("%s/%s", s.endpoint, key)
+	default:
+		// AWS S3 standard endpoint
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
+	}
+}
+
+// isS3NotFound reports whether err is a missing key or bucket
+func isS3NotFound(err error) bool {
+	var notFound *types.NotFound
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &notFound) || errors.As(err, &noSuchKey) {
+		return true
+	}
+	return strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "404")
+}
+
+// s3Err maps missing objects to ErrFileNotFound
+func s3Err(msg string, err error) error {
+	if isS3NotFound(err) {
+		return ErrFileNotFound
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// escapeKey escapes the segments of key for CopySource, keeping the slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// signedHeaders returns the headers a presigned request must carry, Host is set by the client
+func signedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		if !strings.EqualFold(name, "Host") {
+			headers[name] = header.Get(name)
+		}
+	}
+	return headers
+}
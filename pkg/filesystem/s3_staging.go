@@ -0,0 +1,174 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// multipartStager stages chunks as the parts of a native S3 multipart upload. Chunks are
+// collected in a local tail file until they reach MinPartSize, the last part may be smaller.
+// The multipart upload starts with the first part, once the type of the content is known.
+type multipartStager struct {
+	dir     string
+	storage *S3Storage
+}
+
+func newMultipartStager(dir string, storage *S3Storage) *multipartStager {
+	return &multipartStager{dir: dir, storage: storage}
+}
+
+func (s *multipartStager) Begin(ctx context.Context, upload *StagedUpload) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.tailPath(upload), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create staging file: %w", err)
+	}
+	_ = file.Close()
+
+	return nil
+}
+
+func (s *multipartStager) Append(ctx context.Context, upload *StagedUpload, chunk io.Reader) (int64, error) {
+	sent := upload.partsSize()
+
+	written, err := writeAt(s.tailPath(upload), upload.Offset-sent, contextReader{ctx: ctx, reader: io.LimitReader(chunk, upload.Size-upload.Offset)})
+	if err != nil {
+		return 0, err
+	}
+
+	tail := upload.Offset - sent + written
+	if tail > 0 && (tail >= MinPartSize || upload.Offset+written == upload.Size) {
+		if upload.UploadID == "" {
+			if err := s.createMultipart(ctx, upload); err != nil {
+				return 0, err
+			}
+		}
+
+		part, err := s.uploadPart(ctx, upload, int32(len(upload.Parts)+1), tail)
+		if err != nil {
+			return 0, err
+		}
+		// The tail is kept until the next Append, so the part is sent again when the new state is not saved
+		upload.Parts = append(upload.Parts, *part)
+	}
+
+	upload.Offset += written
+	return written, nil
+}
+
+func (s *multipartStager) Finalize(ctx context.Context, upload *StagedUpload) (*UploadResult, error) {
+	if !upload.Complete() {
+		return nil, fmt.Errorf("upload %s is incomplete: %d of %d bytes", upload.ID, upload.Offset, upload.Size)
+	}
+
+	parts := make([]types.CompletedPart, len(upload.Parts))
+	for i, part := range upload.Parts {
+		parts[i] = types.CompletedPart{
+			PartNumber: aws.Int32(part.Number),
+			ETag:       aws.String(part.ETag),
+		}
+	}
+
+	_, err := s.storage.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          s.storage.bucketParam(),
+		Key:             aws.String(upload.Path),
+		UploadId:        aws.String(upload.UploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete S3 multipart upload: %w", err)
+	}
+
+	_ = os.Remove(s.tailPath(upload))
+
+	return &UploadResult{
+		OriginalName: filepath.Base(upload.Path),
+		Filename:     filepath.Base(upload.Path),
+		Path:         upload.Path,
+		Size:         upload.Size,
+		MimeType:     upload.contentType(),
+		Driver:       DriverS3,
+	}, nil
+}
+
+func (s *multipartStager) Abort(ctx context.Context, upload *StagedUpload) error {
+	if err := os.Remove(s.tailPath(upload)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove staging file: %w", err)
+	}
+
+	if upload.UploadID == "" {
+		return nil
+	}
+
+	_, err := s.storage.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   s.storage.bucketParam(),
+		Key:      aws.String(upload.Path),
+		UploadId: aws.String(upload.UploadID),
+	})
+	// Already aborted, e.g. by a lifecycle rule of the bucket
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to abort S3 multipart upload: %w", err)
+	}
+	return nil
+}
+
+// createMultipart starts the multipart upload receiving the parts of upload
+func (s *multipartStager) createMultipart(ctx context.Context, upload *StagedUpload) error {
+	output, err := s.storage.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      s.storage.bucketParam(),
+		Key:         aws.String(upload.Path),
+		ContentType: aws.String(upload.contentType()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create S3 multipart upload: %w", err)
+	}
+
+	upload.UploadID = aws.ToString(output.UploadId)
+	return nil
+}
+
+// uploadPart sends the tail file as part number
+func (s *multipartStager) uploadPart(ctx context.Context, upload *StagedUpload, number int32, size int64) (*StagedPart, error) {
+	file, err := os.Open(s.tailPath(upload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	output, err := s.storage.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        s.storage.bucketParam(),
+		Key:           aws.String(upload.Path),
+		UploadId:      aws.String(upload.UploadID),
+		PartNumber:    aws.Int32(number),
+		Body:          file,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload S3 part %d: %w", number, err)
+	}
+
+	return &StagedPart{
+		Number: number,
+		ETag:   aws.ToString(output.ETag),
+		Size:   size,
+	}, nil
+}
+
+func (s *multipartStager) tailPath(upload *StagedUpload) string {
+	return filepath.Join(s.dir, filepath.Base(upload.ID))
+}
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3Storage_Addressing(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		cfg       S3Config
+		signedURL string // without its query
+		publicURL string
+	}{
+		{
+			name:      "should address AWS buckets by host",
+			cfg:       S3Config{Region: "eu-west-1", Bucket: "assets"},
+			signedURL: "https://assets.s3.eu-west-1.amazonaws.com/files/a.txt",
+			publicURL: "https://assets.s3.eu-west-1.amazonaws.com/files/a.txt",
+		},
+		{
+			name:      "should address the bucket in the path of a custom endpoint",
+			cfg:       S3Config{Region: "us-east-1", Bucket: "assets", Endpoint: "http://localhost:9000/", UsePathStyle: true},
+			signedURL: "http://localhost:9000/assets/files/a.txt",
+			publicURL: "http://localhost:9000/assets/files/a.txt",
+		},
+		{
+			name:      "should keep custom endpoints including the bucket as is",
+			cfg:       S3Config{Region: "us-east-1", Bucket: "assets", Endpoint: "https://assets.example.com"},
+			signedURL: "https://assets.example.com/files/a.txt",
+			publicURL: "https://assets.example.com/files/a.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.AccessKeyID, tt.cfg.SecretAccessKey = "key", "secret"
+			storage, err := NewS3Storage(ctx, tt.cfg)
+			require.NoError(t, err)
+
+			signed, err := storage.SignedURL(ctx, "files/a.txt", time.Minute)
+			require.NoError(t, err)
+			parsed, err := url.Parse(signed)
+			require.NoError(t, err)
+			parsed.RawQuery = ""
+			assert.Equal(t, tt.signedURL, parsed.String())
+
+			public, err := storage.URL(ctx, "files/a.txt")
+			require.NoError(t, err)
+			assert.Equal(t, tt.publicURL, public)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"goilerplate/pkg/utils"
+)
+
+// Scanner inspects uploads before they are stored, e.g. for malware
+type Scanner interface {
+	// Scan reads the content from r, an error means the content could not be scanned
+	Scan(ctx context.Context, r io.Reader) (*ScanResult, error)
+}
+
+// ScanResult is the verdict of a Scanner
+type ScanResult struct {
+	Infected bool
+	Threat   string // name of the signature found, when infected
+}
+
+// ScanAction is what happens to content a Scanner flagged
+type ScanAction string
+
+const (
+	ScanActionReject     ScanAction = "reject"     // the content is discarded
+	ScanActionQuarantine ScanAction = "quarantine" // the content is kept under the quarantine path for inspection
+)
+
+// DefaultQuarantinePath is the folder of quarantined uploads on the driver
+const DefaultQuarantinePath = "quarantine"
+
+// ErrFileInfected rejects uploads flagged by the scanner, wrapped with the threat found
+var ErrFileInfected = utils.ClientErr(422, "File did not pass the malware scan")
+
+// ScanConfig configures the scanning of uploads
+type ScanConfig struct {
+	Driver         string      `mapstructure:"driver"`          // clamd, empty disables scanning
+	Action         ScanAction  `mapstructure:"action"`          // reject (default) or quarantine
+	QuarantinePath string      `mapstructure:"quarantine_path"` // defaults to DefaultQuarantinePath
+	Clamd          ClamdConfig `mapstructure:"clamd"`
+}
+
+// ClamdConfig connects to a clamd daemon
+type ClamdConfig struct {
+	Network string        `mapstructure:"network"` // tcp (default) or unix
+	Address string        `mapstructure:"address"` // e.g. localhost:3310 or /run/clamav/clamd.ctl
+	Timeout time.Duration `mapstructure:"timeout"` // of a whole scan, defaults to DefaultClamdTimeout
+}
+
+// NewScannerFromConfig creates the scanner of cfg, nil when scanning is disabled
+func NewScannerFromConfig(cfg ScanConfig) (Scanner, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "clamd":
+		if cfg.Clamd.Address == "" {
+			return nil, fmt.Errorf("clamd scanner requires address")
+		}
+		return NewClamdScanner(cfg.Clamd), nil
+	default:
+		return nil, fmt.Errorf("unsupported scanner driver: %s", cfg.Driver)
+	}
+}
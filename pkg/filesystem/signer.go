@@ -0,0 +1,98 @@
+package filesystem
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"goilerplate/pkg/utils"
+)
+
+// Query parameters of signed URLs
+const (
+	SignedParamExpires     = "expires"
+	SignedParamSignature   = "signature"
+	SignedParamSize        = "size"
+	SignedParamContentType = "content_type"
+	SignedParamChecksum    = "checksum"
+)
+
+// ErrInvalidSignature is returned for tampered, foreign or expired signed URLs
+var ErrInvalidSignature = utils.ClientErr(http.StatusForbidden, "Invalid or expired signature")
+
+// URLSigner issues and verifies expiring URLs signed with HMAC-SHA256, for drivers whose
+// files are served by this application. The signature covers the method, the path, the
+// expiry and every other query parameter, so upload constraints cannot be changed either.
+type URLSigner struct {
+	key     []byte
+	baseURL string
+}
+
+// NewURLSigner creates a signer, baseURL is the route serving signed requests
+func NewURLSigner(key, baseURL string) *URLSigner {
+	return &URLSigner{
+		key:     []byte(key),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Sign returns the signed URL of method on path valid for ttl, params are signed along
+func (s *URLSigner) Sign(method, path string, ttl time.Duration, params url.Values) (string, time.Time) {
+	expiresAt := utils.Now().Add(ttl).Truncate(time.Second)
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set(SignedParamExpires, strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set(SignedParamSignature, s.signature(method, path, query))
+
+	return s.baseURL + "/" + escapePath(path) + "?" + query.Encode(), expiresAt
+}
+
+// Verify checks the signature and expiry of a signed request
+func (s *URLSigner) Verify(method, path string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get(SignedParamExpires), 10, 64)
+	if err != nil || utils.Now().Unix() > expires {
+		return ErrInvalidSignature
+	}
+
+	signature, err := hex.DecodeString(query.Get(SignedParamSignature))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected, _ := hex.DecodeString(s.signature(method, path, query))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s *URLSigner) signature(method, path string, query url.Values) string {
+	signed := url.Values{}
+	for key, values := range query {
+		if key != SignedParamSignature {
+			signed[key] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + strings.TrimPrefix(path, "/") + "\n" + signed.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// escapePath escapes every segment of path, keeping the separators
+func escapePath(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
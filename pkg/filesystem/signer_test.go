@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedQuery signs a request and returns the path and query a client would send back
+func signedQuery(t *testing.T, signer *URLSigner, method, path string, ttl time.Duration, params url.Values) (string, url.Values) {
+	t.Helper()
+
+	signed, _ := signer.Sign(method, path, ttl, params)
+	parsed, err := url.Parse(signed)
+	require.NoError(t, err)
+
+	unescaped, err := url.PathUnescape(strings.TrimPrefix(parsed.EscapedPath(), "/storage/signed/"))
+	require.NoError(t, err)
+
+	return unescaped, parsed.Query()
+}
+
+func TestURLSigner_Sign(t *testing.T) {
+	signer := NewURLSigner("secret", "http://localhost/storage/signed/")
+
+	signed, expiresAt := signer.Sign(http.MethodGet, "files/report 1.pdf", time.Minute, nil)
+
+	assert.True(t, strings.HasPrefix(signed, "http://localhost/storage/signed/files/report%201.pdf?"))
+	assert.Contains(t, signed, SignedParamSignature+"=")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, 2*time.Second)
+}
+
+func TestURLSigner_Verify(t *testing.T) {
+	signer := NewURLSigner("secret", "http://localhost/storage/signed")
+	params := url.Values{SignedParamSize: {"11"}, SignedParamChecksum: {"abc"}}
+
+	t.Run("should accept an untouched request", func(t *testing.T) {
+		path, query := signedQuery(t, signer, http.MethodPut, "files/a b.txt", time.Minute, params)
+
+		assert.NoError(t, signer.Verify(http.MethodPut, path, query))
+	})
+
+	t.Run("should reject another method or path", func(t *testing.T) {
+		path, query := signedQuery(t, signer, http.MethodGet, "files/a.txt", time.Minute, nil)
+
+		assert.ErrorIs(t, signer.Verify(http.MethodPut, path, query), ErrInvalidSignature)
+		assert.ErrorIs(t, signer.Verify(http.MethodGet, "files/b.txt", query), ErrInvalidSignature)
+	})
+
+	t.Run("should reject changed parameters", func(t *testing.T) {
+		path, query := signedQuery(t, signer, http.MethodPut, "files/a.txt", time.Minute, params)
+		query.Set(SignedParamSize, "12")
+
+		assert.ErrorIs(t, signer.Verify(http.MethodPut, path, query), ErrInvalidSignature)
+	})
+
+	t.Run("should reject an extended expiry", func(t *testing.T) {
+		path, query := signedQuery(t, signer, http.MethodGet, "files/a.txt", time.Minute, nil)
+		query.Set(SignedParamExpires, "9999999999")
+
+		assert.ErrorIs(t, signer.Verify(http.MethodGet, path, query), ErrInvalidSignature)
+	})
+
+	t.Run("should reject an expired request", func(t *testing.T) {
+		path, query := signedQuery(t, signer, http.MethodGet, "files/a.txt", -time.Minute, nil)
+
+		assert.ErrorIs(t, signer.Verify(http.MethodGet, path, query), ErrInvalidSignature)
+	})
+
+	t.Run("should reject a signature of another key", func(t *testing.T) {
+		path, query := signedQuery(t, NewURLSigner("other", "http://localhost/storage/signed"), http.MethodGet, "files/a.txt", time.Minute, nil)
+
+		assert.ErrorIs(t, signer.Verify(http.MethodGet, path, query), ErrInvalidSignature)
+	})
+
+	t.Run("should reject a missing or malformed signature", func(t *testing.T) {
+		path, query := signedQuery(t, signer, http.MethodGet, "files/a.txt", time.Minute, nil)
+		query.Set(SignedParamSignature, "not-hex")
+		assert.ErrorIs(t, signer.Verify(http.MethodGet, path, query), ErrInvalidSignature)
+
+		query.Del(SignedParamSignature)
+		assert.ErrorIs(t, signer.Verify(http.MethodGet, path, query), ErrInvalidSignature)
+	})
+}
@@ -0,0 +1,162 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload, except for the last one
+const MinPartSize = 5 * 1024 * 1024
+
+// StagedUpload is the state of a resumable upload, persisted by the caller between chunks
+type StagedUpload struct {
+	ID       string       `json:"id"`   // staging key, unique per upload
+	Path     string       `json:"path"` // destination on the driver
+	Size     int64        `json:"size"`
+	Offset   int64        `json:"offset"`             // bytes received so far
+	MimeType string       `json:"mimeType,omitempty"` // type of the content, detected from Path when empty
+	UploadID string       `json:"uploadId,omitempty"` // native multipart upload id
+	Parts    []StagedPart `json:"parts,omitempty"`
+}
+
+// StagedPart is a part of a native multipart upload
+type StagedPart struct {
+	Number int32  `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// Complete reports whether every byte of the upload was received
+func (u *StagedUpload) Complete() bool {
+	return u.Offset == u.Size
+}
+
+// contentType returns the type the staged file is stored with
+func (u *StagedUpload) contentType() string {
+	if u.MimeType != "" {
+		return u.MimeType
+	}
+	return DetectMimeType(u.Path)
+}
+
+// partsSize returns the bytes already sent as native parts
+func (u *StagedUpload) partsSize() int64 {
+	var size int64
+	for _, part := range u.Parts {
+		size += part.Size
+	}
+	return size
+}
+
+// Stager stages the chunks of resumable uploads until they are finalized into the driver.
+// Append advances Offset only on success, so a failed chunk is simply sent again:
+// the bytes it left behind are discarded by the next Append.
+type Stager interface {
+	// Begin starts staging upload
+	Begin(ctx context.Context, upload *StagedUpload) error
+	// Append writes chunk at upload.Offset, the chunk is cut at upload.Size
+	Append(ctx context.Context, upload *StagedUpload, chunk io.Reader) (int64, error)
+	// Finalize stores the complete upload on the driver
+	Finalize(ctx context.Context, upload *StagedUpload) (*UploadResult, error)
+	// Abort discards the staged chunks
+	Abort(ctx context.Context, upload *StagedUpload) error
+}
+
+// localStager stages chunks in a local file and uploads it to the driver once complete
+type localStager struct {
+	dir     string
+	storage Uploader
+}
+
+func newLocalStager(dir string, storage Uploader) *localStager {
+	return &localStager{dir: dir, storage: storage}
+}
+
+func (s *localStager) Begin(ctx context.Context, upload *StagedUpload) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.stagingPath(upload), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create staging file: %w", err)
+	}
+	return file.Close()
+}
+
+func (s *localStager) Append(ctx context.Context, upload *StagedUpload, chunk io.Reader) (int64, error) {
+	written, err := writeAt(s.stagingPath(upload), upload.Offset, contextReader{ctx: ctx, reader: io.LimitReader(chunk, upload.Size-upload.Offset)})
+	if err != nil {
+		return 0, err
+	}
+
+	upload.Offset += written
+	return written, nil
+}
+
+func (s *localStager) Finalize(ctx context.Context, upload *StagedUpload) (*UploadResult, error) {
+	if !upload.Complete() {
+		return nil, fmt.Errorf("upload %s is incomplete: %d of %d bytes", upload.ID, upload.Offset, upload.Size)
+	}
+
+	file, err := os.Open(s.stagingPath(upload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	result, err := s.storage.UploadFromReader(ctx, file, filepath.Base(upload.Path), UploadOptions{
+		Path: filepath.Dir(upload.Path),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_ = os.Remove(s.stagingPath(upload))
+
+	return result, nil
+}
+
+func (s *localStager) Abort(ctx context.Context, upload *StagedUpload) error {
+	if err := os.Remove(s.stagingPath(upload)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove staging file: %w", err)
+	}
+	return nil
+}
+
+func (s *localStager) stagingPath(upload *StagedUpload) string {
+	return filepath.Join(s.dir, filepath.Base(upload.ID))
+}
+
+// writeAt writes reader into path starting at offset, dropping whatever followed offset
+func writeAt(path string, offset int64, reader io.Reader) (int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open staging file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat staging file: %w", err)
+	}
+	if info.Size() < offset {
+		return 0, fmt.Errorf("staging file holds %d bytes, %d expected", info.Size(), offset)
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return 0, fmt.Errorf("failed to truncate staging file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek staging file: %w", err)
+	}
+
+	written, err := io.Copy(file, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write staging file: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,79 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader returns its content, then fails
+type failingReader struct {
+	content io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestLocalStager(t *testing.T) {
+	ctx := context.Background()
+	storageDir := t.TempDir()
+	stager := NewManager(NewLocalStorage(storageDir, "")).Stager(t.TempDir())
+
+	upload := &StagedUpload{ID: "upload-1", Path: "files/hello.txt", Size: 11}
+	require.NoError(t, stager.Begin(ctx, upload))
+
+	t.Run("should advance the offset per chunk", func(t *testing.T) {
+		written, err := stager.Append(ctx, upload, strings.NewReader("hello"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), written)
+		assert.Equal(t, int64(5), upload.Offset)
+	})
+
+	t.Run("should keep the offset of a failed chunk", func(t *testing.T) {
+		_, err := stager.Append(ctx, upload, &failingReader{content: strings.NewReader(" wor")})
+
+		require.Error(t, err)
+		assert.Equal(t, int64(5), upload.Offset)
+	})
+
+	t.Run("should cut the chunk at the upload size", func(t *testing.T) {
+		written, err := stager.Append(ctx, upload, strings.NewReader(" world and more"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), written)
+		assert.True(t, upload.Complete())
+	})
+
+	t.Run("should store the assembled file", func(t *testing.T) {
+		result, err := stager.Finalize(ctx, upload)
+		require.NoError(t, err)
+		assert.Equal(t, "files/hello.txt", result.Path)
+
+		content, err := os.ReadFile(filepath.Join(storageDir, result.Path))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("should not finalize an incomplete upload", func(t *testing.T) {
+		incomplete := &StagedUpload{ID: "upload-2", Path: "files/other.txt", Size: 4}
+		require.NoError(t, stager.Begin(ctx, incomplete))
+
+		_, err := stager.Finalize(ctx, incomplete)
+
+		assert.Error(t, err)
+		assert.NoError(t, stager.Abort(ctx, incomplete))
+	})
+}
@@ -1,47 +1,119 @@
 package filesystem
 
 import (
+	"context"
+	"errors"
 	"io"
 	"mime/multipart"
+	"net/url"
+	"time"
 )
 
 // Storage is the main interface for file storage operations
 type Storage interface {
 	Uploader
+	Reader
 	Deleter
 	Checker
+	Lister
+	Mover
 	URLProvider
+	Signer
 }
 
 // Uploader handles file upload operations
 type Uploader interface {
-	Upload(file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error)
-	UploadFromReader(reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error)
+	Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (*UploadResult, error)
+	UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (*UploadResult, error)
+}
+
+// Reader reads stored files back, missing files fail with ErrFileNotFound
+type Reader interface {
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	Stat(ctx context.Context, path string) (*FileInfo, error)
+	Size(ctx context.Context, path string) (int64, error)
+}
+
+// Lister lists stored files
+type Lister interface {
+	// List returns the files whose path starts with prefix, including subfolders,
+	// sorted by path. Drive matches and sorts by name instead.
+	List(ctx context.Context, prefix string) ([]FileInfo, error)
+}
+
+// Mover copies and moves stored files, dst is overwritten when it exists
+type Mover interface {
+	Copy(ctx context.Context, src, dst string) (*FileInfo, error)
+	Move(ctx context.Context, src, dst string) (*FileInfo, error)
 }
 
 // Deleter handles file deletion
 type Deleter interface {
-	Delete(path string) error
+	Delete(ctx context.Context, path string) error
 }
 
 // Checker checks file existence
 type Checker interface {
-	Exists(path string) (bool, error)
+	Exists(ctx context.Context, path string) (bool, error)
 }
 
 // URLProvider provides file URLs
 type URLProvider interface {
-	URL(path string) (string, error)
+	URL(ctx context.Context, path string) (string, error)
 	GetDriver() Driver
 }
 
+// Signer issues expiring URLs, so private files are reachable without making them public
+type Signer interface {
+	// PresignUpload returns a request the client sends to upload the content of path directly
+	PresignUpload(ctx context.Context, path string, opts PresignOptions) (*PresignedUpload, error)
+	// SignedURL returns a download URL of path valid for ttl
+	SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error)
+}
+
+// SignedRequestVerifier is implemented by drivers whose signed URLs are served by the
+// application itself instead of the storage service, see LocalStorage
+type SignedRequestVerifier interface {
+	VerifySignedRequest(method, path string, query url.Values) error
+}
+
+// Wrapper is implemented by storages decorating another storage
+type Wrapper interface {
+	Unwrap() Storage
+}
+
+// unwrapAs returns the first storage of the chain of wrappers starting at storage that is a T
+func unwrapAs[T any](storage Storage) (T, bool) {
+	for storage != nil {
+		if target, ok := storage.(T); ok {
+			return target, true
+		}
+		wrapper, ok := storage.(Wrapper)
+		if !ok {
+			break
+		}
+		storage = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
+var (
+	// ErrSigningNotSupported is returned by drivers that cannot sign URLs
+	ErrSigningNotSupported = errors.New("storage driver does not support signed URLs")
+	// ErrFileNotFound is returned when reading, copying or moving a file that does not exist
+	ErrFileNotFound = errors.New("file not found")
+)
+
 // Driver types
 type Driver string
 
 const (
-	DriverLocal Driver = "local"
-	DriverS3    Driver = "s3"
-	DriverDrive Driver = "drive"
+	DriverLocal  Driver = "local"
+	DriverS3     Driver = "s3"
+	DriverDrive  Driver = "drive"
+	DriverMemory Driver = "memory" // in-memory, for tests
 )
 
 // UploadOptions contains file upload configuration
@@ -51,6 +123,19 @@ type UploadOptions struct {
 	MaxSize          int64
 	AllowedMimeTypes []string
 	Public           bool
+	SkipVariants     bool   // store the content as is, without image variants
+	ContentType      string // sniffed by the manager, drivers fall back to the extension
+}
+
+// UploadPolicy restricts the files accepted by an upload route, zero values allow anything
+type UploadPolicy struct {
+	MaxSize          int64    `mapstructure:"max_size"`           // bytes
+	AllowedMimeTypes []string `mapstructure:"allowed_mime_types"` // exact types or wildcards like image/*
+}
+
+// Validate checks a file of size bytes and the given type against the policy
+func (p UploadPolicy) Validate(size int64, mimeType string) error {
+	return validateUpload(size, mimeType, UploadOptions{MaxSize: p.MaxSize, AllowedMimeTypes: p.AllowedMimeTypes})
 }
 
 // UploadResult contains upload result information
@@ -62,4 +147,34 @@ type UploadResult struct {
 	MimeType     string `json:"mimeType"`
 	URL          string `json:"url"` // Preview URL for embedding (iframe-friendly)
 	Driver       Driver `json:"driver"`
+
+	// Variants are generated by the image pipeline of the manager, stored next to the original
+	Variants []VariantResult `json:"variants,omitempty"`
+}
+
+// FileInfo describes a stored file. On Drive, Path is the file ID and Name the name in
+// the folder, the name is what List prefixes and the dst of Copy and Move refer to.
+type FileInfo struct {
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	MimeType string    `json:"mimeType"`
+	ModTime  time.Time `json:"modTime"`
+}
+
+// PresignOptions constrains a presigned upload, the storage rejects content that does not match
+type PresignOptions struct {
+	TTL         time.Duration
+	ContentType string
+	Size        int64  // exact content length
+	Checksum    string // hex encoded SHA-256 of the content
+}
+
+// PresignedUpload is a request the client sends as is to upload a file
+type PresignedUpload struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"` // headers the request must carry
+	Path      string            `json:"path"`
+	ExpiresAt time.Time         `json:"expiresAt"`
 }
@@ -0,0 +1,221 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter of the storage operations
+const instrumentationName = "goilerplate/pkg/filesystem"
+
+// Attributes of the storage spans and metrics
+const (
+	AttrDriver    = attribute.Key("filesystem.driver")
+	AttrOperation = attribute.Key("filesystem.operation")
+	AttrPath      = attribute.Key("filesystem.path")
+	AttrErrorType = attribute.Key("error.type")
+)
+
+// instrumentedStorage traces every operation of storage and records its duration
+type instrumentedStorage struct {
+	storage  Storage
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	size     metric.Int64Histogram
+}
+
+// Instrument wraps storage so every operation is traced and measured with the global
+// OpenTelemetry providers
+func Instrument(storage Storage) Storage {
+	return newInstrumentedStorage(storage, otel.GetTracerProvider(), otel.GetMeterProvider())
+}
+
+func newInstrumentedStorage(storage Storage, tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) *instrumentedStorage {
+	meter := meterProvider.Meter(instrumentationName)
+
+	// Instrument creation only fails on invalid names, the no-op instruments are returned then
+	duration, _ := meter.Float64Histogram("filesystem.operation.duration",
+		metric.WithDescription("Duration of storage operations"),
+		metric.WithUnit("s"),
+	)
+	size, _ := meter.Int64Histogram("filesystem.upload.size",
+		metric.WithDescription("Size of stored uploads"),
+		metric.WithUnit("By"),
+	)
+
+	return &instrumentedStorage{
+		storage:  storage,
+		tracer:   tracerProvider.Tracer(instrumentationName),
+		duration: duration,
+		size:     size,
+	}
+}
+
+// Unwrap returns the instrumented storage
+func (s *instrumentedStorage) Unwrap() Storage {
+	return s.storage
+}
+
+// start opens the span of operation on path
+func (s *instrumentedStorage) start(ctx context.Context, operation, path string) (context.Context, trace.Span, time.Time) {
+	ctx, span := s.tracer.Start(ctx, "filesystem."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttrDriver.String(string(s.storage.GetDriver())),
+			AttrOperation.String(operation),
+			AttrPath.String(path),
+		),
+	)
+	return ctx, span, time.Now()
+}
+
+// end records the outcome of operation and closes its span
+func (s *instrumentedStorage) end(ctx context.Context, span trace.Span, start time.Time, operation string, err error) {
+	attrs := []attribute.KeyValue{
+		AttrDriver.String(string(s.storage.GetDriver())),
+		AttrOperation.String(operation),
+	}
+	if err != nil {
+		attrs = append(attrs, AttrErrorType.String(errorType(err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	s.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	span.End()
+}
+
+// stored records the size of an upload
+func (s *instrumentedStorage) stored(ctx context.Context, span trace.Span, result *UploadResult) {
+	span.SetAttributes(attribute.Int64("filesystem.size", result.Size))
+	s.size.Record(ctx, result.Size, metric.WithAttributes(AttrDriver.String(string(s.storage.GetDriver()))))
+}
+
+func (s *instrumentedStorage) Upload(ctx context.Context, file *multipart.FileHeader, opts UploadOptions) (result *UploadResult, err error) {
+	ctx, span, start := s.start(ctx, "upload", opts.Path)
+	defer func() { s.end(ctx, span, start, "upload", err) }()
+
+	result, err = s.storage.Upload(ctx, file, opts)
+	if err == nil {
+		s.stored(ctx, span, result)
+	}
+	return result, err
+}
+
+func (s *instrumentedStorage) UploadFromReader(ctx context.Context, reader io.Reader, filename string, opts UploadOptions) (result *UploadResult, err error) {
+	ctx, span, start := s.start(ctx, "upload", opts.Path)
+	defer func() { s.end(ctx, span, start, "upload", err) }()
+
+	result, err = s.storage.UploadFromReader(ctx, reader, filename, opts)
+	if err == nil {
+		s.stored(ctx, span, result)
+	}
+	return result, err
+}
+
+func (s *instrumentedStorage) Delete(ctx context.Context, path string) (err error) {
+	ctx, span, start := s.start(ctx, "delete", path)
+	defer func() { s.end(ctx, span, start, "delete", err) }()
+
+	return s.storage.Delete(ctx, path)
+}
+
+func (s *instrumentedStorage) Exists(ctx context.Context, path string) (exists bool, err error) {
+	ctx, span, start := s.start(ctx, "exists", path)
+	defer func() { s.end(ctx, span, start, "exists", err) }()
+
+	return s.storage.Exists(ctx, path)
+}
+
+// Open is measured until the stream is opened, reading it is up to the caller
+func (s *instrumentedStorage) Open(ctx context.Context, path string) (reader io.ReadCloser, err error) {
+	ctx, span, start := s.start(ctx, "open", path)
+	defer func() { s.end(ctx, span, start, "open", err) }()
+
+	return s.storage.Open(ctx, path)
+}
+
+func (s *instrumentedStorage) Stat(ctx context.Context, path string) (info *FileInfo, err error) {
+	ctx, span, start := s.start(ctx, "stat", path)
+	defer func() { s.end(ctx, span, start, "stat", err) }()
+
+	return s.storage.Stat(ctx, path)
+}
+
+func (s *instrumentedStorage) Size(ctx context.Context, path string) (size int64, err error) {
+	ctx, span, start := s.start(ctx, "size", path)
+	defer func() { s.end(ctx, span, start, "size", err) }()
+
+	return s.storage.Size(ctx, path)
+}
+
+func (s *instrumentedStorage) List(ctx context.Context, prefix string) (files []FileInfo, err error) {
+	ctx, span, start := s.start(ctx, "list", prefix)
+	defer func() { s.end(ctx, span, start, "list", err) }()
+
+	return s.storage.List(ctx, prefix)
+}
+
+func (s *instrumentedStorage) Copy(ctx context.Context, src, dst string) (info *FileInfo, err error) {
+	ctx, span, start := s.start(ctx, "copy", src)
+	span.SetAttributes(attribute.String("filesystem.destination", dst))
+	defer func() { s.end(ctx, span, start, "copy", err) }()
+
+	return s.storage.Copy(ctx, src, dst)
+}
+
+func (s *instrumentedStorage) Move(ctx context.Context, src, dst string) (info *FileInfo, err error) {
+	ctx, span, start := s.start(ctx, "move", src)
+	span.SetAttributes(attribute.String("filesystem.destination", dst))
+	defer func() { s.end(ctx, span, start, "move", err) }()
+
+	return s.storage.Move(ctx, src, dst)
+}
+
+func (s *instrumentedStorage) URL(ctx context.Context, path string) (url string, err error) {
+	ctx, span, start := s.start(ctx, "url", path)
+	defer func() { s.end(ctx, span, start, "url", err) }()
+
+	return s.storage.URL(ctx, path)
+}
+
+func (s *instrumentedStorage) PresignUpload(ctx context.Context, path string, opts PresignOptions) (upload *PresignedUpload, err error) {
+	ctx, span, start := s.start(ctx, "presign_upload", path)
+	defer func() { s.end(ctx, span, start, "presign_upload", err) }()
+
+	return s.storage.PresignUpload(ctx, path, opts)
+}
+
+func (s *instrumentedStorage) SignedURL(ctx context.Context, path string, ttl time.Duration) (url string, err error) {
+	ctx, span, start := s.start(ctx, "signed_url", path)
+	defer func() { s.end(ctx, span, start, "signed_url", err) }()
+
+	return s.storage.SignedURL(ctx, path, ttl)
+}
+
+func (s *instrumentedStorage) GetDriver() Driver {
+	return s.storage.GetDriver()
+}
+
+// errorType classifies err for the error.type attribute
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrFileNotFound):
+		return "not_found"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,79 @@
+package filesystem
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrumentedStorage(t *testing.T) {
+	ctx := context.Background()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	storage := newInstrumentedStorage(
+		NewLocalStorage(t.TempDir(), ""),
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	)
+
+	result, err := storage.UploadFromReader(ctx, strings.NewReader("hello"), "hello.txt", UploadOptions{Path: "files"})
+	require.NoError(t, err)
+	_, err = storage.Stat(ctx, "files/missing.txt")
+	require.ErrorIs(t, err, ErrFileNotFound)
+
+	t.Run("should trace every operation", func(t *testing.T) {
+		ended := spans.Ended()
+		require.Len(t, ended, 2)
+
+		assert.Equal(t, "filesystem.upload", ended[0].Name())
+		assert.Equal(t, trace.SpanKindClient, ended[0].SpanKind())
+		assert.Contains(t, ended[0].Attributes(), AttrDriver.String("local"))
+		assert.Contains(t, ended[0].Attributes(), AttrPath.String("files"))
+		assert.Contains(t, ended[0].Attributes(), attribute.Int64("filesystem.size", result.Size))
+
+		assert.Equal(t, "filesystem.stat", ended[1].Name())
+		assert.Equal(t, codes.Error, ended[1].Status().Code)
+	})
+
+	t.Run("should measure durations by outcome", func(t *testing.T) {
+		var metrics metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(ctx, &metrics))
+		require.Len(t, metrics.ScopeMetrics, 1)
+
+		recorded := map[string]metricdata.Aggregation{}
+		for _, m := range metrics.ScopeMetrics[0].Metrics {
+			recorded[m.Name] = m.Data
+		}
+
+		duration := recorded["filesystem.operation.duration"].(metricdata.Histogram[float64])
+		require.Len(t, duration.DataPoints, 2)
+		var errorTypes []string
+		for _, point := range duration.DataPoints {
+			if errorType, ok := point.Attributes.Value(AttrErrorType); ok {
+				errorTypes = append(errorTypes, errorType.AsString())
+			}
+		}
+		assert.Equal(t, []string{"not_found"}, errorTypes)
+
+		size := recorded["filesystem.upload.size"].(metricdata.Histogram[int64])
+		require.Len(t, size.DataPoints, 1)
+		assert.Equal(t, int64(5), size.DataPoints[0].Sum)
+	})
+
+	t.Run("should keep the wrapped driver reachable", func(t *testing.T) {
+		_, ok := unwrapAs[SignedRequestVerifier](storage)
+		assert.True(t, ok)
+		_, ok = unwrapAs[*S3Storage](storage)
+		assert.False(t, ok)
+	})
+}
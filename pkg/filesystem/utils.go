@@ -1,34 +1,63 @@
 package filesystem
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"goilerplate/pkg/utils"
+	"io"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+// SniffLength is the number of leading bytes SniffMimeType looks at
+const SniffLength = 512
+
+// maxFilenameLength is the length in bytes SanitizeFilename cuts names to
+const maxFilenameLength = 255
+
+// oleSignature starts OLE compound files, the container of legacy Office documents
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const mimeTypeOLE = "application/x-ole-storage"
+
+// refinedMimeTypes are the specific types an extension may claim for a generic sniffed type
+var refinedMimeTypes = map[string][]string{
+	"application/zip": {
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	},
+	"text/plain": {"text/csv"},
+	mimeTypeOLE:  {"application/msword"},
+}
+
 // generateFilename creates a unique filename with format: YYYYMMDD_HHMMSS_randomhash.ext
 func generateFilename(original string) string {
-	ext := filepath.Ext(original)
+	ext := filepath.Ext(SanitizeFilename(original))
 	now := utils.Now()
 	dateTime := now.Format("20060102_150405")
 	randomHash := uuid.New().String()[:8]
 	return fmt.Sprintf("%s_%s%s", dateTime, randomHash, ext)
 }
 
-// detectMimeType detects MIME type from filename extension
-func detectMimeType(filename string) string {
+// DetectMimeType detects MIME type from filename extension
+func DetectMimeType(filename string) string {
 	types := map[string]string{
 		".jpg":  "image/jpeg",
 		".jpeg": "image/jpeg",
 		".png":  "image/png",
 		".gif":  "image/gif",
+		".webp": "image/webp",
 		".pdf":  "application/pdf",
 		".doc":  "application/msword",
 		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
 		".txt":  "text/plain",
 		".csv":  "text/csv",
 		".zip":  "application/zip",
@@ -39,25 +68,104 @@ func detectMimeType(filename string) string {
 	return "application/octet-stream"
 }
 
-// validateUpload validates file upload options
+// contentTypeOf returns the sniffed type of opts, or the type of the filename extension
+func contentTypeOf(filename string, opts UploadOptions) string {
+	if opts.ContentType != "" {
+		return opts.ContentType
+	}
+	return DetectMimeType(filename)
+}
+
+// SniffMimeType detects the MIME type from the magic bytes of head, the first SniffLength
+// bytes of the content. The extension of filename only refines a generic result, like a
+// .docx sniffed as a zip, it never turns unknown content into a trusted type.
+func SniffMimeType(head []byte, filename string) string {
+	sniffed := mimeTypeOLE
+	if !bytes.HasPrefix(head, oleSignature) {
+		sniffed, _, _ = strings.Cut(http.DetectContentType(head), ";")
+	}
+
+	if slices.Contains(refinedMimeTypes[sniffed], DetectMimeType(filename)) {
+		return DetectMimeType(filename)
+	}
+	if sniffed == mimeTypeOLE {
+		return "application/octet-stream"
+	}
+	return sniffed
+}
+
+// Sniff reads the head of r and returns it with the MIME type of its magic bytes,
+// readers that cannot seek continue with io.MultiReader(bytes.NewReader(head), r)
+func Sniff(r io.Reader, filename string) ([]byte, string, error) {
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return head[:n], SniffMimeType(head[:n], filename), nil
+}
+
+// MimeTypeAllowed reports whether mimeType matches allowed, exact types or wildcards
+// like image/*. An empty allowlist allows every type.
+func MimeTypeAllowed(allowed []string, mimeType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, mt := range allowed {
+		if mt == mimeType || (strings.HasSuffix(mt, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(mt, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeFilename reduces a client supplied name to a safe base name: no directories,
+// control or reserved characters, leading dots or trailing dots and spaces, at most
+// maxFilenameLength bytes with the extension kept. Names left empty become "file".
+func SanitizeFilename(name string) string {
+	// Clients on Windows send backslashes
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+	name = strings.TrimRight(name, ". ")
+
+	if len(name) > maxFilenameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := strings.ToValidUTF8(name[:maxFilenameLength-len(ext)], "")
+		name = strings.TrimRight(base, ". ") + ext
+	}
+
+	if name == "" {
+		return "file"
+	}
+	return name
+}
+
+// validateUpload validates file upload options, opts.ContentType takes precedence over mimeType
 func validateUpload(fileSize int64, mimeType string, opts UploadOptions) error {
+	if opts.ContentType != "" {
+		mimeType = opts.ContentType
+	}
+
 	// Validate size
 	if opts.MaxSize > 0 && fileSize > opts.MaxSize {
 		return utils.ClientErr(http.StatusBadRequest, fmt.Sprintf("Ukuran file melebihi batas maksimum %.0fmb", convertToMB(opts.MaxSize)-1))
 	}
 
 	// Validate MIME type
-	if len(opts.AllowedMimeTypes) > 0 {
-		allowed := false
-		for _, mt := range opts.AllowedMimeTypes {
-			if mt == mimeType {
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			return utils.ClientErr(http.StatusBadRequest, fmt.Sprintf("mime type %s is not allowed", mimeType))
-		}
+	if !MimeTypeAllowed(opts.AllowedMimeTypes, mimeType) {
+		return utils.ClientErr(http.StatusBadRequest, fmt.Sprintf("mime type %s is not allowed", mimeType))
 	}
 
 	return nil
@@ -67,3 +175,16 @@ func validateUpload(fileSize int64, mimeType string, opts UploadOptions) error {
 func convertToMB(bytes int64) float64 {
 	return float64(bytes) / (1024 * 1024)
 }
+
+// contextReader stops reading once ctx is done, so copies to disk honor cancellation
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
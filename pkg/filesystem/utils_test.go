@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipOf(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	_, err := writer.Create("word/document.xml")
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestSniffMimeType(t *testing.T) {
+	png := pngOf(t, 2, 2)
+	archive := zipOf(t)
+	ole := append(append([]byte{}, oleSignature...), make([]byte, 64)...)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		filename string
+		want     string
+	}{
+		{name: "image by its magic bytes", content: png, filename: "photo.png", want: "image/png"},
+		{name: "image whatever the extension", content: png, filename: "notes.txt", want: "image/png"},
+		{name: "text pretending to be an image", content: []byte("hello"), filename: "photo.png", want: "text/plain"},
+		{name: "text refined to csv", content: []byte("a,b\n1,2\n"), filename: "data.csv", want: "text/csv"},
+		{name: "zip refined to docx", content: archive, filename: "report.docx", want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{name: "zip pretending to be an image", content: archive, filename: "photo.png", want: "application/zip"},
+		{name: "ole refined to doc", content: ole, filename: "letter.doc", want: "application/msword"},
+		{name: "ole without its extension", content: ole, filename: "letter.bin", want: "application/octet-stream"},
+		{name: "unknown binary named as a document", content: []byte{0x4D, 0x5A, 0x90, 0x00, 0x03}, filename: "setup.doc", want: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should detect "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SniffMimeType(tt.content, tt.filename))
+		})
+	}
+}
+
+func TestMimeTypeAllowed(t *testing.T) {
+	assert.True(t, MimeTypeAllowed(nil, "application/zip"))
+	assert.True(t, MimeTypeAllowed([]string{"application/pdf", "image/*"}, "image/webp"))
+	assert.True(t, MimeTypeAllowed([]string{"application/pdf"}, "application/pdf"))
+	assert.False(t, MimeTypeAllowed([]string{"image/*"}, "imagex/png"))
+	assert.False(t, MimeTypeAllowed([]string{"application/pdf"}, "text/plain"))
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "keeps a plain name", in: "report 2026.pdf", want: "report 2026.pdf"},
+		{name: "drops directories", in: "../../etc/passwd", want: "passwd"},
+		{name: "drops windows directories", in: `C:\Users\me\photo.jpg`, want: "photo.jpg"},
+		{name: "replaces control and reserved characters", in: "a\x00b<c>?.txt", want: "a_b_c__.txt"},
+		{name: "replaces invalid utf-8", in: "caf\xe9.txt", want: "caf_.txt"},
+		{name: "keeps unicode", in: "résumé.pdf", want: "résumé.pdf"},
+		{name: "strips leading dots and trailing dots and spaces", in: " .htaccess. ", want: "htaccess"},
+		{name: "falls back for empty names", in: "../", want: "file"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeFilename(tt.in))
+		})
+	}
+
+	t.Run("should cut long names keeping the extension", func(t *testing.T) {
+		got := SanitizeFilename(strings.Repeat("é", 200) + ".pdf")
+
+		assert.LessOrEqual(t, len(got), maxFilenameLength)
+		assert.True(t, strings.HasSuffix(got, "é.pdf"))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"goilerplate/pkg/constants"
+	"goilerplate/pkg/dberror"
 	"goilerplate/pkg/logger"
 	"goilerplate/pkg/utils"
 
@@ -21,6 +22,14 @@ func HandleError(ctx context.Context, err error) error {
 		return status.Error(httpCodeToGRPC(clientError.Code), clientError.Message)
 	}
 
+	var dbError *dberror.Error
+	if errors.As(err, &dbError) {
+		if dbError.Kind == dberror.ErrRetryable {
+			logger.Warn(ctx, err.Error())
+		}
+		return status.Error(httpCodeToGRPC(dbError.Status()), dbError.Message())
+	}
+
 	logger.Error(ctx, err)
 	return status.Error(codes.Internal, constants.MsgInternalServerError)
 }
@@ -38,6 +47,8 @@ func httpCodeToGRPC(httpCode int) codes.Code {
 		return codes.NotFound
 	case http.StatusConflict:
 		return codes.AlreadyExists
+	case http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		return codes.FailedPrecondition
 	case http.StatusUnprocessableEntity:
 		return codes.InvalidArgument
 	case http.StatusTooManyRequests:
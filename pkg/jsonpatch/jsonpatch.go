@@ -0,0 +1,338 @@
+// Package jsonpatch applies RFC 7386 JSON Merge Patch and RFC 6902 JSON Patch documents
+// to a JSON document, so partial updates can be applied to the JSON view of an entity.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"goilerplate/pkg/utils"
+)
+
+// Content types of the supported patch formats
+const (
+	ContentTypeMergePatch = "application/merge-patch+json"
+	ContentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// MergePatch applies an RFC 7386 merge patch to doc: object members are merged
+// recursively, null removes a member and any other value replaces the target.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, patchValue interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, utils.ClientErr(400, "Invalid merge patch", err)
+	}
+
+	return json.Marshal(mergePatch(target, patchValue))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+// Operation is a single RFC 6902 operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies an RFC 6902 JSON Patch to doc. Operations are applied in order and the
+// patch fails as a whole: a malformed patch returns 400, an operation that cannot be
+// applied 422 and a failed test operation 409.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, utils.ClientErr(400, "Invalid JSON patch, expected an array of operations", err)
+	}
+
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	for i, op := range ops {
+		var err *utils.ClientError
+		if target, err = apply(target, op); err != nil {
+			return nil, utils.ClientErr(err.Code, fmt.Sprintf("JSON patch operation %d (%s %s): %s", i, op.Op, op.Path, err.Message))
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func apply(doc interface{}, op Operation) (interface{}, *utils.ClientError) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, utils.ClientErr(400, "value is required")
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, utils.ClientErr(400, "invalid value")
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, utils.ClientErr(409, "test failed")
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			return add(doc, path, deepCopy(value))
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, utils.ClientErr(422, "cannot move a value into itself")
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, utils.ClientErr(400, "unknown operation")
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, *utils.ClientError) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, utils.ClientErr(400, "path must start with /")
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(doc interface{}, path []string) (interface{}, *utils.ClientError) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, utils.ClientErr(422, "path does not exist")
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, utils.ClientErr(422, "path does not exist")
+		}
+	}
+	return node, nil
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, *utils.ClientError) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent interface{}, token string) (interface{}, *utils.ClientError) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			if token == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, utils.ClientErr(422, "parent is not an object or array")
+		}
+	})
+}
+
+func remove(doc interface{}, path []string) (interface{}, *utils.ClientError) {
+	if len(path) == 0 {
+		return nil, utils.ClientErr(422, "cannot remove the document root")
+	}
+
+	return update(doc, path, func(parent interface{}, token string) (interface{}, *utils.ClientError) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[token]; !ok {
+				return nil, utils.ClientErr(422, "path does not exist")
+			}
+			delete(p, token)
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(p)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, utils.ClientErr(422, "path does not exist")
+		}
+	})
+}
+
+func replace(doc interface{}, path []string, value interface{}) (interface{}, *utils.ClientError) {
+	if _, err := get(doc, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent interface{}, token string) (interface{}, *utils.ClientError) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			i, _ := arrayIndex(token, len(p)-1)
+			p[i] = value
+			return p, nil
+		default:
+			return nil, utils.ClientErr(422, "path does not exist")
+		}
+	})
+}
+
+// update walks to the parent of the last path token and replaces it with the result of fn.
+// Arrays are values, so every level stores the updated child back into its parent.
+func update(node interface{}, path []string, fn func(parent interface{}, token string) (interface{}, *utils.ClientError)) (interface{}, *utils.ClientError) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, utils.ClientErr(422, "path does not exist")
+		}
+		updated, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = updated
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(n[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	default:
+		return nil, utils.ClientErr(422, "path does not exist")
+	}
+}
+
+// arrayIndex parses an array index token, it must be between 0 and max
+func arrayIndex(token string, max int) (int, *utils.ClientError) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, utils.ClientErr(422, "invalid array index")
+	}
+	i, convErr := strconv.Atoi(token)
+	if convErr != nil || i < 0 || i > max {
+		return 0, utils.ClientErr(422, "array index out of range")
+	}
+	return i, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[key] = deepCopy(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = deepCopy(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
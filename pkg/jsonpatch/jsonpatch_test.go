@@ -0,0 +1,88 @@
+package jsonpatch_test
+
+import (
+	"testing"
+
+	"goilerplate/pkg/jsonpatch"
+	"goilerplate/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Test cases from RFC 7386 appendix A
+	for _, tc := range []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		t.Run(tc.patch, func(t *testing.T) {
+			got, err := jsonpatch.MergePatch([]byte(tc.doc), []byte(tc.patch))
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(got))
+		})
+	}
+
+	t.Run("should reject invalid json", func(t *testing.T) {
+		_, err := jsonpatch.MergePatch([]byte(`{}`), []byte(`{`))
+		assert.Error(t, err)
+	})
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"code":"EXP1","tags":["a","b"],"meta":{"x/y":1}}`
+
+	for name, tc := range map[string]struct{ patch, want string }{
+		"add member":         {`[{"op":"add","path":"/bar","value":"x"}]`, `{"code":"EXP1","bar":"x","tags":["a","b"],"meta":{"x/y":1}}`},
+		"insert into array":  {`[{"op":"add","path":"/tags/1","value":"z"}]`, `{"code":"EXP1","tags":["a","z","b"],"meta":{"x/y":1}}`},
+		"append to array":    {`[{"op":"add","path":"/tags/-","value":"z"}]`, `{"code":"EXP1","tags":["a","b","z"],"meta":{"x/y":1}}`},
+		"remove":             {`[{"op":"remove","path":"/tags/0"}]`, `{"code":"EXP1","tags":["b"],"meta":{"x/y":1}}`},
+		"replace":            {`[{"op":"replace","path":"/code","value":"EXP2"}]`, `{"code":"EXP2","tags":["a","b"],"meta":{"x/y":1}}`},
+		"escaped pointer":    {`[{"op":"replace","path":"/meta/x~1y","value":2}]`, `{"code":"EXP1","tags":["a","b"],"meta":{"x/y":2}}`},
+		"move":               {`[{"op":"move","from":"/code","path":"/bar"}]`, `{"bar":"EXP1","tags":["a","b"],"meta":{"x/y":1}}`},
+		"copy":               {`[{"op":"copy","from":"/tags","path":"/copy"}]`, `{"code":"EXP1","tags":["a","b"],"copy":["a","b"],"meta":{"x/y":1}}`},
+		"passing test":       {`[{"op":"test","path":"/meta/x~1y","value":1},{"op":"remove","path":"/meta"}]`, `{"code":"EXP1","tags":["a","b"]}`},
+		"replace whole root": {`[{"op":"replace","path":"","value":{}}]`, `{}`},
+	} {
+		t.Run("should apply "+name, func(t *testing.T) {
+			got, err := jsonpatch.Apply([]byte(doc), []byte(tc.patch))
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(got))
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		patch string
+		code  int
+	}{
+		"not an array":       {`{"op":"add"}`, 400},
+		"unknown operation":  {`[{"op":"merge","path":"/code"}]`, 400},
+		"missing value":      {`[{"op":"add","path":"/code"}]`, 400},
+		"missing member":     {`[{"op":"remove","path":"/bar"}]`, 422},
+		"index out of range": {`[{"op":"replace","path":"/tags/5","value":"x"}]`, 422},
+		"move into itself":   {`[{"op":"move","from":"/meta","path":"/meta/child"}]`, 422},
+		"failed test":        {`[{"op":"test","path":"/code","value":"EXP2"}]`, 409},
+	} {
+		t.Run("should reject "+name, func(t *testing.T) {
+			_, err := jsonpatch.Apply([]byte(doc), []byte(tc.patch))
+
+			var clientErr *utils.ClientError
+			require.ErrorAs(t, err, &clientErr)
+			assert.Equal(t, tc.code, clientErr.Code)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package listquery
+
+import (
+	"goilerplate/pkg/search"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApplyFilters adds the WHERE conditions to db. Columns come from the allowlist and
+// values are always bound as parameters, so client input never reaches the SQL text.
+func (q *Query) ApplyFilters(db *gorm.DB) {
+	if q == nil {
+		return
+	}
+
+	for _, cond := range q.Conditions {
+		column := clause.Column{Table: clause.CurrentTable, Name: cond.Column}
+
+		switch cond.Op {
+		case OpEq:
+			db.Where(clause.Eq{Column: column, Value: cond.Value})
+		case OpNe:
+			db.Where(clause.Neq{Column: column, Value: cond.Value})
+		case OpGt:
+			db.Where(clause.Gt{Column: column, Value: cond.Value})
+		case OpGte:
+			db.Where(clause.Gte{Column: column, Value: cond.Value})
+		case OpLt:
+			db.Where(clause.Lt{Column: column, Value: cond.Value})
+		case OpLte:
+			db.Where(clause.Lte{Column: column, Value: cond.Value})
+		case OpIn:
+			db.Where(clause.IN{Column: column, Values: cond.Value.([]interface{})})
+		case OpLike:
+			db.Where(clause.Expr{SQL: "LOWER(?) LIKE ?", Vars: []interface{}{column, search.LikePattern(cond.Value.(string))}})
+		case OpNull:
+			if cond.Value.(bool) {
+				db.Where(clause.Eq{Column: column, Value: nil})
+			} else {
+				db.Where(clause.Neq{Column: column, Value: nil})
+			}
+		}
+	}
+}
+
+// ApplySort adds the ORDER BY entries to db
+func (q *Query) ApplySort(db *gorm.DB) {
+	if q == nil {
+		return
+	}
+
+	for _, s := range q.Sorts {
+		db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: s.Column}, Desc: s.Desc})
+	}
+}
+
+// Apply adds both the WHERE conditions and the ORDER BY entries to db
+func (q *Query) Apply(db *gorm.DB) {
+	q.ApplyFilters(db)
+	q.ApplySort(db)
+}
@@ -0,0 +1,199 @@
+package listquery
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"goilerplate/pkg/pagination"
+	"goilerplate/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// keysetIDColumn breaks ties between rows with equal sort keys, it must be unique
+const keysetIDColumn = "id"
+
+// ApplyKeyset adds the keyset condition of cursor and the matching ORDER BY to db.
+// A nil cursor selects the first page. Backward cursors invert the order, the caller
+// reverses the fetched rows. Sort columns are expected to be NOT NULL.
+func (q *Query) ApplyKeyset(db *gorm.DB, cursor *pagination.Cursor) error {
+	sorts := q.keysetSorts()
+	backward := cursor != nil && cursor.Backward
+
+	if cursor != nil {
+		if len(cursor.Values) != len(sorts)-1 {
+			return utils.ClientErr(400, "Cursor does not match the requested sort")
+		}
+
+		values := make([]interface{}, len(sorts))
+		for i, s := range sorts[:len(sorts)-1] {
+			v, err := convert(s.Type, cursor.Values[i])
+			if err != nil {
+				return utils.ClientErr(400, "Invalid cursor")
+			}
+			values[i] = v
+		}
+		values[len(sorts)-1] = cursor.ID
+
+		// (a > x) OR (a = x AND b > y) OR (a = x AND b = y AND id > z)
+		or := make([]clause.Expression, len(sorts))
+		for i, s := range sorts {
+			and := make([]clause.Expression, 0, i+1)
+			for j := 0; j < i; j++ {
+				and = append(and, clause.Eq{Column: keysetColumn(sorts[j]), Value: values[j]})
+			}
+			if s.Desc != backward {
+				and = append(and, clause.Lt{Column: keysetColumn(s), Value: values[i]})
+			} else {
+				and = append(and, clause.Gt{Column: keysetColumn(s), Value: values[i]})
+			}
+			or[i] = clause.And(and...)
+		}
+		db.Where(clause.Or(or...))
+	}
+
+	for _, s := range sorts {
+		db.Order(clause.OrderByColumn{Column: keysetColumn(s), Desc: s.Desc != backward})
+	}
+
+	return nil
+}
+
+// FindPage loads one keyset page of T (a GORM model) from db, which carries the filters
+// but no ORDER BY, OFFSET or LIMIT. Total counts are never computed in cursor mode.
+func FindPage[T any](db *gorm.DB, q *Query, page *pagination.PaginationRequest) ([]T, *pagination.CursorPage, error) {
+	if page == nil {
+		page = &pagination.PaginationRequest{Limit: pagination.DefaultPaginationConfig().DefaultLimit}
+	}
+
+	var cursor *pagination.Cursor
+	if page.Cursor != "" {
+		decoded, err := pagination.DecodeCursor(page.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		cursor = decoded
+	}
+
+	if err := q.ApplyKeyset(db, cursor); err != nil {
+		return nil, nil, err
+	}
+
+	var rows []T
+	if err := db.Limit(page.GetLimit() + 1).Find(&rows).Error; err != nil {
+		return nil, nil, utils.WrapErr(err)
+	}
+
+	hasMore := len(rows) > page.GetLimit()
+	if hasMore {
+		rows = rows[:page.GetLimit()]
+	}
+
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	result := &pagination.CursorPage{}
+	if len(rows) == 0 {
+		return rows, result, nil
+	}
+
+	// Going forward there is a previous page whenever a cursor was sent, going
+	// backward there is always a next page (the one the cursor came from)
+	var err error
+	if backward || hasMore {
+		if result.NextCursor, err = q.cursorOf(db, &rows[len(rows)-1], false); err != nil {
+			return nil, nil, err
+		}
+	}
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		if result.PrevCursor, err = q.cursorOf(db, &rows[0], true); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return rows, result, nil
+}
+
+// cursorOf encodes the sort key of row, a pointer to a GORM model
+func (q *Query) cursorOf(db *gorm.DB, row interface{}, backward bool) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(row); err != nil {
+		return "", utils.WrapErr(err)
+	}
+
+	rv := reflect.ValueOf(row).Elem()
+	valueOf := func(column string) (string, error) {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return "", utils.WrapErr(fmt.Errorf("column %s is not part of %s", column, stmt.Schema.Name))
+		}
+
+		v, _ := field.ValueOf(db.Statement.Context, rv)
+		switch val := v.(type) {
+		case time.Time:
+			return val.UTC().Format(time.RFC3339Nano), nil
+		case *time.Time:
+			if val == nil {
+				return "", utils.WrapErr(fmt.Errorf("column %s is NULL", column))
+			}
+			return val.UTC().Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(val), nil
+		}
+	}
+
+	sorts := q.keysetSorts()
+	cursor := pagination.Cursor{Values: make([]string, 0, len(sorts)-1), Backward: backward}
+	for _, s := range sorts[:len(sorts)-1] {
+		v, err := valueOf(s.Column)
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, v)
+	}
+
+	id, err := valueOf(keysetIDColumn)
+	if err != nil {
+		return "", err
+	}
+	cursor.ID = id
+
+	return pagination.EncodeCursor(cursor), nil
+}
+
+// KeysetColumns returns the columns a keyset page reads its cursor from,
+// they must be selected when the select list is narrowed
+func (q *Query) KeysetColumns() []string {
+	sorts := q.keysetSorts()
+	columns := make([]string, len(sorts))
+	for i, s := range sorts {
+		columns[i] = s.Column
+	}
+	return columns
+}
+
+// keysetSorts returns the requested sorts followed by the id tie breaker,
+// which follows the direction of the last sort
+func (q *Query) keysetSorts() []Sort {
+	var sorts []Sort
+	if q != nil {
+		for _, s := range q.Sorts {
+			if s.Column != keysetIDColumn {
+				sorts = append(sorts, s)
+			}
+		}
+	}
+
+	desc := len(sorts) > 0 && sorts[len(sorts)-1].Desc
+	return append(sorts, Sort{Field: keysetIDColumn, Column: keysetIDColumn, Desc: desc})
+}
+
+func keysetColumn(s Sort) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: s.Column}
+}
@@ -0,0 +1,278 @@
+// Package listquery parses sorting and filtering parameters of list endpoints
+// (sort=-code,created_at and filter[field][op]=value) against a per-resource
+// allowlist and applies them to GORM queries.
+package listquery
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goilerplate/pkg/utils"
+)
+
+// Operator is a filter comparison operator
+type Operator string
+
+const (
+	OpEq   Operator = "eq"
+	OpNe   Operator = "ne"
+	OpGt   Operator = "gt"
+	OpGte  Operator = "gte"
+	OpLt   Operator = "lt"
+	OpLte  Operator = "lte"
+	OpLike Operator = "like" // case-insensitive contains
+	OpIn   Operator = "in"   // comma separated values
+	OpNull Operator = "null" // true = IS NULL, false = IS NOT NULL
+)
+
+// FieldType controls how filter values are converted before they reach the database
+type FieldType int
+
+const (
+	TypeString FieldType = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeTime
+)
+
+// Default operator sets
+var (
+	StringOps = []Operator{OpEq, OpNe, OpLike, OpIn}
+	NumberOps = []Operator{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn}
+	TimeOps   = []Operator{OpEq, OpGt, OpGte, OpLt, OpLte, OpNull}
+	BoolOps   = []Operator{OpEq}
+)
+
+// Limits protecting the database from oversized queries
+const (
+	MaxFilters = 20
+	MaxSorts   = 5
+)
+
+// Field describes a field clients may sort or filter by
+type Field struct {
+	Column   string     // database column, never taken from client input
+	Type     FieldType  // value conversion
+	Ops      []Operator // allowed filter operators, empty = not filterable
+	Sortable bool
+}
+
+// Allowlist maps public field names to their definition for one resource
+type Allowlist struct {
+	Fields      map[string]Field
+	DefaultSort string // applied when the client sends no sort, e.g. "-created_at"
+}
+
+// Sort is a single ORDER BY entry
+type Sort struct {
+	Field  string
+	Column string
+	Type   FieldType
+	Desc   bool
+}
+
+// Condition is a single WHERE entry. Value holds the converted value,
+// or a slice for OpIn, or a bool for OpNull.
+type Condition struct {
+	Field  string
+	Column string
+	Op     Operator
+	Value  interface{}
+}
+
+// Query is the parsed sort and filter of a list request
+type Query struct {
+	Sorts       []Sort
+	Conditions  []Condition
+	DefaultSort bool // the client sent no sort, Sorts hold the allowlist default
+}
+
+// Parse parses "sort" and "filter[field][op]" parameters from url.Values.
+// Unknown fields, operators and malformed values are rejected with a 400 client error.
+func Parse(values url.Values, allowlist Allowlist) (*Query, error) {
+	q := &Query{}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = allowlist.DefaultSort
+		q.DefaultSort = true
+	}
+	if err := q.parseSort(sortParam, allowlist); err != nil {
+		return nil, err
+	}
+
+	// Iterate in key order so the generated SQL is deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, "filter[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, op, err := parseFilterKey(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range values[key] {
+			if err := q.addCondition(field, op, val, allowlist); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(q.Conditions) > MaxFilters {
+		return nil, utils.ClientErr(400, fmt.Sprintf("too many filters, maximum is %d", MaxFilters))
+	}
+
+	return q, nil
+}
+
+// ParseString parses a raw query string, e.g. "sort=-code&filter[code][like]=ab"
+func ParseString(raw string, allowlist Allowlist) (*Query, error) {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, utils.ClientErr(400, "invalid sort or filter parameters")
+	}
+	return Parse(values, allowlist)
+}
+
+// IsEmpty reports whether the query has neither sorts nor conditions
+func (q *Query) IsEmpty() bool {
+	return q == nil || (len(q.Sorts) == 0 && len(q.Conditions) == 0)
+}
+
+// SortedByDefault reports whether the client left the order up to the server, e.g. to
+// order keyword search results by relevance instead
+func (q *Query) SortedByDefault() bool {
+	return q == nil || q.DefaultSort
+}
+
+func (q *Query) parseSort(sortParam string, allowlist Allowlist) error {
+	if sortParam == "" {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(sortParam, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		desc := false
+		switch part[0] {
+		case '-':
+			desc, part = true, part[1:]
+		case '+':
+			part = part[1:]
+		}
+
+		field, ok := allowlist.Fields[part]
+		if !ok || !field.Sortable {
+			return utils.ClientErr(400, fmt.Sprintf("sorting by '%s' is not allowed", part))
+		}
+		if seen[part] {
+			continue
+		}
+		seen[part] = true
+
+		q.Sorts = append(q.Sorts, Sort{Field: part, Column: field.Column, Type: field.Type, Desc: desc})
+	}
+
+	if len(q.Sorts) > MaxSorts {
+		return utils.ClientErr(400, fmt.Sprintf("too many sort fields, maximum is %d", MaxSorts))
+	}
+	return nil
+}
+
+// parseFilterKey splits "filter[field]" or "filter[field][op]" into field and operator
+func parseFilterKey(key string) (string, Operator, error) {
+	invalid := utils.ClientErr(400, fmt.Sprintf("invalid filter parameter '%s'", key))
+
+	rest := strings.TrimPrefix(key, "filter[")
+	end := strings.Index(rest, "]")
+	if end <= 0 {
+		return "", "", invalid
+	}
+	field, rest := rest[:end], rest[end+1:]
+
+	if rest == "" {
+		return field, OpEq, nil
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", invalid
+	}
+	return field, Operator(rest[1 : len(rest)-1]), nil
+}
+
+func (q *Query) addCondition(name string, op Operator, raw string, allowlist Allowlist) error {
+	field, ok := allowlist.Fields[name]
+	if !ok || !field.allows(op) {
+		return utils.ClientErr(400, fmt.Sprintf("filter '%s' with operator '%s' is not allowed", name, op))
+	}
+
+	var (
+		value interface{}
+		err   error
+	)
+	switch op {
+	case OpNull:
+		value, err = strconv.ParseBool(raw)
+	case OpIn:
+		parts := strings.Split(raw, ",")
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			v, convErr := convert(field.Type, strings.TrimSpace(part))
+			if convErr != nil {
+				err = convErr
+				break
+			}
+			values = append(values, v)
+		}
+		value = values
+	case OpLike:
+		value = raw
+	default:
+		value, err = convert(field.Type, raw)
+	}
+	if err != nil {
+		return utils.ClientErr(400, fmt.Sprintf("invalid value '%s' for filter '%s'", raw, name))
+	}
+
+	q.Conditions = append(q.Conditions, Condition{Field: name, Column: field.Column, Op: op, Value: value})
+	return nil
+}
+
+func (f Field) allows(op Operator) bool {
+	for _, allowed := range f.Ops {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+func convert(typ FieldType, raw string) (interface{}, error) {
+	switch typ {
+	case TypeInt:
+		return strconv.ParseInt(raw, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(raw, 64)
+	case TypeBool:
+		return strconv.ParseBool(raw)
+	case TypeTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, raw)
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,213 @@
+package listquery_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"goilerplate/internal/infrastructure/model"
+	"goilerplate/pkg/listquery"
+	"goilerplate/pkg/pagination"
+	"goilerplate/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var allowlist = listquery.Allowlist{
+	Fields: map[string]listquery.Field{
+		"code":       {Column: "code", Ops: listquery.StringOps, Sortable: true},
+		"bar":        {Column: "bar", Ops: listquery.StringOps},
+		"created_at": {Column: "created_at", Type: listquery.TypeTime, Ops: listquery.TimeOps, Sortable: true},
+	},
+	DefaultSort: "-created_at",
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestParseString(t *testing.T) {
+	t.Run("should parse sort and filters", func(t *testing.T) {
+		q, err := listquery.ParseString("sort=-code,created_at&filter[code][in]=A,B&filter[bar]=x&filter[created_at][gte]=2025-01-01", allowlist)
+
+		require.NoError(t, err)
+		assert.Equal(t, []listquery.Sort{
+			{Field: "code", Column: "code", Desc: true},
+			{Field: "created_at", Column: "created_at", Type: listquery.TypeTime},
+		}, q.Sorts)
+		require.Len(t, q.Conditions, 3)
+		assert.Equal(t, listquery.Condition{Field: "bar", Column: "bar", Op: listquery.OpEq, Value: "x"}, q.Conditions[0])
+		assert.Equal(t, []interface{}{"A", "B"}, q.Conditions[1].Value)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), q.Conditions[2].Value)
+	})
+
+	t.Run("should apply the default sort", func(t *testing.T) {
+		q, err := listquery.ParseString("", allowlist)
+
+		require.NoError(t, err)
+		assert.Equal(t, []listquery.Sort{{Field: "created_at", Column: "created_at", Type: listquery.TypeTime, Desc: true}}, q.Sorts)
+		assert.True(t, q.SortedByDefault())
+	})
+
+	t.Run("should not report a client sort as default", func(t *testing.T) {
+		q, err := listquery.ParseString("sort=-created_at", allowlist)
+
+		require.NoError(t, err)
+		assert.False(t, q.SortedByDefault())
+	})
+
+	tests := map[string]string{
+		"unknown sort field":    "sort=password",
+		"unsortable field":      "sort=bar",
+		"unknown filter field":  "filter[password]=x",
+		"disallowed operator":   "filter[code][gt]=x",
+		"malformed filter key":  "filter[code",
+		"invalid time value":    "filter[created_at][gte]=yesterday",
+		"invalid null value":    "filter[created_at][null]=maybe",
+		"unknown operator":      "filter[code][regex]=.*",
+		"trailing filter parts": "filter[code][eq]x",
+	}
+	for name, raw := range tests {
+		t.Run("should reject "+name, func(t *testing.T) {
+			_, err := listquery.ParseString(raw, allowlist)
+
+			var clientErr *utils.ClientError
+			require.ErrorAs(t, err, &clientErr)
+			assert.Equal(t, 400, clientErr.Code)
+		})
+	}
+}
+
+func TestQuery_Apply(t *testing.T) {
+	db := newDryRunDB(t)
+
+	t.Run("should bind values and quote columns", func(t *testing.T) {
+		q, err := listquery.ParseString("sort=-code&filter[code][like]=50%25_off&filter[created_at][null]=false", allowlist)
+		require.NoError(t, err)
+
+		var bars []model.Bar
+		stmt := db.Model(&model.Bar{}).Scopes(func(tx *gorm.DB) *gorm.DB {
+			q.Apply(tx)
+			return tx
+		}).Find(&bars).Statement
+
+		sql := stmt.SQL.String()
+		assert.Contains(t, sql, `LOWER("bars"."code") LIKE $1`)
+		assert.Contains(t, sql, `"bars"."created_at" IS NOT NULL`)
+		assert.Contains(t, sql, `ORDER BY "bars"."code" DESC`)
+		assert.Equal(t, []interface{}{`%50\%\_off%`}, stmt.Vars)
+	})
+
+	t.Run("should be a no-op for a nil query", func(t *testing.T) {
+		var q *listquery.Query
+		var bars []model.Bar
+
+		sql := db.Scopes(func(tx *gorm.DB) *gorm.DB {
+			q.Apply(tx)
+			return tx
+		}).Find(&bars).Statement.SQL.String()
+
+		assert.NotContains(t, sql, "WHERE")
+		assert.NotContains(t, sql, "ORDER BY")
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("should read sort and filter from metadata", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"x-sort", "code",
+			"x-filter", "filter[code][eq]=ABC",
+		))
+
+		q, err := listquery.ParseMetadata(ctx, allowlist)
+
+		require.NoError(t, err)
+		assert.Equal(t, []listquery.Sort{{Field: "code", Column: "code"}}, q.Sorts)
+		assert.Equal(t, []listquery.Condition{{Field: "code", Column: "code", Op: listquery.OpEq, Value: "ABC"}}, q.Conditions)
+	})
+
+	t.Run("should fall back to the default sort without metadata", func(t *testing.T) {
+		q, err := listquery.ParseMetadata(context.Background(), allowlist)
+
+		require.NoError(t, err)
+		assert.Len(t, q.Sorts, 1)
+		assert.Empty(t, q.Conditions)
+	})
+}
+
+func TestQuery_ApplyKeyset(t *testing.T) {
+	db := newDryRunDB(t)
+	q, err := listquery.ParseString("sort=code,-created_at", allowlist)
+	require.NoError(t, err)
+
+	t.Run("should order by the sorts and the id tie breaker on the first page", func(t *testing.T) {
+		query := db.Model(&model.Bar{})
+
+		rows, page, err := listquery.FindPage[model.Bar](query, q, &pagination.PaginationRequest{Limit: 10, UseCursor: true})
+
+		require.NoError(t, err)
+		assert.Empty(t, rows)
+		assert.Equal(t, &pagination.CursorPage{}, page)
+
+		sql := query.Statement.SQL.String()
+		assert.NotContains(t, sql, "WHERE")
+		assert.Contains(t, sql, `ORDER BY "bars"."code","bars"."created_at" DESC,"bars"."id" DESC LIMIT $1`)
+		assert.Equal(t, []interface{}{11}, query.Statement.Vars)
+	})
+
+	t.Run("should expand the cursor into a keyset condition", func(t *testing.T) {
+		var bars []model.Bar
+		cursor := &pagination.Cursor{Values: []string{"EXP1", "2025-01-02T03:04:05Z"}, ID: "abc"}
+
+		stmt := db.Model(&model.Bar{}).Scopes(func(tx *gorm.DB) *gorm.DB {
+			require.NoError(t, q.ApplyKeyset(tx, cursor))
+			return tx
+		}).Find(&bars).Statement
+
+		sql := stmt.SQL.String()
+		assert.Contains(t, sql, `WHERE ("bars"."code" > $1 OR ("bars"."code" = $2 AND "bars"."created_at" < $3) OR ("bars"."code" = $4 AND "bars"."created_at" = $5 AND "bars"."id" < $6))`)
+		assert.Contains(t, sql, `ORDER BY "bars"."code","bars"."created_at" DESC,"bars"."id" DESC`)
+		assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), stmt.Vars[2])
+		assert.Equal(t, "abc", stmt.Vars[5])
+	})
+
+	t.Run("should invert the order for backward cursors", func(t *testing.T) {
+		var bars []model.Bar
+		cursor := &pagination.Cursor{Values: []string{"EXP1", "2025-01-02T03:04:05Z"}, ID: "abc", Backward: true}
+
+		sql := db.Model(&model.Bar{}).Scopes(func(tx *gorm.DB) *gorm.DB {
+			require.NoError(t, q.ApplyKeyset(tx, cursor))
+			return tx
+		}).Find(&bars).Statement.SQL.String()
+
+		assert.Contains(t, sql, `"bars"."code" < $1`)
+		assert.Contains(t, sql, `ORDER BY "bars"."code" DESC,"bars"."created_at","bars"."id"`)
+	})
+
+	t.Run("should list the columns the cursor is built from", func(t *testing.T) {
+		assert.Equal(t, []string{"code", "created_at", "id"}, q.KeysetColumns())
+	})
+
+	t.Run("should reject cursors of another sort", func(t *testing.T) {
+		cursor := &pagination.Cursor{Values: []string{"EXP1"}, ID: "abc"}
+
+		err := q.ApplyKeyset(db.Model(&model.Bar{}), cursor)
+
+		var clientErr *utils.ClientError
+		require.ErrorAs(t, err, &clientErr)
+		assert.Equal(t, 400, clientErr.Code)
+	})
+}
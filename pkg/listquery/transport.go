@@ -0,0 +1,43 @@
+package listquery
+
+import (
+	"context"
+	"net/url"
+
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/metadata"
+)
+
+// ParseHTTP parses sort and filter parameters from the request query string
+func ParseHTTP(ctx *fiber.Ctx, allowlist Allowlist) (*Query, error) {
+	return ParseString(string(ctx.Request().URI().QueryString()), allowlist)
+}
+
+// ParseMetadata parses sort and filter parameters from incoming gRPC metadata.
+// Metadata keys cannot contain brackets, so the values use the HTTP syntax:
+//
+//	x-sort:   -code,created_at
+//	x-filter: filter[code][like]=ab&filter[created_at][gte]=2025-01-01
+func ParseMetadata(ctx context.Context, allowlist Allowlist) (*Query, error) {
+	values := url.Values{}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, raw := range md.Get(constants.HeaderFilter) {
+			parsed, err := url.ParseQuery(raw)
+			if err != nil {
+				return nil, utils.ClientErr(400, "invalid sort or filter parameters")
+			}
+			for key, vals := range parsed {
+				values[key] = append(values[key], vals...)
+			}
+		}
+		if sorts := md.Get(constants.HeaderSort); len(sorts) > 0 {
+			values.Set("sort", sorts[0])
+		}
+	}
+
+	return Parse(values, allowlist)
+}
@@ -1,21 +1,26 @@
 package logger
 
 import (
-	"goilerplate/config"
 	"log/slog"
 	"os"
 	"strings"
 )
 
-func NewSlog(cfg *config.Config) *slog.Logger {
+// Config configures the logger of NewSlog
+type Config struct {
+	Level  string `mapstructure:"level"`
+	Source bool   `mapstructure:"source"`
+}
+
+func NewSlog(cfg *Config) *slog.Logger {
 	logLevel := slog.LevelInfo
 	logSource := false
 
-	if cfg != nil && cfg.Log != nil {
-		logLevel = getLogLevel(cfg.Log.Level)
+	if cfg != nil {
+		logLevel = getLogLevel(cfg.Level)
 	}
-	if cfg != nil && cfg.Log != nil {
-		logSource = cfg.Log.Source
+	if cfg != nil {
+		logSource = cfg.Source
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
@@ -0,0 +1,128 @@
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"goilerplate/pkg/constants"
+	"goilerplate/pkg/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Cursor is the decoded form of an opaque keyset pagination cursor.
+// Values holds the sort key of the boundary row (in sort order) and ID breaks ties.
+type Cursor struct {
+	Values   []string `json:"v"`
+	ID       string   `json:"id"`
+	Backward bool     `json:"b,omitempty"` // true for prev_cursor: fetch the rows before the boundary
+}
+
+// CursorPage carries the cursors of the neighbouring pages, empty when there is none
+type CursorPage struct {
+	NextCursor string
+	PrevCursor string
+}
+
+var errInvalidCursor = utils.ClientErr(400, "Invalid cursor")
+
+// EncodeCursor returns the opaque string representation of c
+func EncodeCursor(c Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, errInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// NewCursorPaginatedResponse builds a cursor mode response. Cursor mode never counts rows,
+// so total, page and totalPages are left out of the JSON output.
+func NewCursorPaginatedResponse[T any](items []T, limit int, page *CursorPage) *PaginatedResponse[T] {
+	if page == nil {
+		page = &CursorPage{}
+	}
+
+	return &PaginatedResponse[T]{
+		Items:      items,
+		Limit:      limit,
+		HasNext:    page.NextCursor != "",
+		HasPrev:    page.PrevCursor != "",
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		cursorMode: true,
+	}
+}
+
+// MarshalJSON omits the offset fields in cursor mode
+func (r PaginatedResponse[T]) MarshalJSON() ([]byte, error) {
+	if !r.cursorMode {
+		type offsetResponse PaginatedResponse[T]
+		return json.Marshal(offsetResponse(r))
+	}
+
+	return json.Marshal(struct {
+		Items      []T    `json:"items"`
+		Limit      int    `json:"limit"`
+		HasNext    bool   `json:"hasNext"`
+		HasPrev    bool   `json:"hasPrev"`
+		NextCursor string `json:"nextCursor,omitempty"`
+		PrevCursor string `json:"prevCursor,omitempty"`
+	}{
+		Items:      r.Items,
+		Limit:      r.Limit,
+		HasNext:    r.HasNext,
+		HasPrev:    r.HasPrev,
+		NextCursor: r.NextCursor,
+		PrevCursor: r.PrevCursor,
+	})
+}
+
+// CursorFromMetadata reads the cursor from incoming gRPC metadata. The protos have no
+// cursor field, so cursor mode is selected by the presence of the x-cursor key.
+func CursorFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get(constants.HeaderCursor)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// SetCursorHeader sends the cursors of the neighbouring pages as gRPC response headers
+func SetCursorHeader(ctx context.Context, page *CursorPage) error {
+	if page == nil {
+		return nil
+	}
+
+	md := metadata.MD{}
+	if page.NextCursor != "" {
+		md.Set(constants.HeaderNextCursor, page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		md.Set(constants.HeaderPrevCursor, page.PrevCursor)
+	}
+	if md.Len() == 0 {
+		return nil
+	}
+
+	return grpc.SetHeader(ctx, md)
+}
@@ -0,0 +1,49 @@
+package pagination_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"goilerplate/pkg/pagination"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("should round trip", func(t *testing.T) {
+		cursor := pagination.Cursor{Values: []string{"EXP1", "2025-01-02T03:04:05Z"}, ID: "abc", Backward: true}
+
+		decoded, err := pagination.DecodeCursor(pagination.EncodeCursor(cursor))
+
+		require.NoError(t, err)
+		assert.Equal(t, &cursor, decoded)
+	})
+
+	for name, raw := range map[string]string{
+		"invalid base64": "***",
+		"invalid json":   "bm90LWpzb24",
+		"missing id":     pagination.EncodeCursor(pagination.Cursor{Values: []string{"x"}}),
+	} {
+		t.Run("should reject "+name, func(t *testing.T) {
+			_, err := pagination.DecodeCursor(raw)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPaginatedResponse_MarshalJSON(t *testing.T) {
+	t.Run("should keep the offset fields in offset mode", func(t *testing.T) {
+		b, err := json.Marshal(pagination.NewPaginatedResponse([]int{1}, 11, 1, 10))
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"items":[1],"total":11,"page":1,"limit":10,"totalPages":2,"hasNext":true,"hasPrev":false}`, string(b))
+	})
+
+	t.Run("should drop total and page in cursor mode", func(t *testing.T) {
+		b, err := json.Marshal(pagination.NewCursorPaginatedResponse([]int{1}, 10, &pagination.CursorPage{NextCursor: "n"}))
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"items":[1],"limit":10,"hasNext":true,"hasPrev":false,"nextCursor":"n"}`, string(b))
+	})
+}
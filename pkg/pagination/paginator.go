@@ -22,16 +22,24 @@ func DefaultPaginationConfig() PaginationConfig {
 type PaginationRequest struct {
 	Page  int `json:"page" query:"page" form:"page"`
 	Limit int `json:"limit" query:"limit" form:"limit"`
+
+	// Cursor switches to keyset pagination when UseCursor is set. An empty cursor requests the first page.
+	Cursor    string `json:"cursor" query:"cursor" form:"cursor"`
+	UseCursor bool   `json:"-" query:"-" form:"-"`
 }
 
 type PaginatedResponse[T any] struct {
-	Items      []T   `json:"items"`
-	Total      int64 `json:"total"`
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	TotalPages int   `json:"totalPages"`
-	HasNext    bool  `json:"hasNext"`
-	HasPrev    bool  `json:"hasPrev"`
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalPages int    `json:"totalPages"`
+	HasNext    bool   `json:"hasNext"`
+	HasPrev    bool   `json:"hasPrev"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+
+	cursorMode bool
 }
 
 func NewPaginatedResponse[T any](items []T, total int64, page, limit int) *PaginatedResponse[T] {
@@ -137,6 +145,12 @@ func ParsePagination(ctx *fiber.Ctx, config ...PaginationConfig) *PaginationRequ
 		}
 	}
 
+	// Cursor mode is selected by the presence of the cursor parameter, "?cursor=" requests the first page
+	if ctx.Request().URI().QueryArgs().Has("cursor") {
+		req.UseCursor = true
+		req.Cursor = ctx.Query("cursor")
+	}
+
 	req.Validate(cfg)
 
 	return req
@@ -159,6 +173,11 @@ func (pr *PaginationRequest) GetLimit() int {
 	return pr.Limit
 }
 
+// IsCursor reports whether keyset pagination was requested
+func (pr *PaginationRequest) IsCursor() bool {
+	return pr != nil && pr.UseCursor
+}
+
 // extractParam extracts a parameter value from a query string format
 func extractParam(body, param string) string {
 	parts := strings.Split(body, "&")
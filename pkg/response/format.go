@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"goilerplate/pkg/constants"
+	"goilerplate/pkg/dberror"
 	"goilerplate/pkg/logger"
 	"goilerplate/pkg/utils"
 
@@ -85,6 +86,21 @@ func Created(ctx *fiber.Ctx, data interface{}, options ...ResponseOption) error
 	return ctx.Status(http.StatusCreated).JSON(response)
 }
 
+// MultiStatus sends a 207 response for batch requests where only some items succeeded
+func MultiStatus(ctx *fiber.Ctx, data interface{}, options ...ResponseOption) error {
+	response := &BaseResponse{
+		Success: true,
+		Message: constants.MsgOperationCompletedSuccessfully,
+		Data:    data,
+	}
+
+	for _, opt := range options {
+		opt(response)
+	}
+
+	return ctx.Status(http.StatusMultiStatus).JSON(response)
+}
+
 // NoContent sends a successful response with no content
 func NoContent(ctx *fiber.Ctx, options ...ResponseOption) error {
 	response := &BaseResponse{
@@ -253,6 +269,16 @@ func HandleError(ctx *fiber.Ctx, err error) error {
 		return CustomError(ctx, clientError.Code, clientError.Message, nil)
 	}
 
+	// Constraint violations not mapped to a domain error by the repository
+	var dbError *dberror.Error
+	if errors.As(err, &dbError) {
+		if dbError.Kind == dberror.ErrRetryable {
+			logger.Warn(ctx.UserContext(), err.Error())
+			ctx.Set(fiber.HeaderRetryAfter, "1")
+		}
+		return CustomError(ctx, dbError.Status(), dbError.Message(), nil)
+	}
+
 	logger.Error(ctx.UserContext(), err)
 	return InternalServerError(ctx, constants.MsgInternalServerError)
 }
@@ -0,0 +1,226 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldType describes how a field spec type maps onto every generated layer
+type fieldType struct {
+	GoType   string
+	SQLType  string
+	ZeroTest string // Go expression (with %s as the value) that reports a missing value
+	Stringy  bool
+}
+
+var fieldTypes = map[string]fieldType{
+	"string":  {GoType: "string", SQLType: "VARCHAR(255)", ZeroTest: `strings.TrimSpace(%s) == ""`, Stringy: true},
+	"text":    {GoType: "string", SQLType: "TEXT", ZeroTest: `strings.TrimSpace(%s) == ""`, Stringy: true},
+	"uuid":    {GoType: "string", SQLType: "UUID", ZeroTest: `strings.TrimSpace(%s) == ""`, Stringy: true},
+	"int":     {GoType: "int", SQLType: "INTEGER", ZeroTest: `%s == 0`},
+	"int64":   {GoType: "int64", SQLType: "BIGINT", ZeroTest: `%s == 0`},
+	"float64": {GoType: "float64", SQLType: "DOUBLE PRECISION", ZeroTest: `%s == 0`},
+	"bool":    {GoType: "bool", SQLType: "BOOLEAN"},
+	"time":    {GoType: "time.Time", SQLType: "TIMESTAMP", ZeroTest: `%s.IsZero()`},
+}
+
+// fieldTypeAliases maps alternative spellings to a canonical field type
+var fieldTypeAliases = map[string]string{
+	"float":     "float64",
+	"bigint":    "int64",
+	"boolean":   "bool",
+	"timestamp": "time",
+	"datetime":  "time",
+}
+
+// reservedColumns are managed by the generated model and cannot be declared in the spec
+var reservedColumns = map[string]bool{
+	"id": true, "is_active": true,
+	"created_at": true, "updated_at": true, "deleted_at": true,
+	"created_by": true, "updated_by": true, "deleted_by": true,
+}
+
+// Field is a single resource attribute parsed from the field spec
+type Field struct {
+	Name     string // PascalCase Go name, e.g. UnitPrice
+	Column   string // snake_case column / JSON name, e.g. unit_price
+	Type     string // canonical spec type, e.g. float64
+	Required bool
+	Unique   bool
+	Search   bool
+}
+
+// GoType returns the Go type used by the entity, model and DTOs
+func (f Field) GoType() string { return fieldTypes[f.Type].GoType }
+
+// SQLType returns the column type used by the migration
+func (f Field) SQLType() string { return fieldTypes[f.Type].SQLType }
+
+// IsTime reports whether the field needs the time package
+func (f Field) IsTime() bool { return f.Type == "time" }
+
+// IsString reports whether the field is stored as a Go string
+func (f Field) IsString() bool { return fieldTypes[f.Type].Stringy }
+
+// Var returns the lowerCamelCase name of the field
+func (f Field) Var() string { return lowerFirst(f.Name) }
+
+// MissingCheck returns a Go boolean expression that is true when value holds no data
+func (f Field) MissingCheck(value string) string {
+	return fmt.Sprintf(fieldTypes[f.Type].ZeroTest, value)
+}
+
+// SampleValue returns a Go literal holding valid data, used by generated tests
+func (f Field) SampleValue() string {
+	switch f.Type {
+	case "uuid":
+		return `"00000000-0000-0000-0000-000000000001"`
+	case "int", "int64":
+		return "1"
+	case "float64":
+		return "1.5"
+	case "bool":
+		return "true"
+	case "time":
+		return "time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)"
+	default:
+		return fmt.Sprintf("%q", "Sample "+strings.ReplaceAll(f.Column, "_", " "))
+	}
+}
+
+// ZeroValue returns the Go literal of an empty value, used by generated tests
+func (f Field) ZeroValue() string {
+	switch f.Type {
+	case "int", "int64", "float64":
+		return "0"
+	case "bool":
+		return "false"
+	case "time":
+		return "time.Time{}"
+	default:
+		return `""`
+	}
+}
+
+// FromProto converts a proto message field into the entity type.
+// Generated protos use int32 for int and google.protobuf.Timestamp for time.
+func (f Field) FromProto(value string) string {
+	switch f.Type {
+	case "int":
+		return "int(" + value + ")"
+	case "time":
+		return value + ".AsTime()"
+	default:
+		return value
+	}
+}
+
+// ToProto converts an entity value into the proto message field type
+func (f Field) ToProto(value string) string {
+	switch f.Type {
+	case "int":
+		return "int32(" + value + ")"
+	case "time":
+		return "timestamppb.New(" + value + ")"
+	default:
+		return value
+	}
+}
+
+// ListQueryField returns the listquery.Field literal used in the resource allowlist
+func (f Field) ListQueryField() string {
+	var typ, ops string
+	switch f.Type {
+	case "int", "int64":
+		typ, ops = "listquery.TypeInt", "listquery.NumberOps"
+	case "float64":
+		typ, ops = "listquery.TypeFloat", "listquery.NumberOps"
+	case "bool":
+		typ, ops = "listquery.TypeBool", "listquery.BoolOps"
+	case "time":
+		typ, ops = "listquery.TypeTime", "listquery.TimeOps"
+	default:
+		return fmt.Sprintf("{Column: %q, Ops: listquery.StringOps, Sortable: true}", f.Column)
+	}
+	return fmt.Sprintf("{Column: %q, Type: %s, Ops: %s, Sortable: true}", f.Column, typ, ops)
+}
+
+// ValidateTag returns the go-playground validator tag for the request DTO
+func (f Field) ValidateTag() string {
+	if f.Required {
+		return ` validate:"required"`
+	}
+	return ""
+}
+
+// ParseFields parses a comma separated field spec.
+//
+// Each field is written as name:type[:modifier...], e.g.
+//
+//	"code:string:required:unique:search,name:string:required:search,price:float64,released_at:time"
+//
+// Supported types are string, text, uuid, int, int64, float64, bool and time.
+// Supported modifiers are required, unique (string types) and search (string types).
+func ParseFields(spec string) ([]Field, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("field spec is empty")
+	}
+
+	var fields []Field
+	seen := make(map[string]bool)
+
+	for _, raw := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(raw), ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid field %q: expected name:type[:modifier...]", raw)
+		}
+
+		column := toSnake(parts[0])
+		if !isIdentifier(column) {
+			return nil, fmt.Errorf("invalid field name %q", parts[0])
+		}
+		if reservedColumns[column] {
+			return nil, fmt.Errorf("field %q is managed by the generator and cannot be declared", column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate field %q", column)
+		}
+		seen[column] = true
+
+		typ := strings.ToLower(strings.TrimSpace(parts[1]))
+		if alias, ok := fieldTypeAliases[typ]; ok {
+			typ = alias
+		}
+		if _, ok := fieldTypes[typ]; !ok {
+			return nil, fmt.Errorf("field %q has unsupported type %q", column, parts[1])
+		}
+
+		field := Field{Name: toPascal(column), Column: column, Type: typ}
+		for _, modifier := range parts[2:] {
+			switch strings.ToLower(strings.TrimSpace(modifier)) {
+			case "required":
+				if typ == "bool" {
+					return nil, fmt.Errorf("field %q: bool fields cannot be required", column)
+				}
+				field.Required = true
+			case "unique":
+				if !field.IsString() {
+					return nil, fmt.Errorf("field %q: only string fields can be unique", column)
+				}
+				field.Unique = true
+			case "search":
+				if !field.IsString() {
+					return nil, fmt.Errorf("field %q: only string fields can be searchable", column)
+				}
+				field.Search = true
+			default:
+				return nil, fmt.Errorf("field %q has unknown modifier %q", column, modifier)
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
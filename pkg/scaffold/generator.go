@@ -0,0 +1,153 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"goilerplate/pkg/utils"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("scaffold").Funcs(template.FuncMap{
+	"upperFirst": upperFirst,
+	"humanize":   func(s string) string { return strings.ReplaceAll(s, "_", " ") },
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// Generator writes the files of a resource and registers it in the application
+type Generator struct {
+	Root  string    // repository root, the directory that holds go.mod
+	Force bool      // overwrite generated files that already exist
+	Out   io.Writer // progress output, defaults to os.Stdout
+}
+
+// templateData is passed to every template
+type templateData struct {
+	*Resource
+	Idempotency bool
+	CreatedAt   string
+}
+
+// output is a single file rendered from a template
+type output struct {
+	template string
+	path     string
+}
+
+// Generate renders all layers of the resource and registers it in wire, routes and permissions.
+// Existing files are left untouched unless Force is set, and registrations are only added once,
+// so running the generator again for the same resource is safe.
+func (g *Generator) Generate(res *Resource, idempotency bool) error {
+	if g.Out == nil {
+		g.Out = os.Stdout
+	}
+	if _, err := os.Stat(filepath.Join(g.Root, "go.mod")); err != nil {
+		return fmt.Errorf("%s is not the repository root: %w", g.Root, err)
+	}
+
+	now := utils.Now()
+	data := &templateData{
+		Resource:    res,
+		Idempotency: idempotency,
+		CreatedAt:   now.UTC().Format(time.RFC3339),
+	}
+
+	for _, out := range g.outputs(res, now) {
+		if err := g.render(out, data); err != nil {
+			return err
+		}
+	}
+
+	for _, reg := range registrations(res) {
+		if err := g.register(reg, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) outputs(res *Resource, now time.Time) []output {
+	domainDir := filepath.Join("internal", "domain", res.Package)
+	file := res.File + ".go"
+
+	outputs := []output{
+		{"domain_entity.go.tmpl", filepath.Join(domainDir, "entity.go")},
+		{"domain_error.go.tmpl", filepath.Join(domainDir, "error.go")},
+		{"domain_filter.go.tmpl", filepath.Join(domainDir, "filter.go")},
+		{"domain_message.go.tmpl", filepath.Join(domainDir, "message.go")},
+		{"domain_repository.go.tmpl", filepath.Join(domainDir, "repository.go")},
+		{"domain_usecase.go.tmpl", filepath.Join(domainDir, "usecase.go")},
+		{"domain_entity_test.go.tmpl", filepath.Join(domainDir, "entity_test.go")},
+		{"domain_usecase_test.go.tmpl", filepath.Join(domainDir, "usecase_test.go")},
+		{"model.go.tmpl", filepath.Join("internal", "infrastructure", "model", file)},
+		{"repository.go.tmpl", filepath.Join("internal", "infrastructure", "repository", file)},
+		{"dto_request.go.tmpl", filepath.Join("internal", "delivery", "http", "dto", "request", file)},
+		{"dto_response.go.tmpl", filepath.Join("internal", "delivery", "http", "dto", "response", file)},
+		{"request.go.tmpl", filepath.Join("internal", "delivery", "http", "request", file)},
+		{"presenter.go.tmpl", filepath.Join("internal", "delivery", "http", "presenter", file)},
+		{"handler.go.tmpl", filepath.Join("internal", "delivery", "http", "handler", file)},
+	}
+	if res.WithGrpc {
+		outputs = append(outputs, output{"grpc_handler.go.tmpl", filepath.Join("internal", "delivery", "grpc", "handler", file)})
+	}
+
+	// Reuse the migration of a previous run so the generator stays idempotent
+	migrationDir := filepath.Join("internal", "migrations")
+	migrationID := now.Format("20060102150405")
+	if existing, _ := filepath.Glob(filepath.Join(g.Root, migrationDir, "*_create_"+res.Table+"_table.up.sql")); len(existing) > 0 {
+		migrationID = strings.SplitN(filepath.Base(existing[0]), "_", 2)[0]
+	}
+	name := fmt.Sprintf("%s_create_%s_table", migrationID, res.Table)
+	outputs = append(outputs,
+		output{"migration.up.sql.tmpl", filepath.Join(migrationDir, name+".up.sql")},
+		output{"migration.down.sql.tmpl", filepath.Join(migrationDir, name+".down.sql")},
+	)
+
+	return outputs
+}
+
+func (g *Generator) render(out output, data *templateData) error {
+	path := filepath.Join(g.Root, out.path)
+	if _, err := os.Stat(path); err == nil && !g.Force {
+		g.logf("skip     %s (already exists)", out.path)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, out.template, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", out.path, err)
+	}
+
+	content := buf.Bytes()
+	if strings.HasSuffix(out.path, ".go") {
+		formatted, err := format.Source(content)
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", out.path, err)
+		}
+		content = formatted
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", out.path, err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out.path, err)
+	}
+
+	g.logf("create   %s", out.path)
+	return nil
+}
+
+func (g *Generator) logf(format string, args ...interface{}) {
+	fmt.Fprintf(g.Out, format+"\n", args...)
+}
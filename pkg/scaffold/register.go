@@ -0,0 +1,252 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// insertion adds a snippet right above a marker comment
+type insertion struct {
+	marker  string // marker line content, e.g. "// scaffold:repositories"
+	exists  string // the snippet is skipped when the file already contains this text
+	snippet string // template rendered with templateData
+}
+
+// registration describes every change made to one existing file
+type registration struct {
+	path       string
+	imports    []string
+	insertions []insertion
+	appendix   *insertion // appended at the end of the file, marker is unused
+}
+
+func registrations(res *Resource) []registration {
+	regs := []registration{
+		{
+			path: filepath.Join("pkg", "constants", "permission.go"),
+			insertions: []insertion{{
+				marker: "// Add more resource permissions here as needed",
+				exists: "Permission{{.Name}}List ",
+				snippet: `// {{.Words}} Resource Permissions
+const (
+	Permission{{.Name}}List   = "{{.Slug}}.list"
+	Permission{{.Name}}Get    = "{{.Slug}}.get"
+	Permission{{.Name}}Create = "{{.Slug}}.create"
+	Permission{{.Name}}Update = "{{.Slug}}.update"
+	Permission{{.Name}}Delete = "{{.Slug}}.delete"
+)
+
+`,
+			}},
+		},
+		{
+			path:    filepath.Join("internal", "wire", "repository.go"),
+			imports: []string{"goilerplate/internal/domain/{{.Package}}"},
+			insertions: []insertion{
+				{marker: "// scaffold:repositories", exists: "\t{{.Name}}Repo ", snippet: "\t{{.Name}}Repo {{.Package}}.Repository\n"},
+				{marker: "// scaffold:repository-constructors", exists: "repository.New{{.Name}}(", snippet: "\t\t{{.Name}}Repo: repository.New{{.Name}}(db{{if .SearchFields}}, app.Config.Search[\"{{.Table}}\"]{{end}}),\n"},
+			},
+		},
+		{
+			path:    filepath.Join("internal", "wire", "usecase.go"),
+			imports: []string{"goilerplate/internal/domain/{{.Package}}"},
+			insertions: []insertion{
+				{marker: "// scaffold:usecases", exists: "\t{{.Name}}UC ", snippet: "\t{{.Name}}UC {{.Package}}.Usecase\n"},
+				{marker: "// scaffold:usecase-constructors", exists: " {{.Package}}.NewUseCase(", snippet: "\t\t{{.Name}}UC: {{.Package}}.NewUseCase(repos.{{.Name}}Repo),\n"},
+			},
+		},
+		{
+			path: filepath.Join("internal", "wire", "handler.go"),
+			insertions: []insertion{
+				{marker: "// scaffold:handlers", exists: "*handler.{{.Name}}\n", snippet: "\t{{.Name}} *handler.{{.Name}}\n"},
+				{marker: "// scaffold:handler-constructors", exists: "handler.New{{.Name}}(", snippet: "\t\t{{.Name}}: handler.New{{.Name}}(app.Validator, useCases.{{.Name}}UC),\n"},
+			},
+		},
+	}
+
+	if res.WithGrpc {
+		regs = append(regs, registration{
+			path: filepath.Join("internal", "wire", "handler_grpc.go"),
+			insertions: []insertion{
+				{marker: "// scaffold:grpc-handlers", exists: "grpchandler.New{{.Name}}(", snippet: "\t{{.Var}} := grpchandler.New{{.Name}}(useCases.{{.Name}}UC)\n"},
+				{marker: "// scaffold:grpc-services", exists: "\t{{.Var}},\n", snippet: "\t\t{{.Var}},\n"},
+			},
+		})
+	}
+
+	for _, scope := range res.Scopes {
+		regs = append(regs, routeRegistration(scope))
+	}
+
+	return regs
+}
+
+func routeRegistration(scope string) registration {
+	registry := map[string]string{
+		ScopePublic:   "PublicRouteRegistry",
+		ScopePartner:  "PartnerRouteRegistry",
+		ScopeInternal: "InternalRouteRegistry",
+	}[scope]
+	group := map[string]string{
+		ScopePublic:   "v1",
+		ScopePartner:  "v1",
+		ScopeInternal: "internal",
+	}[scope]
+
+	var permission func(action string) string
+	imports := []string{}
+	if scope == ScopePublic {
+		imports = append(imports, "goilerplate/pkg/constants")
+		permission = func(action string) string {
+			return "\t\tr.Wired.Middleware.Auth.RequiredPermission(constants.Permission{{.Name}}" + action + "),\n"
+		}
+	} else {
+		permission = func(string) string { return "" }
+	}
+
+	idempotency := ""
+	if scope == ScopePublic {
+		imports = append(imports, "goilerplate/internal/delivery/http/middleware")
+		idempotency = "{{if .Idempotency}}\t\tmiddleware.RequireIdempotencyKey(), r.Wired.Middleware.Idempotency,\n{{end}}"
+	}
+
+	method := `
+func (r *` + registry + `) {{.Var}}(` + group + ` fiber.Router) {
+	{{.Var}} := ` + group + `.Group("{{.Path}}")
+	{{.Var}}.Post("",
+` + idempotency + permission("Create") + `		r.Wired.Handlers.{{.Name}}.Create)
+
+	{{.Var}}.Put("/:id",
+` + permission("Update") + `		r.Wired.Handlers.{{.Name}}.Update)
+
+	{{.Var}}.Delete("/:id",
+` + permission("Delete") + `		r.Wired.Handlers.{{.Name}}.Delete)
+
+	{{.Var}}.Get("",
+` + permission("List") + `		r.Wired.Handlers.{{.Name}}.List)
+
+	{{.Var}}.Get("/:id",
+` + permission("Get") + `		r.Wired.Handlers.{{.Name}}.Get)
+}
+`
+
+	return registration{
+		path:    filepath.Join("internal", "delivery", "http", "router", scope+".go"),
+		imports: imports,
+		insertions: []insertion{{
+			marker:  "// scaffold:" + scope + "-routes",
+			exists:  "r.{{.Var}}(" + group + ")",
+			snippet: "\tr.{{.Var}}(" + group + ")\n",
+		}},
+		appendix: &insertion{
+			exists:  "func (r *" + registry + ") {{.Var}}(",
+			snippet: method,
+		},
+	}
+}
+
+// register applies a registration to an existing file. Snippets already present are skipped.
+func (g *Generator) register(reg registration, data *templateData) error {
+	path := filepath.Join(g.Root, reg.path)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", reg.path, err)
+	}
+	content := string(original)
+
+	for _, ins := range reg.insertions {
+		exists, snippet, err := renderInsertion(ins, data)
+		if err != nil {
+			return fmt.Errorf("failed to render registration for %s: %w", reg.path, err)
+		}
+		if strings.Contains(content, exists) {
+			continue
+		}
+
+		idx := strings.Index(content, ins.marker)
+		if idx < 0 {
+			return fmt.Errorf("marker %q not found in %s, register the resource manually", ins.marker, reg.path)
+		}
+		lineStart := strings.LastIndex(content[:idx], "\n") + 1
+		content = content[:lineStart] + snippet + content[lineStart:]
+	}
+
+	if reg.appendix != nil {
+		exists, snippet, err := renderInsertion(*reg.appendix, data)
+		if err != nil {
+			return fmt.Errorf("failed to render registration for %s: %w", reg.path, err)
+		}
+		if !strings.Contains(content, exists) {
+			content = strings.TrimRight(content, "\n") + "\n" + snippet
+		}
+	}
+
+	if content == string(original) {
+		g.logf("skip     %s (already registered)", reg.path)
+		return nil
+	}
+
+	for _, imp := range reg.imports {
+		rendered, err := renderString(imp, data)
+		if err != nil {
+			return err
+		}
+		content = ensureImport(content, rendered)
+	}
+
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", reg.path, err)
+	}
+	if err := os.WriteFile(path, formatted, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reg.path, err)
+	}
+
+	g.logf("update   %s", reg.path)
+	return nil
+}
+
+func renderInsertion(ins insertion, data *templateData) (string, string, error) {
+	exists, err := renderString(ins.exists, data)
+	if err != nil {
+		return "", "", err
+	}
+	snippet, err := renderString(ins.snippet, data)
+	if err != nil {
+		return "", "", err
+	}
+	return exists, snippet, nil
+}
+
+func renderString(text string, data *templateData) (string, error) {
+	tmpl, err := template.New("snippet").Funcs(template.FuncMap{"upperFirst": upperFirst}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ensureImport adds path to the first import block; go/format sorts it afterwards
+func ensureImport(content, path string) string {
+	quoted := `"` + path + `"`
+	if strings.Contains(content, quoted) {
+		return content
+	}
+	if idx := strings.Index(content, "import (\n"); idx >= 0 {
+		at := idx + len("import (\n")
+		return content[:at] + "\t" + quoted + "\n" + content[at:]
+	}
+	if idx := strings.Index(content, "\n\n"); idx >= 0 {
+		return content[:idx] + "\n\nimport " + quoted + content[idx:]
+	}
+	return content
+}
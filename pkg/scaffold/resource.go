@@ -0,0 +1,244 @@
+package scaffold
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Route scopes a resource can be registered in
+const (
+	ScopePublic   = "public"
+	ScopePartner  = "partner"
+	ScopeInternal = "internal"
+)
+
+// Resource holds every name derived from the resource name plus its fields
+type Resource struct {
+	Name     string // PascalCase, e.g. PurchaseOrder
+	Var      string // lowerCamelCase, e.g. purchaseOrder
+	Package  string // domain package, e.g. purchaseorder
+	File     string // snake_case file name without extension, e.g. purchase_order
+	Table    string // plural snake_case table name, e.g. purchase_orders
+	Path     string // plural kebab-case route segment, e.g. purchase-orders
+	Human    string // lower case words, e.g. purchase order
+	Slug     string // permission prefix, e.g. purchase_order
+	Fields   []Field
+	Scopes   []string
+	WithGrpc bool
+}
+
+// NewResource derives all names for the resource and validates the scopes
+func NewResource(name string, fields []Field, scopes []string, withGrpc bool) (*Resource, error) {
+	snake := toSnake(name)
+	if !isIdentifier(snake) {
+		return nil, fmt.Errorf("invalid resource name %q", name)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("resource %q has no fields", name)
+	}
+	if len(scopes) == 0 {
+		scopes = []string{ScopePublic}
+	}
+	for _, scope := range scopes {
+		switch scope {
+		case ScopePublic, ScopePartner, ScopeInternal:
+		default:
+			return nil, fmt.Errorf("unknown route scope %q", scope)
+		}
+	}
+
+	plural := pluralize(snake)
+	pascal := toPascal(snake)
+
+	return &Resource{
+		Name:     pascal,
+		Var:      lowerFirst(pascal),
+		Package:  strings.ReplaceAll(snake, "_", ""),
+		File:     snake,
+		Table:    plural,
+		Path:     strings.ReplaceAll(plural, "_", "-"),
+		Human:    strings.ReplaceAll(snake, "_", " "),
+		Slug:     snake,
+		Fields:   fields,
+		Scopes:   scopes,
+		WithGrpc: withGrpc,
+	}, nil
+}
+
+// PluralName returns the PascalCase plural, e.g. PurchaseOrders
+func (r *Resource) PluralName() string { return toPascal(r.Table) }
+
+// PluralHuman returns the lower case plural words, e.g. purchase orders
+func (r *Resource) PluralHuman() string { return strings.ReplaceAll(r.Table, "_", " ") }
+
+// Title returns the capitalized human name, e.g. Purchase order
+func (r *Resource) Title() string { return upperFirst(r.Human) }
+
+// Words returns the capitalized words of the name, e.g. Purchase Order
+func (r *Resource) Words() string {
+	words := strings.Fields(r.Human)
+	for i, w := range words {
+		words[i] = upperFirst(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// HasTime reports whether any field needs the time package
+func (r *Resource) HasTime() bool {
+	for _, f := range r.Fields {
+		if f.IsTime() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasStringCheck reports whether the entity validation needs the strings package
+func (r *Resource) HasStringCheck() bool {
+	for _, f := range r.Fields {
+		if f.Required && f.IsString() {
+			return true
+		}
+	}
+	return false
+}
+
+// UniqueFields returns the fields with a uniqueness constraint
+func (r *Resource) UniqueFields() []Field {
+	var out []Field
+	for _, f := range r.Fields {
+		if f.Unique {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// SearchFields returns the fields matched by the keyword filter
+func (r *Resource) SearchFields() []Field {
+	var out []Field
+	for _, f := range r.Fields {
+		if f.Search {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// RequiredFields returns the fields validated as required
+func (r *Resource) RequiredFields() []Field {
+	var out []Field
+	for _, f := range r.Fields {
+		if f.Required {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// SearchColumns returns the keyword search columns as Go arguments, e.g. "code", "name"
+func (r *Resource) SearchColumns() string {
+	var parts []string
+	for _, f := range r.SearchFields() {
+		parts = append(parts, strconv.Quote(f.Column))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func toSnake(s string) string {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	runes := []rune(s)
+	for i, c := range runes {
+		switch {
+		case c == '-' || c == ' ' || c == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(c):
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])
+			if (prevLower || nextLower) && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// commonInitialisms keeps Go naming conventions for well-known abbreviations
+var commonInitialisms = map[string]string{
+	"id": "ID", "url": "URL", "uuid": "UUID", "api": "API", "sku": "SKU", "ip": "IP", "http": "HTTP",
+}
+
+func toPascal(snake string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snake, "_") {
+		if part == "" {
+			continue
+		}
+		if initialism, ok := commonInitialisms[part]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(upperFirst(part))
+	}
+	return b.String()
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	// Lower a leading initialism as a whole, e.g. SKUCode -> skuCode
+	i := 0
+	for i < len(r) && unicode.IsUpper(r[i]) {
+		i++
+	}
+	if i > 1 && i < len(r) {
+		i--
+	}
+	for j := 0; j < i || j == 0; j++ {
+		r[j] = unicode.ToLower(r[j])
+	}
+	return string(r)
+}
+
+func pluralize(snake string) string {
+	switch {
+	case strings.HasSuffix(snake, "y") && len(snake) > 1 && !strings.ContainsRune("aeiou", rune(snake[len(snake)-2])):
+		return snake[:len(snake)-1] + "ies"
+	case strings.HasSuffix(snake, "s"), strings.HasSuffix(snake, "x"), strings.HasSuffix(snake, "z"),
+		strings.HasSuffix(snake, "ch"), strings.HasSuffix(snake, "sh"):
+		return snake + "es"
+	default:
+		return snake + "s"
+	}
+}
+
+func isIdentifier(snake string) bool {
+	if snake == "" || !unicode.IsLetter(rune(snake[0])) {
+		return false
+	}
+	for _, c := range snake {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package scaffold_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goilerplate/pkg/scaffold"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registeredFiles are the existing files the generator edits
+var registeredFiles = []string{
+	"pkg/constants/permission.go",
+	"internal/wire/repository.go",
+	"internal/wire/usecase.go",
+	"internal/wire/handler.go",
+	"internal/wire/handler_grpc.go",
+	"internal/delivery/http/router/public.go",
+	"internal/delivery/http/router/partner.go",
+	"internal/delivery/http/router/internal.go",
+}
+
+// newFixtureRoot copies the files the generator edits into a temporary repository root
+func newFixtureRoot(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module goilerplate\n"), 0644))
+
+	for _, file := range registeredFiles {
+		content, err := os.ReadFile(filepath.Join("..", "..", file))
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, file), content, 0644))
+	}
+
+	return root
+}
+
+func readFile(t *testing.T, root, file string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(root, file))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestParseFields(t *testing.T) {
+	t.Run("should parse types and modifiers", func(t *testing.T) {
+		fields, err := scaffold.ParseFields("code:string:required:unique:search, unitPrice:float, released_at:datetime")
+
+		require.NoError(t, err)
+		require.Len(t, fields, 3)
+		assert.Equal(t, scaffold.Field{Name: "Code", Column: "code", Type: "string", Required: true, Unique: true, Search: true}, fields[0])
+		assert.Equal(t, scaffold.Field{Name: "UnitPrice", Column: "unit_price", Type: "float64"}, fields[1])
+		assert.Equal(t, "time.Time", fields[2].GoType())
+	})
+
+	tests := map[string]string{
+		"empty spec":          "",
+		"missing type":        "code",
+		"unknown type":        "code:decimal",
+		"unknown modifier":    "code:string:indexed",
+		"duplicate field":     "code:string,code:text",
+		"reserved column":     "created_at:time",
+		"unique non-string":   "qty:int:unique",
+		"required bool":       "paid:bool:required",
+		"invalid identifier":  "9lives:int",
+		"searchable non-text": "price:float64:search",
+	}
+	for name, spec := range tests {
+		t.Run("should reject "+name, func(t *testing.T) {
+			_, err := scaffold.ParseFields(spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	fields, err := scaffold.ParseFields("number:string:required")
+	require.NoError(t, err)
+
+	t.Run("should derive names", func(t *testing.T) {
+		res, err := scaffold.NewResource("PurchaseOrder", fields, nil, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "PurchaseOrder", res.Name)
+		assert.Equal(t, "purchaseOrder", res.Var)
+		assert.Equal(t, "purchaseorder", res.Package)
+		assert.Equal(t, "purchase_order", res.File)
+		assert.Equal(t, "purchase_orders", res.Table)
+		assert.Equal(t, "purchase-orders", res.Path)
+		assert.Equal(t, []string{scaffold.ScopePublic}, res.Scopes)
+	})
+
+	t.Run("should pluralize", func(t *testing.T) {
+		res, err := scaffold.NewResource("category", fields, nil, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "categories", res.Table)
+	})
+
+	t.Run("should reject unknown scopes", func(t *testing.T) {
+		_, err := scaffold.NewResource("product", fields, []string{"admin"}, false)
+		assert.Error(t, err)
+	})
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	root := newFixtureRoot(t)
+	fields, err := scaffold.ParseFields("code:string:required:unique:search,name:string:required:search,price:float64")
+	require.NoError(t, err)
+	res, err := scaffold.NewResource("product", fields, []string{scaffold.ScopePublic, scaffold.ScopeInternal}, true)
+	require.NoError(t, err)
+
+	generator := &scaffold.Generator{Root: root, Out: io.Discard}
+	require.NoError(t, generator.Generate(res, true))
+
+	t.Run("should write every layer", func(t *testing.T) {
+		for _, file := range []string{
+			"internal/domain/product/entity.go",
+			"internal/domain/product/usecase.go",
+			"internal/domain/product/entity_test.go",
+			"internal/domain/product/usecase_test.go",
+			"internal/infrastructure/model/product.go",
+			"internal/infrastructure/repository/product.go",
+			"internal/delivery/http/dto/request/product.go",
+			"internal/delivery/http/dto/response/product.go",
+			"internal/delivery/http/request/product.go",
+			"internal/delivery/http/presenter/product.go",
+			"internal/delivery/http/handler/product.go",
+			"internal/delivery/grpc/handler/product.go",
+		} {
+			assert.FileExists(t, filepath.Join(root, file))
+		}
+
+		migrations, err := filepath.Glob(filepath.Join(root, "internal/migrations/*_create_products_table.*.sql"))
+		require.NoError(t, err)
+		assert.Len(t, migrations, 2)
+	})
+
+	t.Run("should register the resource", func(t *testing.T) {
+		assert.Contains(t, readFile(t, root, "internal/wire/repository.go"), `repository.NewProduct(db, app.Config.Search["products"])`)
+		assert.Contains(t, readFile(t, root, "internal/wire/usecase.go"), "product.NewUseCase(repos.ProductRepo)")
+		assert.Contains(t, readFile(t, root, "internal/wire/handler.go"), "handler.NewProduct(app.Validator, useCases.ProductUC)")
+		assert.Contains(t, readFile(t, root, "internal/wire/handler_grpc.go"), "grpchandler.NewProduct(useCases.ProductUC)")
+		assert.Contains(t, readFile(t, root, "pkg/constants/permission.go"), `PermissionProductCreate = "product.create"`)
+		assert.Contains(t, readFile(t, root, "internal/delivery/http/router/public.go"), "middleware.RequireIdempotencyKey(), r.Wired.Middleware.Idempotency")
+		assert.Contains(t, readFile(t, root, "internal/delivery/http/router/internal.go"), "r.product(internal)")
+		assert.NotContains(t, readFile(t, root, "internal/delivery/http/router/partner.go"), "product")
+	})
+
+	t.Run("should be idempotent", func(t *testing.T) {
+		before := make(map[string]string)
+		for _, file := range registeredFiles {
+			before[file] = readFile(t, root, file)
+		}
+
+		var out bytes.Buffer
+		again := &scaffold.Generator{Root: root, Out: &out}
+		require.NoError(t, again.Generate(res, true))
+
+		for _, file := range registeredFiles {
+			assert.Equal(t, before[file], readFile(t, root, file), file)
+		}
+		assert.NotContains(t, out.String(), "create ")
+		assert.NotContains(t, out.String(), "update ")
+
+		migrations, err := filepath.Glob(filepath.Join(root, "internal/migrations/*.sql"))
+		require.NoError(t, err)
+		assert.Len(t, migrations, 2)
+	})
+
+	t.Run("should keep the registration markers", func(t *testing.T) {
+		for _, file := range registeredFiles {
+			if strings.HasSuffix(file, "permission.go") {
+				continue
+			}
+			assert.Contains(t, readFile(t, root, file), "// scaffold:", file)
+		}
+	})
+}
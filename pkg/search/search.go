@@ -0,0 +1,144 @@
+// Package search builds dialect-aware keyword search conditions for GORM queries.
+// The like mode works on every supported database, the fulltext mode uses a Postgres
+// tsvector (ideally a stored column with a GIN index) and ranks results by relevance.
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Mode selects how keywords are matched
+type Mode string
+
+const (
+	ModeLike     Mode = "like"     // case-insensitive contains on every searched column
+	ModeFullText Mode = "fulltext" // Postgres full-text search, like on other databases
+)
+
+const defaultLanguage = "simple"
+
+// Config is the search configuration of one resource
+type Config struct {
+	Mode     Mode   `mapstructure:"mode"`     // like (default) or fulltext
+	Language string `mapstructure:"language"` // Postgres text search configuration, defaults to simple
+	Column   string `mapstructure:"column"`   // stored tsvector column, computed from the searched columns when empty
+}
+
+// Search matches keywords against a fixed set of columns
+type Search struct {
+	mode     Mode
+	language string
+	vector   string
+	columns  []string
+}
+
+// identifier guards the names that are written into the SQL text
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// New returns a Search over columns. Columns come from the repository, never from client input.
+func New(cfg Config, columns ...string) *Search {
+	s := &Search{
+		mode:     cfg.Mode,
+		language: strings.ToLower(cfg.Language),
+		columns:  columns,
+	}
+	if !identifier.MatchString(s.language) {
+		s.language = defaultLanguage
+	}
+	if identifier.MatchString(cfg.Column) {
+		s.vector = cfg.Column
+	}
+	return s
+}
+
+// likeEscaper escapes LIKE wildcards so keywords are matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// LikePattern returns the lower case "contains" pattern of a keyword with LIKE wildcards escaped.
+// Compare it against LOWER(column), which behaves the same on Postgres and MySQL.
+func LikePattern(keyword string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(keyword)) + "%"
+}
+
+// Apply adds the keyword condition to db, a blank keyword adds nothing
+func (s *Search) Apply(db *gorm.DB, keyword string) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" || len(s.columns) == 0 {
+		return
+	}
+
+	if s.fullText(db) {
+		sql, vars := s.vectorExpr()
+		db.Where(clause.Expr{
+			SQL:  sql + " @@ websearch_to_tsquery('" + s.language + "', ?)",
+			Vars: append(vars, keyword),
+		})
+		return
+	}
+
+	pattern := LikePattern(keyword)
+	exprs := make([]clause.Expression, len(s.columns))
+	for i, column := range s.columns {
+		exprs[i] = clause.Expr{SQL: "LOWER(?) LIKE ?", Vars: []interface{}{currentColumn(column), pattern}}
+	}
+	db.Where(clause.Or(exprs...))
+}
+
+// Rank orders full-text matches by relevance and reports whether it did. Sorts already
+// on db are kept as tie-breakers, so call it after them. The like mode has no ranking.
+func (s *Search) Rank(db *gorm.DB, keyword string) bool {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" || len(s.columns) == 0 || !s.fullText(db) {
+		return false
+	}
+
+	sql, vars := s.vectorExpr()
+	sql = "ts_rank(" + sql + ", websearch_to_tsquery('" + s.language + "', ?)) DESC"
+	vars = append(vars, keyword)
+
+	// An ORDER BY expression replaces its columns, so the existing sorts are folded into it
+	if c, ok := db.Statement.Clauses["ORDER BY"]; ok {
+		if orderBy, ok := c.Expression.(clause.OrderBy); ok && orderBy.Expression == nil {
+			for _, column := range orderBy.Columns {
+				sql += ", ?"
+				if column.Desc {
+					sql += " DESC"
+				}
+				vars = append(vars, column.Column)
+			}
+		}
+	}
+
+	db.Statement.AddClause(clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}})
+	return true
+}
+
+func (s *Search) fullText(db *gorm.DB) bool {
+	return s.mode == ModeFullText && db.Dialector.Name() == "postgres"
+}
+
+// vectorExpr returns the stored tsvector column or the expression computing it. The
+// expression is immutable so it can back an expression index, e.g.
+//
+//	to_tsvector('simple', coalesce(code, '') || ' ' || coalesce(bar, ''))
+func (s *Search) vectorExpr() (string, []interface{}) {
+	if s.vector != "" {
+		return "?", []interface{}{currentColumn(s.vector)}
+	}
+
+	parts := make([]string, len(s.columns))
+	vars := make([]interface{}, len(s.columns))
+	for i, column := range s.columns {
+		parts[i] = "coalesce(?, '')"
+		vars[i] = currentColumn(column)
+	}
+	return "to_tsvector('" + s.language + "', " + strings.Join(parts, " || ' ' || ") + ")", vars
+}
+
+func currentColumn(name string) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: name}
+}
@@ -0,0 +1,123 @@
+package search_test
+
+import (
+	"testing"
+
+	"goilerplate/internal/infrastructure/model"
+	"goilerplate/pkg/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newDryRunDB(t *testing.T, dialector gorm.Dialector) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	require.NoError(t, err)
+
+	return db
+}
+
+func toSQL(db *gorm.DB, fn func(tx *gorm.DB)) *gorm.Statement {
+	var bars []model.Bar
+	return db.Model(&model.Bar{}).Scopes(func(tx *gorm.DB) *gorm.DB {
+		fn(tx)
+		return tx
+	}).Find(&bars).Statement
+}
+
+func TestLikePattern(t *testing.T) {
+	assert.Equal(t, `%50\%\_off%`, search.LikePattern("50%_OFF"))
+}
+
+func TestSearch_Apply(t *testing.T) {
+	postgresDB := newDryRunDB(t, postgres.New(postgres.Config{DSN: "host=localhost"}))
+	mysqlDB := newDryRunDB(t, mysql.New(mysql.Config{DSN: "user@tcp(localhost:3306)/db", SkipInitializeWithVersion: true}))
+
+	t.Run("should match case-insensitively on every column", func(t *testing.T) {
+		s := search.New(search.Config{}, "code", "bar")
+
+		stmt := toSQL(mysqlDB, func(tx *gorm.DB) { s.Apply(tx, " Ab ") })
+
+		assert.Contains(t, stmt.SQL.String(), "(LOWER(`bars`.`code`) LIKE ? OR LOWER(`bars`.`bar`) LIKE ?)")
+		assert.Equal(t, []interface{}{"%ab%", "%ab%"}, stmt.Vars)
+	})
+
+	t.Run("should ignore a blank keyword", func(t *testing.T) {
+		s := search.New(search.Config{}, "code", "bar")
+
+		sql := toSQL(postgresDB, func(tx *gorm.DB) { s.Apply(tx, "  ") }).SQL.String()
+
+		assert.NotContains(t, sql, "LIKE")
+	})
+
+	t.Run("should use the stored vector in fulltext mode", func(t *testing.T) {
+		s := search.New(search.Config{Mode: search.ModeFullText, Language: "english", Column: "search_vector"}, "code", "bar")
+
+		stmt := toSQL(postgresDB, func(tx *gorm.DB) { s.Apply(tx, "red bar") })
+
+		assert.Contains(t, stmt.SQL.String(), `"bars"."search_vector" @@ websearch_to_tsquery('english', $1)`)
+		assert.Equal(t, []interface{}{"red bar"}, stmt.Vars)
+	})
+
+	t.Run("should compute the vector without a stored column", func(t *testing.T) {
+		s := search.New(search.Config{Mode: search.ModeFullText}, "code", "bar")
+
+		sql := toSQL(postgresDB, func(tx *gorm.DB) { s.Apply(tx, "red") }).SQL.String()
+
+		assert.Contains(t, sql, `to_tsvector('simple', coalesce("bars"."code", '') || ' ' || coalesce("bars"."bar", '')) @@ websearch_to_tsquery('simple', $1)`)
+	})
+
+	t.Run("should not inline an invalid language", func(t *testing.T) {
+		s := search.New(search.Config{Mode: search.ModeFullText, Language: "english'); --"}, "code")
+
+		sql := toSQL(postgresDB, func(tx *gorm.DB) { s.Apply(tx, "red") }).SQL.String()
+
+		assert.Contains(t, sql, "websearch_to_tsquery('simple', $1)")
+	})
+
+	t.Run("should fall back to like outside Postgres", func(t *testing.T) {
+		s := search.New(search.Config{Mode: search.ModeFullText, Column: "search_vector"}, "code", "bar")
+
+		sql := toSQL(mysqlDB, func(tx *gorm.DB) { s.Apply(tx, "red") }).SQL.String()
+
+		assert.Contains(t, sql, "LOWER(`bars`.`code`) LIKE ?")
+		assert.NotContains(t, sql, "tsquery")
+	})
+}
+
+func TestSearch_Rank(t *testing.T) {
+	db := newDryRunDB(t, postgres.New(postgres.Config{DSN: "host=localhost"}))
+
+	t.Run("should order by relevance and keep existing sorts", func(t *testing.T) {
+		s := search.New(search.Config{Mode: search.ModeFullText, Column: "search_vector"}, "code", "bar")
+
+		var ranked bool
+		stmt := toSQL(db, func(tx *gorm.DB) {
+			tx.Order("created_at DESC")
+			ranked = s.Rank(tx, "red")
+		})
+
+		assert.True(t, ranked)
+		assert.Contains(t, stmt.SQL.String(), `ORDER BY ts_rank("bars"."search_vector", websearch_to_tsquery('simple', $1)) DESC, created_at DESC`)
+		assert.Equal(t, []interface{}{"red"}, stmt.Vars)
+	})
+
+	t.Run("should not rank in like mode", func(t *testing.T) {
+		s := search.New(search.Config{Mode: search.ModeLike}, "code", "bar")
+
+		var ranked bool
+		sql := toSQL(db, func(tx *gorm.DB) { ranked = s.Rank(tx, "red") }).SQL.String()
+
+		assert.False(t, ranked)
+		assert.NotContains(t, sql, "ORDER BY")
+	})
+}
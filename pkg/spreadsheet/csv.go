@@ -0,0 +1,67 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter returns a Writer producing RFC 4180 CSV
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(row []string) error {
+	escaped := make([]string, len(row))
+	for i, cell := range row {
+		escaped[i] = escapeFormula(cell)
+	}
+	return c.w.Write(escaped)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type csvReader struct {
+	r *csv.Reader
+}
+
+// NewCSVReader returns a Reader for CSV input. Rows may have different lengths
+// and a UTF-8 byte order mark (as written by Excel) is skipped.
+func NewCSVReader(r io.Reader) Reader {
+	reader := csv.NewReader(skipBOM(r))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &csvReader{r: reader}
+}
+
+func (c *csvReader) Read() ([]string, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	row := make([]string, len(record))
+	for i, cell := range record {
+		row[i] = unescapeFormula(cell)
+	}
+	return row, nil
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// skipBOM drops a leading UTF-8 byte order mark
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if head, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(head, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	return br
+}
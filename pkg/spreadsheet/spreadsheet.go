@@ -0,0 +1,88 @@
+// Package spreadsheet reads and writes tabular data as CSV or XLSX, one row at a time,
+// so exports and imports never hold a whole file in memory.
+package spreadsheet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is a supported file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ParseFormat parses a format name or file extension, case-insensitive
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "."))) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("unsupported spreadsheet format %q", s)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes rows to a spreadsheet. Close must be called to complete the file.
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// Reader reads rows from a spreadsheet, it returns io.EOF after the last row
+type Reader interface {
+	Read() ([]string, error)
+}
+
+// File is the input of NewReader, multipart.File satisfies it
+type File interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// NewWriter returns a Writer for format
+func NewWriter(format Format, w io.Writer) Writer {
+	if format == FormatXLSX {
+		return NewXLSXWriter(w)
+	}
+	return NewCSVWriter(w)
+}
+
+// NewReader returns a Reader for format, size is the file size in bytes
+func NewReader(format Format, f File, size int64) (Reader, error) {
+	if format == FormatXLSX {
+		return NewXLSXReader(f, size)
+	}
+	return NewCSVReader(f), nil
+}
+
+// formulaPrefixes start a formula in spreadsheet applications. CSV cells starting
+// with one are prefixed with a quote to prevent formula injection.
+const formulaPrefixes = "=+-@\t\r"
+
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
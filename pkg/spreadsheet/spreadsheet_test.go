@@ -0,0 +1,108 @@
+package spreadsheet_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"goilerplate/pkg/spreadsheet"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rows = [][]string{
+	{"code", "bar"},
+	{"EXP1", `quotes " and, commas`},
+	{"EXP2", "=HYPERLINK(\"http://evil\")"},
+	{"EXP3", "<tag> & ünïcode"},
+}
+
+func readAll(t *testing.T, r spreadsheet.Reader) [][]string {
+	t.Helper()
+
+	var out [][]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return out
+		}
+		require.NoError(t, err)
+		out = append(out, row)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	w := spreadsheet.NewCSVWriter(&buf)
+	for _, row := range rows {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	t.Run("should escape formulas", func(t *testing.T) {
+		assert.Contains(t, buf.String(), `EXP2,"'=HYPERLINK(""http://evil"")"`)
+	})
+
+	t.Run("should round trip", func(t *testing.T) {
+		assert.Equal(t, rows, readAll(t, spreadsheet.NewCSVReader(&buf)))
+	})
+
+	t.Run("should skip the byte order mark", func(t *testing.T) {
+		got := readAll(t, spreadsheet.NewCSVReader(bytes.NewReader([]byte("\xEF\xBB\xBFcode,bar\n"))))
+		assert.Equal(t, [][]string{{"code", "bar"}}, got)
+	})
+}
+
+func TestXLSX(t *testing.T) {
+	t.Run("should round trip", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := spreadsheet.NewXLSXWriter(&buf)
+		for _, row := range rows {
+			require.NoError(t, w.Write(row))
+		}
+		require.NoError(t, w.Close())
+
+		r, err := spreadsheet.NewXLSXReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		assert.Equal(t, rows, readAll(t, r))
+	})
+
+	t.Run("should read shared strings and sparse rows", func(t *testing.T) {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		files := map[string]string{
+			"xl/sharedStrings.xml": `<sst><si><t>code</t></si><si><r><t>EX</t></r><r><t>P1</t></r></si></sst>`,
+			"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+				`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>bar</t></is></c></row>` +
+				`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="B2"><v>42</v></c></row>` +
+				`</sheetData></worksheet>`,
+		}
+		for name, content := range files {
+			f, err := archive.Create(name)
+			require.NoError(t, err)
+			_, err = f.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, archive.Close())
+
+		r, err := spreadsheet.NewXLSXReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"code", "", "bar"}, {"EXP1", "42"}}, readAll(t, r))
+	})
+
+	t.Run("should reject non xlsx input", func(t *testing.T) {
+		_, err := spreadsheet.NewXLSXReader(bytes.NewReader([]byte("code,bar")), 8)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := spreadsheet.ParseFormat(".XLSX")
+	require.NoError(t, err)
+	assert.Equal(t, spreadsheet.FormatXLSX, format)
+
+	_, err = spreadsheet.ParseFormat("ods")
+	assert.Error(t, err)
+}
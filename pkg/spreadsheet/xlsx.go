@@ -0,0 +1,311 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Static parts of a single sheet workbook. The sheet itself is streamed, cells are
+// written as inline strings so no shared string table has to be kept in memory.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewXLSXWriter returns a Writer producing a single sheet XLSX workbook
+func NewXLSXWriter(w io.Writer) Writer {
+	x := &xlsxWriter{zip: zip.NewWriter(w)}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		if x.err = x.writePart(part.name, part.content); x.err != nil {
+			return x
+		}
+	}
+
+	sheet, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		x.err = err
+		return x
+	}
+	x.sheet = bufio.NewWriter(sheet)
+	_, x.err = x.sheet.WriteString(xlsxSheetHeader)
+
+	return x
+}
+
+func (x *xlsxWriter) writePart(name, content string) error {
+	part, err := x.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	if x.err != nil {
+		return x.err
+	}
+
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, cell := range row {
+		fmt.Fprintf(x.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(i), x.rows)
+		if x.err = xml.EscapeText(x.sheet, []byte(cell)); x.err != nil {
+			return x.err
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, x.err = x.sheet.WriteString(`</row>`)
+
+	return x.err
+}
+
+func (x *xlsxWriter) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	if _, err := x.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// columnName converts a zero based column index to its letters, 0 = A, 26 = AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// columnIndex converts a cell reference such as "AB12" to its zero based column index
+func columnIndex(ref string) int {
+	index := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	return index - 1
+}
+
+// Limits protecting the reader from crafted files
+const (
+	MaxXLSXColumns       = 1024     // bounds sparse rows, a cell reference cannot allocate huge rows
+	maxSharedStringsSize = 32 << 20 // uncompressed bytes of the shared string table
+)
+
+type xlsxReader struct {
+	decoder *xml.Decoder
+	sheet   io.Closer
+	shared  []string
+}
+
+// NewXLSXReader returns a Reader for the first sheet of an XLSX workbook. The sheet
+// is decoded as a stream, only the shared string table is held in memory.
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	var sheets []string
+	for _, f := range archive.File {
+		files[f.Name] = f
+		if matched, _ := path.Match("xl/worksheets/sheet*.xml", f.Name); matched {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, errors.New("invalid xlsx file: no worksheet")
+	}
+	sort.Strings(sheets)
+	sheetName := "xl/worksheets/sheet1.xml"
+	if files[sheetName] == nil {
+		sheetName = sheets[0]
+	}
+
+	x := &xlsxReader{}
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		if x.shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := files[sheetName].Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+	x.sheet = sheet
+	x.decoder = xml.NewDecoder(sheet)
+
+	return x, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	for {
+		token, err := x.decoder.Token()
+		if err == io.EOF {
+			x.sheet.Close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx file: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "row" {
+			return x.readRow()
+		}
+	}
+}
+
+// readRow decodes the cells of a <row> element, filling gaps of sparse rows with ""
+func (x *xlsxReader) readRow() ([]string, error) {
+	var row []string
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx file: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				continue
+			}
+			index, value, err := x.readCell(t)
+			if err != nil {
+				return nil, err
+			}
+			if index < 0 {
+				index = len(row)
+			}
+			if index >= MaxXLSXColumns {
+				return nil, fmt.Errorf("invalid xlsx file: more than %d columns", MaxXLSXColumns)
+			}
+			for len(row) <= index {
+				row = append(row, "")
+			}
+			row[index] = value
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				return row, nil
+			}
+		}
+	}
+}
+
+type xlsxCell struct {
+	Ref       string `xml:"r,attr"`
+	Type      string `xml:"t,attr"`
+	Value     string `xml:"v"`
+	Inline    string `xml:"is>t"`
+	InlineRun []struct {
+		Text string `xml:"t"`
+	} `xml:"is>r"`
+}
+
+func (x *xlsxReader) readCell(start xml.StartElement) (int, string, error) {
+	var cell xlsxCell
+	if err := x.decoder.DecodeElement(&cell, &start); err != nil {
+		return 0, "", fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	index := -1
+	if cell.Ref != "" {
+		index = columnIndex(cell.Ref)
+	}
+
+	switch cell.Type {
+	case "s":
+		i, err := strconv.Atoi(cell.Value)
+		if err != nil || i < 0 || i >= len(x.shared) {
+			return 0, "", fmt.Errorf("invalid xlsx file: bad shared string in %s", cell.Ref)
+		}
+		return index, x.shared[i], nil
+	case "inlineStr":
+		if len(cell.InlineRun) > 0 {
+			var b strings.Builder
+			for _, run := range cell.InlineRun {
+				b.WriteString(run.Text)
+			}
+			return index, b.String(), nil
+		}
+		return index, cell.Inline, nil
+	default:
+		return index, cell.Value, nil
+	}
+}
+
+// readSharedStrings loads the shared string table, rich text runs are concatenated
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+	defer rc.Close()
+
+	var table struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(rc, maxSharedStringsSize)).Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	shared := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		if len(item.Runs) == 0 {
+			shared[i] = item.Text
+			continue
+		}
+		var b strings.Builder
+		for _, run := range item.Runs {
+			b.WriteString(run.Text)
+		}
+		shared[i] = b.String()
+	}
+
+	return shared, nil
+}